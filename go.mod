@@ -2,4 +2,7 @@ module github.com/UnitVectorY-Labs/gogitup
 
 go 1.26.0 // GOVERSION
 
-require gopkg.in/yaml.v3 v3.0.1
+require (
+	golang.org/x/mod v0.40.0
+	gopkg.in/yaml.v3 v3.0.1
+)
@@ -0,0 +1,241 @@
+package bundle
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// ManifestName is the name of the manifest entry inside a bundle.
+const ManifestName = "manifest.json"
+
+// Tool describes a single binary stored in a bundle.
+type Tool struct {
+	Name        string `json:"name"`
+	File        string `json:"file"`
+	ModulePath  string `json:"module_path"`
+	PackagePath string `json:"package_path,omitempty"`
+	Version     string `json:"version"`
+	GoVersion   string `json:"go_version"`
+	SHA256      string `json:"sha256"`
+}
+
+// Manifest describes the contents of a bundle.
+type Manifest struct {
+	CreatedAt time.Time `json:"created_at"`
+	GOOS      string    `json:"goos"`
+	GOARCH    string    `json:"goarch"`
+	Tools     []Tool    `json:"tools"`
+}
+
+// Source pairs a manifest tool entry with the binary file to read it from.
+// The SHA256 field of Tool is computed by Write and need not be set.
+type Source struct {
+	Tool Tool
+	Path string
+}
+
+// Write creates a gzip-compressed tarball containing the manifest followed by
+// each source binary under bin/. The manifest is written first so that Extract
+// can verify binaries as they are read.
+func Write(w io.Writer, m Manifest, sources []Source) error {
+	m.Tools = make([]Tool, 0, len(sources))
+	for _, src := range sources {
+		sum, err := fileSHA256(src.Path)
+		if err != nil {
+			return err
+		}
+		tool := src.Tool
+		if tool.File == "" {
+			tool.File = filepath.Base(src.Path)
+		}
+		tool.SHA256 = sum
+		m.Tools = append(m.Tools, tool)
+	}
+
+	manifest, err := json.MarshalIndent(m, "", "  ")
+	if err != nil {
+		return fmt.Errorf("marshaling manifest: %w", err)
+	}
+
+	gz := gzip.NewWriter(w)
+	tw := tar.NewWriter(gz)
+
+	if err := tw.WriteHeader(&tar.Header{
+		Name:    ManifestName,
+		Mode:    0644,
+		Size:    int64(len(manifest)),
+		ModTime: m.CreatedAt,
+	}); err != nil {
+		return err
+	}
+	if _, err := tw.Write(manifest); err != nil {
+		return err
+	}
+
+	for i, src := range sources {
+		if err := addFile(tw, "bin/"+m.Tools[i].File, src.Path); err != nil {
+			return err
+		}
+	}
+
+	if err := tw.Close(); err != nil {
+		return err
+	}
+	return gz.Close()
+}
+
+func addFile(tw *tar.Writer, name, path string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	info, err := f.Stat()
+	if err != nil {
+		return err
+	}
+	if err := tw.WriteHeader(&tar.Header{
+		Name:    name,
+		Mode:    0755,
+		Size:    info.Size(),
+		ModTime: info.ModTime(),
+	}); err != nil {
+		return err
+	}
+	_, err = io.Copy(tw, f)
+	return err
+}
+
+// Extract reads a bundle, writes each binary listed in the manifest into
+// binDir and returns the manifest. Every binary is checked against its
+// recorded SHA-256 checksum before it replaces any existing file, so a
+// corrupted bundle never overwrites a working binary.
+func Extract(r io.Reader, binDir string) (*Manifest, error) {
+	gz, err := gzip.NewReader(r)
+	if err != nil {
+		return nil, fmt.Errorf("reading bundle: %w", err)
+	}
+	defer gz.Close()
+	tr := tar.NewReader(gz)
+
+	hdr, err := tr.Next()
+	if err != nil {
+		return nil, fmt.Errorf("reading bundle: %w", err)
+	}
+	if hdr.Name != ManifestName {
+		return nil, fmt.Errorf("bundle does not start with %s", ManifestName)
+	}
+	var m Manifest
+	if err := json.NewDecoder(tr).Decode(&m); err != nil {
+		return nil, fmt.Errorf("parsing manifest: %w", err)
+	}
+
+	if err := validateManifest(&m); err != nil {
+		return nil, err
+	}
+	expected := make(map[string]Tool, len(m.Tools))
+	for _, tool := range m.Tools {
+		expected["bin/"+tool.File] = tool
+	}
+
+	if err := os.MkdirAll(binDir, 0755); err != nil {
+		return nil, err
+	}
+
+	for {
+		hdr, err := tr.Next()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("reading bundle: %w", err)
+		}
+		tool, ok := expected[hdr.Name]
+		if !ok {
+			return nil, fmt.Errorf("unexpected entry in bundle: %s", hdr.Name)
+		}
+		if err := extractFile(tr, binDir, tool); err != nil {
+			return nil, err
+		}
+		delete(expected, hdr.Name)
+	}
+
+	for name := range expected {
+		return nil, fmt.Errorf("bundle is missing %s", name)
+	}
+	return &m, nil
+}
+
+// validateManifest rejects file names that would be written outside the bin
+// directory and tools listed twice, before anything is extracted.
+func validateManifest(m *Manifest) error {
+	files := make(map[string]bool, len(m.Tools))
+	names := make(map[string]bool, len(m.Tools))
+	for _, tool := range m.Tools {
+		// Bundles move between platforms, so both separators are rejected.
+		if tool.File == "" || tool.File == "." || tool.File == ".." || strings.ContainsAny(tool.File, `/\`) {
+			return fmt.Errorf("invalid file name in manifest: %q", tool.File)
+		}
+		if files[tool.File] {
+			return fmt.Errorf("file %q is listed more than once in the manifest", tool.File)
+		}
+		if names[tool.Name] {
+			return fmt.Errorf("tool %q is listed more than once in the manifest", tool.Name)
+		}
+		files[tool.File] = true
+		names[tool.Name] = true
+	}
+	return nil
+}
+
+// extractFile writes a binary to a temporary file in binDir and renames it
+// into place only once its checksum matches.
+func extractFile(r io.Reader, binDir string, tool Tool) error {
+	tmp, err := os.CreateTemp(binDir, "."+tool.File+".tmp-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	h := sha256.New()
+	if _, err := io.Copy(io.MultiWriter(tmp, h), r); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+
+	if sum := hex.EncodeToString(h.Sum(nil)); sum != tool.SHA256 {
+		return fmt.Errorf("checksum mismatch for %s: expected %s, got %s", tool.Name, tool.SHA256, sum)
+	}
+	if err := os.Chmod(tmp.Name(), 0755); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), filepath.Join(binDir, tool.File))
+}
+
+func fileSHA256(path string) (string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+
+	h := sha256.New()
+	if _, err := io.Copy(h, f); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
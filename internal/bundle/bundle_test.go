@@ -0,0 +1,118 @@
+package bundle
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func writeBinary(t *testing.T, dir, name, content string) string {
+	t.Helper()
+	path := filepath.Join(dir, name)
+	if err := os.WriteFile(path, []byte(content), 0755); err != nil {
+		t.Fatalf("write binary: %v", err)
+	}
+	return path
+}
+
+func TestWriteAndExtractRoundtrip(t *testing.T) {
+	src := t.TempDir()
+	var buf bytes.Buffer
+	err := Write(&buf, Manifest{CreatedAt: time.Date(2024, 1, 15, 10, 30, 0, 0, time.UTC), GOOS: "linux", GOARCH: "arm64"}, []Source{
+		{Tool: Tool{Name: "tool", ModulePath: "github.com/acme/tool", Version: "v1.2.3", GoVersion: "go1.22.0"}, Path: writeBinary(t, src, "tool", "tool-binary")},
+		{Tool: Tool{Name: "other", ModulePath: "example.com/other", Version: "v0.1.0"}, Path: writeBinary(t, src, "other", "other-binary")},
+	})
+	if err != nil {
+		t.Fatalf("Write() error = %v", err)
+	}
+
+	dst := t.TempDir()
+	m, err := Extract(&buf, dst)
+	if err != nil {
+		t.Fatalf("Extract() error = %v", err)
+	}
+	if m.GOOS != "linux" || m.GOARCH != "arm64" || len(m.Tools) != 2 {
+		t.Fatalf("unexpected manifest: %+v", m)
+	}
+	if m.Tools[0].SHA256 == "" || m.Tools[0].File != "tool" {
+		t.Fatalf("expected file name and checksum in manifest, got %+v", m.Tools[0])
+	}
+
+	data, err := os.ReadFile(filepath.Join(dst, "tool"))
+	if err != nil || string(data) != "tool-binary" {
+		t.Fatalf("unexpected extracted binary %q (err=%v)", data, err)
+	}
+	info, err := os.Stat(filepath.Join(dst, "other"))
+	if err != nil || info.Mode().Perm()&0100 == 0 {
+		t.Fatalf("expected executable extracted binary, got %v (err=%v)", info, err)
+	}
+}
+
+func TestExtractChecksumMismatch(t *testing.T) {
+	manifest, _ := json.Marshal(Manifest{Tools: []Tool{{Name: "tool", File: "tool", SHA256: strings.Repeat("0", 64)}}})
+
+	var buf bytes.Buffer
+	gz := gzip.NewWriter(&buf)
+	tw := tar.NewWriter(gz)
+	_ = tw.WriteHeader(&tar.Header{Name: ManifestName, Mode: 0644, Size: int64(len(manifest))})
+	_, _ = tw.Write(manifest)
+	_ = tw.WriteHeader(&tar.Header{Name: "bin/tool", Mode: 0755, Size: 8})
+	_, _ = tw.Write([]byte("tampered"))
+	_ = tw.Close()
+	_ = gz.Close()
+
+	dst := t.TempDir()
+	existing := writeBinary(t, dst, "tool", "original")
+
+	_, err := Extract(&buf, dst)
+	if err == nil || !strings.Contains(err.Error(), "checksum mismatch") {
+		t.Fatalf("expected checksum mismatch error, got %v", err)
+	}
+	data, _ := os.ReadFile(existing)
+	if string(data) != "original" {
+		t.Fatalf("expected existing binary to be left untouched, got %q", data)
+	}
+}
+
+func TestExtractRejectsInvalidManifests(t *testing.T) {
+	tests := []struct {
+		name  string
+		tools []Tool
+	}{
+		{"path traversal", []Tool{{Name: "tool", File: "../tool"}}},
+		{"dot", []Tool{{Name: "tool", File: "."}}},
+		{"dot dot", []Tool{{Name: "tool", File: ".."}}},
+		{"slash", []Tool{{Name: "tool", File: "sub/tool"}}},
+		{"backslash", []Tool{{Name: "tool", File: `sub\tool`}}},
+		{"empty", []Tool{{Name: "tool"}}},
+		{"duplicate file", []Tool{{Name: "a", File: "tool"}, {Name: "b", File: "tool"}}},
+		{"duplicate name", []Tool{{Name: "tool", File: "a"}, {Name: "tool", File: "b"}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			manifest, _ := json.Marshal(Manifest{Tools: tt.tools})
+
+			var buf bytes.Buffer
+			gz := gzip.NewWriter(&buf)
+			tw := tar.NewWriter(gz)
+			_ = tw.WriteHeader(&tar.Header{Name: ManifestName, Mode: 0644, Size: int64(len(manifest))})
+			_, _ = tw.Write(manifest)
+			_ = tw.Close()
+			_ = gz.Close()
+
+			binDir := filepath.Join(t.TempDir(), "bin")
+			if _, err := Extract(&buf, binDir); err == nil {
+				t.Fatal("expected an error for an invalid manifest")
+			}
+			if _, err := os.Stat(binDir); !os.IsNotExist(err) {
+				t.Fatal("expected nothing to be written for an invalid manifest")
+			}
+		})
+	}
+}
@@ -2,10 +2,14 @@ package cache
 
 import (
 	"errors"
+	"fmt"
 	"os"
 	"path/filepath"
+	"strings"
 	"time"
 
+	"github.com/UnitVectorY-Labs/gogitup/internal/fileutil"
+	"github.com/UnitVectorY-Labs/gogitup/internal/xdg"
 	"gopkg.in/yaml.v3"
 )
 
@@ -17,25 +21,113 @@ type Entry struct {
 	LatestVersion    string    `yaml:"latest_version"`
 	InstalledVersion string    `yaml:"installed_version,omitempty"`
 	CheckedAt        time.Time `yaml:"checked_at"`
+	// Verification is the checksum database status of LatestVersion, such as
+	// "verified" or "mismatch", with the reason for any other status.
+	Verification       string `yaml:"verification,omitempty"`
+	VerificationDetail string `yaml:"verification_detail,omitempty"`
+	// Retracted records that InstalledVersion was retracted by the module
+	// author, and Deprecated holds the module's deprecation message.
+	Retracted           bool   `yaml:"retracted,omitempty"`
+	RetractionRationale string `yaml:"retraction_rationale,omitempty"`
+	Deprecated          string `yaml:"deprecated,omitempty"`
+	// NotifiedVersion is the latest version that check --notify has
+	// reported for InstalledVersion, so each update is only announced once.
+	NotifiedVersion string `yaml:"notified_version,omitempty"`
+	// BinaryPath and BinaryModTime identify the binary InstalledVersion was
+	// read from, so that status can tell it has not changed without running
+	// go version.
+	BinaryPath    string    `yaml:"binary_path,omitempty"`
+	BinaryModTime time.Time `yaml:"binary_mod_time,omitempty"`
+	// ModulePath is the module the app is built from, which tells cache prune
+	// the stored responses that still belong to a registered app.
+	ModulePath string `yaml:"module_path,omitempty"`
+}
+
+// Resolution is a cached resolution of a vanity import path to the
+// repositories that serve it. Empty repository paths record that the path
+// could not be resolved.
+type Resolution struct {
+	// Prefix is the import path prefix served by the repository root.
+	Prefix string `yaml:"prefix,omitempty"`
+	// RepoPath and SourcePath are the repository locations, such as
+	// "github.com/dominikh/go-tools", from the go-import and go-source tags.
+	RepoPath   string    `yaml:"repo_path,omitempty"`
+	SourcePath string    `yaml:"source_path,omitempty"`
+	CheckedAt  time.Time `yaml:"checked_at"`
+}
+
+// ResolutionTTL is how long vanity import path resolutions stay valid. They
+// change far less often than release versions.
+const ResolutionTTL = 7 * 24 * time.Hour
+
+// Response holds the validators of an API response and the value parsed from
+// it, keyed by request URL, so that later requests can be conditional.
+type Response struct {
+	ETag         string `yaml:"etag,omitempty"`
+	LastModified string `yaml:"last_modified,omitempty"`
+	Value        string `yaml:"value"`
 }
 
 // Cache represents the gogitup cache file.
 type Cache struct {
-	Entries map[string]Entry `yaml:"entries"`
+	Entries     map[string]Entry      `yaml:"entries"`
+	Resolutions map[string]Resolution `yaml:"resolutions,omitempty"`
+	Responses   map[string]Response   `yaml:"responses,omitempty"`
 }
 
-// DefaultPath returns the default cache file path (~/.gogitup.cache).
+// EnvPath is the environment variable that overrides the cache file path.
+const EnvPath = "GOGITUP_CACHE"
+
+// DefaultPath returns the cache file path: $GOGITUP_CACHE when set, otherwise
+// $XDG_CACHE_HOME/gogitup/cache.yaml. The legacy ~/.gogitup.cache is returned
+// while it exists and has not been migrated.
 func DefaultPath() string {
-	home, err := os.UserHomeDir()
-	if err != nil {
-		return filepath.Join(".", ".gogitup.cache")
+	return xdg.Resolve(EnvPath, xdgPath(), LegacyPath())
+}
+
+// LegacyPath returns the cache file path used before XDG support (~/.gogitup.cache).
+func LegacyPath() string {
+	return xdg.HomePath(".gogitup.cache")
+}
+
+func xdgPath() string {
+	return filepath.Join(xdg.CacheHome(), "gogitup", "cache.yaml")
+}
+
+// MigrateLegacy moves ~/.gogitup.cache to the XDG cache location unless the
+// path is overridden by $GOGITUP_CACHE or the XDG file already exists. It
+// returns the new path when a file was moved.
+func MigrateLegacy() (string, error) {
+	if os.Getenv(EnvPath) != "" {
+		return "", nil
+	}
+	moved, err := xdg.Migrate(xdgPath(), LegacyPath())
+	if err != nil || !moved {
+		return "", err
 	}
-	return filepath.Join(home, ".gogitup.cache")
+	return xdgPath(), nil
 }
 
 // Load reads and parses the cache file at the given path.
 // If the file does not exist, an empty Cache is returned without error.
 func Load(path string) (*Cache, error) {
+	c, err := LoadReadOnly(path)
+	var corrupt *CorruptError
+	if errors.As(err, &corrupt) {
+		// The cache only holds data that can be fetched again, so a damaged
+		// file is moved aside and replaced with an empty cache.
+		corrupt.MovedTo = path + ".corrupt"
+		if renameErr := os.Rename(path, corrupt.MovedTo); renameErr != nil {
+			corrupt.MovedTo = ""
+		}
+	}
+	return c, err
+}
+
+// LoadReadOnly reads the cache file like Load, but never changes it: a
+// damaged file is left in place, for a caller that does not hold the state
+// lock.
+func LoadReadOnly(path string) (*Cache, error) {
 	data, err := os.ReadFile(path)
 	if err != nil {
 		if errors.Is(err, os.ErrNotExist) {
@@ -46,7 +138,7 @@ func Load(path string) (*Cache, error) {
 
 	var c Cache
 	if err := yaml.Unmarshal(data, &c); err != nil {
-		return nil, err
+		return &Cache{Entries: make(map[string]Entry)}, &CorruptError{Path: path, Err: err}
 	}
 	if c.Entries == nil {
 		c.Entries = make(map[string]Entry)
@@ -54,13 +146,35 @@ func Load(path string) (*Cache, error) {
 	return &c, nil
 }
 
-// Save writes the cache to the given file path.
+// CorruptError reports a cache file that could not be parsed. Load and
+// LoadReadOnly return it together with an empty, usable Cache.
+type CorruptError struct {
+	Path string
+	// MovedTo is where the damaged file was moved, or empty if it could not be.
+	MovedTo string
+	Err     error
+}
+
+func (e *CorruptError) Error() string {
+	msg := fmt.Sprintf("cache file %s is truncated or corrupt (%v); starting with an empty cache", e.Path, e.Err)
+	if e.MovedTo != "" {
+		msg += fmt.Sprintf(", damaged file moved to %s", e.MovedTo)
+	}
+	return msg
+}
+
+func (e *CorruptError) Unwrap() error {
+	return e.Err
+}
+
+// Save atomically writes the cache to the given file path, creating its
+// directory if needed.
 func Save(path string, c *Cache) error {
 	data, err := yaml.Marshal(c)
 	if err != nil {
 		return err
 	}
-	return os.WriteFile(path, data, 0600)
+	return fileutil.WriteAtomic(path, data, 0600)
 }
 
 // Get returns the cache entry for the given app name and whether it was found.
@@ -76,11 +190,30 @@ func Set(c *Cache, name string, version string) {
 
 // SetForInstalledVersion caches a version check for a specific installed version.
 func SetForInstalledVersion(c *Cache, name, installedVersion, latestVersion string) {
-	c.Entries[name] = Entry{
+	entry := Entry{
 		LatestVersion:    latestVersion,
 		InstalledVersion: installedVersion,
 		CheckedAt:        time.Now(),
 	}
+	// Notifications already sent, and the binary the version was read from,
+	// still apply while the same version is installed.
+	if old, ok := c.Entries[name]; ok && old.InstalledVersion == installedVersion {
+		entry.NotifiedVersion = old.NotifiedVersion
+		entry.BinaryPath, entry.BinaryModTime = old.BinaryPath, old.BinaryModTime
+	}
+	c.Entries[name] = entry
+}
+
+// SetBinary records that the installed version cached for name was read from
+// the binary at path, last modified at modTime. It does nothing when name has
+// no entry for installedVersion.
+func SetBinary(c *Cache, name, installedVersion, path string, modTime time.Time) {
+	entry, ok := c.Entries[name]
+	if !ok || entry.InstalledVersion != installedVersion {
+		return
+	}
+	entry.BinaryPath, entry.BinaryModTime = path, modTime
+	c.Entries[name] = entry
 }
 
 // IsExpired checks if a cache entry is older than the given TTL.
@@ -88,7 +221,107 @@ func IsExpired(entry Entry, ttl time.Duration) bool {
 	return time.Since(entry.CheckedAt) > ttl
 }
 
-// Remove removes the cache entry for the given app name.
+// PlatformKey returns the cache key for an app built for a specific platform,
+// such as "linux/arm64". An empty platform returns the app name unchanged so
+// native installs keep their existing keys.
+func PlatformKey(name, platform string) string {
+	if platform == "" {
+		return name
+	}
+	return name + "@" + platform
+}
+
+// Remove removes the cache entry for the given app name, including entries
+// recorded for other platforms.
 func Remove(c *Cache, name string) {
-	delete(c.Entries, name)
+	for key := range c.Entries {
+		if key == name || strings.HasPrefix(key, name+"@") {
+			delete(c.Entries, key)
+		}
+	}
+}
+
+// AppName returns the app name a cache key belongs to, stripping any platform
+// suffix added by PlatformKey.
+func AppName(key string) string {
+	name, _, _ := strings.Cut(key, "@")
+	return name
+}
+
+// Prune removes entries whose app is not in names, and vanity import path
+// resolutions that have expired. It returns the removed entry keys and import
+// paths.
+func Prune(c *Cache, names []string) []string {
+	keep := make(map[string]bool, len(names))
+	for _, name := range names {
+		keep[name] = true
+	}
+	var removed []string
+	for key := range c.Entries {
+		if !keep[AppName(key)] {
+			delete(c.Entries, key)
+			removed = append(removed, key)
+		}
+	}
+	for importPath, res := range c.Resolutions {
+		if time.Since(res.CheckedAt) > ResolutionTTL {
+			delete(c.Resolutions, importPath)
+			removed = append(removed, importPath)
+		}
+	}
+	return removed
+}
+
+// PruneResponses removes the stored responses whose request URL keep rejects
+// and returns their URLs.
+func PruneResponses(c *Cache, keep func(url string) bool) []string {
+	var removed []string
+	for url := range c.Responses {
+		if !keep(url) {
+			delete(c.Responses, url)
+			removed = append(removed, url)
+		}
+	}
+	return removed
+}
+
+// Clear removes every entry, resolution, and stored response from the cache.
+func Clear(c *Cache) {
+	c.Entries = make(map[string]Entry)
+	c.Resolutions = nil
+	c.Responses = nil
+}
+
+// GetResolution returns the cached resolution of a vanity import path if it
+// has not expired.
+func GetResolution(c *Cache, importPath string) (Resolution, bool) {
+	res, ok := c.Resolutions[importPath]
+	if !ok || time.Since(res.CheckedAt) > ResolutionTTL {
+		return Resolution{}, false
+	}
+	return res, true
+}
+
+// SetResolution caches the resolution of a vanity import path with the
+// current time.
+func SetResolution(c *Cache, importPath string, res Resolution) {
+	if c.Resolutions == nil {
+		c.Resolutions = make(map[string]Resolution)
+	}
+	res.CheckedAt = time.Now()
+	c.Resolutions[importPath] = res
+}
+
+// GetResponse returns the stored response for a request URL.
+func GetResponse(c *Cache, url string) (Response, bool) {
+	r, ok := c.Responses[url]
+	return r, ok
+}
+
+// SetResponse stores the response for a request URL.
+func SetResponse(c *Cache, url string, r Response) {
+	if c.Responses == nil {
+		c.Responses = make(map[string]Response)
+	}
+	c.Responses[url] = r
 }
@@ -1,7 +1,10 @@
 package cache
 
 import (
+	"errors"
+	"os"
 	"path/filepath"
+	"strings"
 	"testing"
 	"time"
 )
@@ -99,6 +102,43 @@ func TestSetForInstalledVersion(t *testing.T) {
 	}
 }
 
+func TestSetForInstalledVersionKeepsNotifiedVersion(t *testing.T) {
+	c := &Cache{Entries: map[string]Entry{
+		"app": {InstalledVersion: "v1.0.0", LatestVersion: "v1.1.0", NotifiedVersion: "v1.1.0"},
+	}}
+
+	SetForInstalledVersion(c, "app", "v1.0.0", "v1.2.0")
+	if got := c.Entries["app"].NotifiedVersion; got != "v1.1.0" {
+		t.Fatalf("expected notified version to be kept, got %q", got)
+	}
+
+	SetForInstalledVersion(c, "app", "v1.2.0", "v1.2.0")
+	if got := c.Entries["app"].NotifiedVersion; got != "" {
+		t.Fatalf("expected notified version to reset after an upgrade, got %q", got)
+	}
+}
+
+func TestSetBinary(t *testing.T) {
+	modTime := time.Date(2026, 10, 1, 12, 0, 0, 0, time.UTC)
+	c := &Cache{Entries: map[string]Entry{"app": {InstalledVersion: "v1.0.0", LatestVersion: "v1.1.0"}}}
+
+	SetBinary(c, "app", "v0.9.0", "/go/bin/app", modTime)
+	SetBinary(c, "missing", "v1.0.0", "/go/bin/missing", modTime)
+	if c.Entries["app"].BinaryPath != "" || len(c.Entries) != 1 {
+		t.Fatalf("expected no change for another version or app, got %+v", c.Entries)
+	}
+
+	SetBinary(c, "app", "v1.0.0", "/go/bin/app", modTime)
+	SetForInstalledVersion(c, "app", "v1.0.0", "v1.2.0")
+	if e := c.Entries["app"]; e.BinaryPath != "/go/bin/app" || !e.BinaryModTime.Equal(modTime) {
+		t.Fatalf("expected the binary to be kept for the same version, got %+v", e)
+	}
+	SetForInstalledVersion(c, "app", "v1.2.0", "v1.2.0")
+	if e := c.Entries["app"]; e.BinaryPath != "" {
+		t.Fatalf("expected the binary to reset after an upgrade, got %+v", e)
+	}
+}
+
 func TestIsExpired(t *testing.T) {
 	recent := Entry{
 		LatestVersion: "v1.0.0",
@@ -142,3 +182,182 @@ func TestRemove(t *testing.T) {
 		t.Fatal("expected app2 to still exist")
 	}
 }
+
+func TestPlatformKey(t *testing.T) {
+	if got := PlatformKey("tool", ""); got != "tool" {
+		t.Fatalf("expected tool, got %s", got)
+	}
+	if got := PlatformKey("tool", "linux/arm64"); got != "tool@linux/arm64" {
+		t.Fatalf("expected tool@linux/arm64, got %s", got)
+	}
+}
+
+func TestRemoveIncludesPlatformEntries(t *testing.T) {
+	c := &Cache{Entries: map[string]Entry{
+		"tool":              {LatestVersion: "v1.0.0"},
+		"tool@linux/arm64":  {LatestVersion: "v1.0.0"},
+		"toolbox":           {LatestVersion: "v2.0.0"},
+		"toolbox@linux/arm": {LatestVersion: "v2.0.0"},
+	}}
+	Remove(c, "tool")
+
+	if len(c.Entries) != 2 {
+		t.Fatalf("expected 2 remaining entries, got %v", c.Entries)
+	}
+	if _, ok := c.Entries["toolbox@linux/arm"]; !ok {
+		t.Fatal("expected toolbox platform entry to remain")
+	}
+}
+
+func TestAppName(t *testing.T) {
+	if got := AppName("tool@linux/arm64"); got != "tool" {
+		t.Fatalf("expected tool, got %s", got)
+	}
+	if got := AppName("tool"); got != "tool" {
+		t.Fatalf("expected tool, got %s", got)
+	}
+}
+
+func TestPrune(t *testing.T) {
+	c := &Cache{Entries: map[string]Entry{
+		"kept":             {LatestVersion: "v1.0.0"},
+		"kept@linux/arm64": {LatestVersion: "v1.0.0"},
+		"gone":             {LatestVersion: "v1.0.0"},
+		"gone@linux/arm64": {LatestVersion: "v1.0.0"},
+	}}
+
+	removed := Prune(c, []string{"kept"})
+
+	if len(removed) != 2 {
+		t.Fatalf("expected 2 removed keys, got %v", removed)
+	}
+	if _, ok := c.Entries["kept@linux/arm64"]; !ok || len(c.Entries) != 2 {
+		t.Fatalf("unexpected remaining entries: %v", c.Entries)
+	}
+}
+
+func TestPruneRemovesExpiredResolutions(t *testing.T) {
+	c := &Cache{
+		Entries: map[string]Entry{},
+		Resolutions: map[string]Resolution{
+			"fresh.example.com/tool": {CheckedAt: time.Now()},
+			"stale.example.com/tool": {CheckedAt: time.Now().Add(-ResolutionTTL - time.Hour)},
+		},
+	}
+
+	removed := Prune(c, nil)
+
+	if len(removed) != 1 || removed[0] != "stale.example.com/tool" {
+		t.Fatalf("expected the stale resolution to be removed, got %v", removed)
+	}
+	if _, ok := c.Resolutions["fresh.example.com/tool"]; !ok {
+		t.Fatal("expected the fresh resolution to be kept")
+	}
+}
+
+func TestPruneResponses(t *testing.T) {
+	c := &Cache{Responses: map[string]Response{
+		"https://api.github.com/repos/owner/kept/releases/latest": {Value: "v1.0.0"},
+		"https://api.github.com/repos/owner/gone/releases/latest": {Value: "v1.0.0"},
+	}}
+
+	removed := PruneResponses(c, func(url string) bool { return strings.Contains(url, "/kept/") })
+
+	if len(removed) != 1 || !strings.Contains(removed[0], "/gone/") || len(c.Responses) != 1 {
+		t.Fatalf("unexpected pruning: removed %v, remaining %v", removed, c.Responses)
+	}
+}
+
+func TestClear(t *testing.T) {
+	c := &Cache{Entries: map[string]Entry{"app": {LatestVersion: "v1.0.0"}}}
+	Clear(c)
+	if len(c.Entries) != 0 {
+		t.Fatalf("expected empty cache, got %v", c.Entries)
+	}
+}
+
+func TestLoadCorruptCacheIsMovedAside(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "cache.yaml")
+	if err := os.WriteFile(path, []byte("entries:\n  app1:\n    latest_version: [v1"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	c, err := Load(path)
+	var corrupt *CorruptError
+	if !errors.As(err, &corrupt) {
+		t.Fatalf("expected CorruptError, got %v", err)
+	}
+	if c == nil || len(c.Entries) != 0 {
+		t.Fatalf("expected an empty usable cache, got %+v", c)
+	}
+	if _, err := os.Stat(path + ".corrupt"); err != nil {
+		t.Fatalf("expected damaged file to be moved aside: %v", err)
+	}
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		t.Fatalf("expected original path to be free, got %v", err)
+	}
+}
+
+func TestLoadReadOnlyLeavesCorruptCache(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "cache.yaml")
+	if err := os.WriteFile(path, []byte("entries:\n  app1:\n    latest_version: [v1"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	c, err := LoadReadOnly(path)
+	var corrupt *CorruptError
+	if !errors.As(err, &corrupt) || corrupt.MovedTo != "" {
+		t.Fatalf("expected CorruptError without a move, got %v", err)
+	}
+	if c == nil || len(c.Entries) != 0 {
+		t.Fatalf("expected an empty usable cache, got %+v", c)
+	}
+	if _, err := os.Stat(path); err != nil {
+		t.Fatalf("expected damaged file to stay in place: %v", err)
+	}
+	if _, err := os.Stat(path + ".corrupt"); !os.IsNotExist(err) {
+		t.Fatalf("expected nothing moved aside, got %v", err)
+	}
+}
+
+func TestResolutions(t *testing.T) {
+	c := &Cache{Entries: make(map[string]Entry)}
+	if _, ok := GetResolution(c, "honnef.co/go/tools"); ok {
+		t.Fatal("expected no resolution in an empty cache")
+	}
+
+	SetResolution(c, "honnef.co/go/tools", Resolution{Prefix: "honnef.co/go/tools", RepoPath: "github.com/dominikh/go-tools"})
+	res, ok := GetResolution(c, "honnef.co/go/tools")
+	if !ok || res.RepoPath != "github.com/dominikh/go-tools" || res.CheckedAt.IsZero() {
+		t.Fatalf("unexpected resolution: %+v (found=%v)", res, ok)
+	}
+
+	c.Resolutions["old.example/tool"] = Resolution{CheckedAt: time.Now().Add(-ResolutionTTL - time.Hour)}
+	if _, ok := GetResolution(c, "old.example/tool"); ok {
+		t.Fatal("expected expired resolution to be ignored")
+	}
+
+	Clear(c)
+	if len(c.Resolutions) != 0 {
+		t.Fatalf("expected Clear to remove resolutions, got %v", c.Resolutions)
+	}
+}
+
+func TestResponses(t *testing.T) {
+	c := &Cache{Entries: make(map[string]Entry)}
+	url := "https://api.github.com/repos/owner/repo/releases/latest"
+	if _, ok := GetResponse(c, url); ok {
+		t.Fatal("expected no response in an empty cache")
+	}
+
+	SetResponse(c, url, Response{ETag: `"abc"`, Value: "v1.0.0"})
+	r, ok := GetResponse(c, url)
+	if !ok || r.ETag != `"abc"` || r.Value != "v1.0.0" {
+		t.Fatalf("unexpected response: %+v (found=%v)", r, ok)
+	}
+
+	Clear(c)
+	if len(c.Responses) != 0 {
+		t.Fatalf("expected Clear to remove responses, got %v", c.Responses)
+	}
+}
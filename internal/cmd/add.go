@@ -1,7 +1,10 @@
 package cmd
 
 import (
+	"errors"
+	"flag"
 	"fmt"
+	"io"
 	"os"
 
 	"github.com/UnitVectorY-Labs/gogitup/internal/config"
@@ -9,13 +12,41 @@ import (
 	"github.com/UnitVectorY-Labs/gogitup/internal/output"
 )
 
+const addUsage = "Usage: gogitup add <binary-name> [--create-profile]"
+
+type addOptions struct {
+	Name          string
+	CreateProfile bool
+}
+
+func parseAddOptions(args []string, stderr io.Writer) (addOptions, error) {
+	fs := flag.NewFlagSet("add", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+
+	var opts addOptions
+	addCreateProfileFlag(fs, &opts.CreateProfile)
+	positional, err := parseInterspersed(fs, args)
+	if err != nil {
+		return addOptions{}, err
+	}
+	if len(positional) != 1 {
+		return addOptions{}, errors.New(addUsage)
+	}
+	opts.Name = positional[0]
+	return opts, nil
+}
+
 func runAdd(args []string) {
-	if len(args) < 1 {
-		output.Error("Usage: gogitup add <binary-name>")
+	opts, err := parseAddOptions(args, output.ErrorWriter.Out)
+	if err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return
+		}
+		output.Error(err.Error())
 		os.Exit(1)
 	}
 
-	name := args[0]
+	name := opts.Name
 
 	runner := &goversion.DefaultRunner{}
 	info, err := runner.GetInfo(name)
@@ -24,8 +55,10 @@ func runAdd(args []string) {
 		os.Exit(1)
 	}
 
-	cfgPath := config.DefaultPath()
-	cfg, err := config.Load(cfgPath)
+	defer lockState()()
+
+	cfgPath := configPath()
+	cfg, err := loadProfileToEdit(cfgPath, opts.CreateProfile)
 	if err != nil {
 		output.Error(fmt.Sprintf("Failed to load config: %v", err))
 		os.Exit(1)
@@ -47,3 +80,31 @@ func runAdd(args []string) {
 
 	output.Success(fmt.Sprintf("Added '%s' (%s)", name, info.Path))
 }
+
+// addCreateProfileFlag adds the --create-profile flag of the commands that
+// register apps.
+func addCreateProfileFlag(fs *flag.FlagSet, create *bool) {
+	fs.BoolVar(create, "create-profile", false, "Create the profile selected with --profile if it does not exist")
+}
+
+// loadProfileToEdit loads the selected profile for a command that registers
+// apps. A profile that does not exist is created only when create is set, so
+// that a misspelled --profile is not saved as a new profile.
+func loadProfileToEdit(path string, create bool) (*config.Config, error) {
+	name := profileName()
+	if create && name == "" {
+		return nil, errors.New("--create-profile requires --profile or " + envProfile)
+	}
+	cfg, err := config.Load(path)
+	if err != nil || name == "" {
+		return cfg, err
+	}
+	if create {
+		return config.CreateProfile(cfg, name), nil
+	}
+	view, err := config.SelectProfile(cfg, name)
+	if errors.Is(err, config.ErrUnknownProfile) {
+		return nil, fmt.Errorf("%w; use --create-profile to create it", err)
+	}
+	return view, err
+}
@@ -0,0 +1,235 @@
+package cmd
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"time"
+
+	"github.com/UnitVectorY-Labs/gogitup/internal/bundle"
+	"github.com/UnitVectorY-Labs/gogitup/internal/config"
+	"github.com/UnitVectorY-Labs/gogitup/internal/goversion"
+	"github.com/UnitVectorY-Labs/gogitup/internal/installer"
+	"github.com/UnitVectorY-Labs/gogitup/internal/output"
+)
+
+type bundleOptions struct {
+	Out string
+	targetOptions
+}
+
+func parseBundleOptions(args []string, stderr io.Writer) (bundleOptions, error) {
+	fs := flag.NewFlagSet("bundle", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+
+	var opts bundleOptions
+	fs.StringVar(&opts.Out, "out", "", "Path of the tarball to write")
+	addTargetFlags(fs, &opts.targetOptions)
+	if err := fs.Parse(args); err != nil {
+		return bundleOptions{}, err
+	}
+	if opts.Out == "" || fs.NArg() != 0 {
+		return bundleOptions{}, errors.New("Usage: gogitup bundle --out <file.tar.gz> [--os <goos>] [--arch <goarch>] [--bin-dir <dir>]")
+	}
+	return opts, nil
+}
+
+func runBundle(args []string) {
+	opts, err := parseBundleOptions(args, output.ErrorWriter.Out)
+	if err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return
+		}
+		output.Error(err.Error())
+		os.Exit(1)
+	}
+
+	cfgPath := configPath()
+	cfg, err := config.LoadProfile(cfgPath, profileName())
+	if err != nil {
+		output.Error(fmt.Sprintf("Failed to load config: %v", err))
+		os.Exit(1)
+	}
+
+	if len(cfg.Apps) == 0 {
+		output.Info("No binaries registered. Use 'gogitup add <name>' to add one.")
+		return
+	}
+
+	target, err := resolveTarget(opts.targetOptions, cfg, config.App{})
+	if err != nil {
+		output.Error(fmt.Sprintf("Failed to resolve target platform: %v", err))
+		os.Exit(1)
+	}
+
+	manifest := bundle.Manifest{CreatedAt: time.Now().UTC(), GOOS: runtime.GOOS, GOARCH: runtime.GOARCH}
+	if platform := target.platform; !platform.IsZero() {
+		manifest.GOOS, manifest.GOARCH = platform.GOOS, platform.GOARCH
+	}
+
+	sources := collectBundleSources(cfg, newTargetRunner(target), target.platform, target.binDir)
+	if len(sources) == 0 {
+		output.Error("No registered binaries could be bundled")
+		os.Exit(1)
+	}
+
+	f, err := os.Create(opts.Out)
+	if err != nil {
+		output.Error(fmt.Sprintf("Failed to create bundle: %v", err))
+		os.Exit(1)
+	}
+	if err := bundle.Write(f, manifest, sources); err != nil {
+		f.Close()
+		os.Remove(opts.Out)
+		output.Error(fmt.Sprintf("Failed to write bundle: %v", err))
+		os.Exit(1)
+	}
+	if err := f.Close(); err != nil {
+		output.Error(fmt.Sprintf("Failed to write bundle: %v", err))
+		os.Exit(1)
+	}
+
+	output.Success(fmt.Sprintf("Bundled %d binary(ies) for %s/%s into %s", len(sources), manifest.GOOS, manifest.GOARCH, opts.Out))
+}
+
+// collectBundleSources resolves each registered app to its binary file and
+// build metadata, warning about and skipping apps that cannot be resolved.
+func collectBundleSources(cfg *config.Config, runner goversion.Runner, platform installer.Target, binDir string) []bundle.Source {
+	sources := make([]bundle.Source, 0, len(cfg.Apps))
+	for _, app := range cfg.Apps {
+		info, err := runner.GetInfo(app.Name)
+		if err != nil {
+			output.Warn(fmt.Sprintf("Skipping '%s': %v", app.Name, err))
+			continue
+		}
+		path, err := binaryPath(app.Name, platform, binDir)
+		if err != nil {
+			output.Warn(fmt.Sprintf("Skipping '%s': %v", app.Name, err))
+			continue
+		}
+		sources = append(sources, bundle.Source{
+			Tool: bundle.Tool{
+				Name:        app.Name,
+				ModulePath:  info.Path,
+				PackagePath: info.PackagePath,
+				Version:     info.Version,
+				GoVersion:   info.GoVersion,
+			},
+			Path: path,
+		})
+	}
+	return sources
+}
+
+// binaryPath returns the file for a registered binary: the executable on PATH
+// for native installs, or the file in binDir for cross-compiled ones.
+func binaryPath(name string, platform installer.Target, binDir string) (string, error) {
+	if platform.IsZero() {
+		path, err := exec.LookPath(name)
+		if err != nil {
+			return "", fmt.Errorf("binary not found on PATH: %s", name)
+		}
+		return path, nil
+	}
+	if platform.GOOS == "windows" {
+		name += ".exe"
+	}
+	return filepath.Join(binDir, name), nil
+}
+
+type unbundleOptions struct {
+	File   string
+	BinDir string
+}
+
+func parseUnbundleOptions(args []string, stderr io.Writer) (unbundleOptions, error) {
+	fs := flag.NewFlagSet("unbundle", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+
+	var opts unbundleOptions
+	fs.StringVar(&opts.BinDir, "bin-dir", "", "Directory to install the bundled binaries into (default GOBIN)")
+	positional, err := parseInterspersed(fs, args)
+	if err != nil {
+		return unbundleOptions{}, err
+	}
+	if len(positional) != 1 {
+		return unbundleOptions{}, errors.New("Usage: gogitup unbundle <file.tar.gz> [--bin-dir <dir>]")
+	}
+	opts.File = positional[0]
+	return opts, nil
+}
+
+func runUnbundle(args []string) {
+	opts, err := parseUnbundleOptions(args, output.ErrorWriter.Out)
+	if err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return
+		}
+		output.Error(err.Error())
+		os.Exit(1)
+	}
+
+	defer lockState()()
+
+	binDir := opts.BinDir
+	if binDir == "" {
+		binDir, err = installer.DefaultBinDir()
+		if err != nil {
+			output.Error(fmt.Sprintf("Failed to determine bin directory: %v", err))
+			os.Exit(1)
+		}
+	}
+
+	cfgPath := configPath()
+	cfg, err := config.LoadProfile(cfgPath, profileName())
+	if err != nil {
+		output.Error(fmt.Sprintf("Failed to load config: %v", err))
+		os.Exit(1)
+	}
+
+	f, err := os.Open(opts.File)
+	if err != nil {
+		output.Error(fmt.Sprintf("Failed to open bundle: %v", err))
+		os.Exit(1)
+	}
+	manifest, err := bundle.Extract(f, binDir)
+	f.Close()
+	if err != nil {
+		output.Error(fmt.Sprintf("Failed to unbundle: %v", err))
+		os.Exit(1)
+	}
+
+	if manifest.GOOS != runtime.GOOS || manifest.GOARCH != runtime.GOARCH {
+		output.Warn(fmt.Sprintf("Bundle was built for %s/%s; binaries may not run on this machine", manifest.GOOS, manifest.GOARCH))
+	}
+
+	registerBundledTools(cfg, manifest)
+
+	if err := config.Save(cfgPath, cfg); err != nil {
+		output.Error(fmt.Sprintf("Failed to save config: %v", err))
+		os.Exit(1)
+	}
+
+	output.Success(fmt.Sprintf("Installed %d binary(ies) into %s", len(manifest.Tools), binDir))
+}
+
+// registerBundledTools adds every tool in the manifest that is not already
+// registered, recording the package path for non-GitHub modules as add does.
+func registerBundledTools(cfg *config.Config, manifest *bundle.Manifest) {
+	for _, tool := range manifest.Tools {
+		output.Success(fmt.Sprintf("Installed '%s' %s", tool.Name, installedVersion(tool.Version)))
+		if config.HasApp(cfg, tool.Name) {
+			continue
+		}
+		installPath := ""
+		if !goversion.IsGitHubRepo(tool.ModulePath) {
+			installPath = tool.PackagePath
+		}
+		_ = config.AddAppWithInstallPath(cfg, tool.Name, installPath)
+	}
+}
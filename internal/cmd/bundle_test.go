@@ -0,0 +1,43 @@
+package cmd
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/UnitVectorY-Labs/gogitup/internal/bundle"
+	"github.com/UnitVectorY-Labs/gogitup/internal/config"
+)
+
+func TestParseBundleOptionsRequiresOut(t *testing.T) {
+	var stderr bytes.Buffer
+	if _, err := parseBundleOptions(nil, &stderr); err == nil {
+		t.Fatal("expected an error when --out is missing")
+	}
+
+	opts, err := parseBundleOptions([]string{"--out", "tools.tar.gz", "--arch", "arm64"}, &stderr)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if opts.Out != "tools.tar.gz" || opts.GOARCH != "arm64" {
+		t.Fatalf("unexpected options: %+v", opts)
+	}
+}
+
+func TestRegisterBundledTools(t *testing.T) {
+	cfg := &config.Config{Apps: []config.App{{Name: "existing"}}}
+	registerBundledTools(cfg, &bundle.Manifest{Tools: []bundle.Tool{
+		{Name: "existing", ModulePath: "github.com/acme/existing"},
+		{Name: "gh-tool", ModulePath: "github.com/acme/gh-tool", PackagePath: "github.com/acme/gh-tool/cmd/gh-tool"},
+		{Name: "govulncheck", ModulePath: "golang.org/x/vuln", PackagePath: "golang.org/x/vuln/cmd/govulncheck"},
+	}})
+
+	if len(cfg.Apps) != 3 {
+		t.Fatalf("expected 3 apps, got %+v", cfg.Apps)
+	}
+	if cfg.Apps[1].Name != "gh-tool" || cfg.Apps[1].InstallPath != "" {
+		t.Fatalf("unexpected GitHub app entry: %+v", cfg.Apps[1])
+	}
+	if cfg.Apps[2].InstallPath != "golang.org/x/vuln/cmd/govulncheck" {
+		t.Fatalf("expected install path for non-GitHub module, got %+v", cfg.Apps[2])
+	}
+}
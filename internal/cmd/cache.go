@@ -0,0 +1,235 @@
+package cmd
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/UnitVectorY-Labs/gogitup/internal/cache"
+	"github.com/UnitVectorY-Labs/gogitup/internal/config"
+	"github.com/UnitVectorY-Labs/gogitup/internal/output"
+)
+
+type cacheShowEntry struct {
+	Name             string    `json:"name"`
+	InstalledVersion string    `json:"installed_version,omitempty"`
+	LatestVersion    string    `json:"latest_version"`
+	CheckedAt        time.Time `json:"checked_at"`
+}
+
+const cacheUsage = "Usage: gogitup cache <show [--json]|clear [<name>]|prune>"
+
+func runCache(args []string) {
+	if len(args) < 1 {
+		output.Error(cacheUsage)
+		os.Exit(1)
+	}
+
+	switch args[0] {
+	case "show":
+		runCacheShow(args[1:])
+	case "clear":
+		runCacheClear(args[1:])
+	case "prune":
+		runCachePrune(args[1:])
+	default:
+		output.Error("Unknown cache command: " + args[0])
+		output.Error(cacheUsage)
+		os.Exit(1)
+	}
+}
+
+func loadCacheOrExit() (string, *cache.Cache) {
+	cachePath := cacheFilePath()
+	c, err := loadCache(cachePath)
+	if err != nil {
+		output.Error(fmt.Sprintf("Failed to load cache: %v", err))
+		os.Exit(1)
+	}
+	return cachePath, c
+}
+
+func saveCacheOrExit(cachePath string, c *cache.Cache) {
+	if err := cache.Save(cachePath, c); err != nil {
+		output.Error(fmt.Sprintf("Failed to save cache: %v", err))
+		os.Exit(1)
+	}
+}
+
+func runCacheShow(args []string) {
+	fs := flag.NewFlagSet("cache show", flag.ContinueOnError)
+	fs.SetOutput(output.ErrorWriter.Out)
+	jsonFlag := fs.Bool("json", false, "Output as JSON")
+	if err := fs.Parse(args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return
+		}
+		os.Exit(2)
+	}
+
+	// show takes no lock, so it reads the cache without moving a corrupt file
+	// aside under a command that holds the lock.
+	c, err := readCache(cacheFilePath())
+	if err != nil {
+		output.Error(fmt.Sprintf("Failed to load cache: %v", err))
+		os.Exit(1)
+	}
+	entries := cacheShowEntries(c)
+
+	if *jsonFlag {
+		if err := output.PrintJSON(entries); err != nil {
+			output.Error(fmt.Sprintf("Failed to output JSON: %v", err))
+			os.Exit(1)
+		}
+		return
+	}
+
+	if len(entries) == 0 {
+		output.Info("The cache is empty.")
+		return
+	}
+
+	// Calculate column widths
+	nameW, instW, latW, ageW := len("Name"), len("Installed"), len("Latest"), len("Checked")
+	for _, e := range entries {
+		nameW = max(nameW, len(e.Name))
+		instW = max(instW, len(e.InstalledVersion))
+		latW = max(latW, len(e.LatestVersion))
+		ageW = max(ageW, len(formatAge(time.Since(e.CheckedAt))))
+	}
+
+	output.Header("Cache (" + cacheFilePath() + ")")
+	fmt.Println()
+	// Header row
+	fmt.Printf("  %s%s%-*s  %-*s  %-*s  %-*s%s\n", output.Bold, output.Cyan,
+		nameW, "Name", instW, "Installed", latW, "Latest", ageW, "Checked", output.Reset)
+	// Separator
+	fmt.Printf("  %s%s  %s  %s  %s%s\n", output.Gray,
+		strings.Repeat("─", nameW), strings.Repeat("─", instW), strings.Repeat("─", latW), strings.Repeat("─", ageW), output.Reset)
+	// Data rows
+	for _, e := range entries {
+		fmt.Printf("  %-*s  %s%-*s%s  %s%-*s%s  %s%-*s%s\n",
+			nameW, e.Name,
+			output.Green, instW, e.InstalledVersion, output.Reset,
+			output.Cyan, latW, e.LatestVersion, output.Reset,
+			output.Gray, ageW, formatAge(time.Since(e.CheckedAt)), output.Reset)
+	}
+	fmt.Println()
+}
+
+// cacheShowEntries returns the cache entries sorted by key.
+func cacheShowEntries(c *cache.Cache) []cacheShowEntry {
+	keys := make([]string, 0, len(c.Entries))
+	for key := range c.Entries {
+		keys = append(keys, key)
+	}
+	slices.Sort(keys)
+
+	entries := make([]cacheShowEntry, 0, len(keys))
+	for _, key := range keys {
+		e := c.Entries[key]
+		entries = append(entries, cacheShowEntry{
+			Name:             key,
+			InstalledVersion: e.InstalledVersion,
+			LatestVersion:    e.LatestVersion,
+			CheckedAt:        e.CheckedAt,
+		})
+	}
+	return entries
+}
+
+func runCacheClear(args []string) {
+	if len(args) > 1 {
+		output.Error("Usage: gogitup cache clear [<name>]")
+		os.Exit(1)
+	}
+
+	defer lockState()()
+
+	cachePath, c := loadCacheOrExit()
+
+	if len(args) == 0 {
+		count := len(c.Entries)
+		cache.Clear(c)
+		saveCacheOrExit(cachePath, c)
+		output.Success(fmt.Sprintf("Cleared %d cache entry(ies)", count))
+		return
+	}
+
+	name := args[0]
+	before := len(c.Entries)
+	cache.Remove(c, name)
+	if len(c.Entries) == before {
+		output.Info(fmt.Sprintf("No cache entries for '%s'", name))
+		return
+	}
+	saveCacheOrExit(cachePath, c)
+	output.Success(fmt.Sprintf("Cleared cache entries for '%s'", name))
+}
+
+func runCachePrune(args []string) {
+	if len(args) != 0 {
+		output.Error("Usage: gogitup cache prune")
+		os.Exit(1)
+	}
+
+	defer lockState()()
+
+	cfg, err := config.Load(configPath())
+	if err != nil {
+		output.Error(fmt.Sprintf("Failed to load config: %v", err))
+		os.Exit(1)
+	}
+
+	cachePath, c := loadCacheOrExit()
+
+	// Apps registered in any profile keep their entries and responses.
+	names := config.AllAppNames(cfg)
+	removed := cache.PruneResponses(c, ownedResponse(c, names))
+	removed = append(removed, cache.Prune(c, names)...)
+	if len(removed) == 0 {
+		output.Info("No stale cache entries.")
+		return
+	}
+	saveCacheOrExit(cachePath, c)
+
+	slices.Sort(removed)
+	for _, key := range removed {
+		output.Info(fmt.Sprintf("Pruned '%s'", key))
+	}
+	output.Success(fmt.Sprintf("Pruned %d cache entry(ies)", len(removed)))
+}
+
+// ownedResponse returns a filter that keeps the stored GitHub responses for
+// the repositories of the named apps' modules, including the repositories
+// their vanity import paths resolve to. Every response is kept while one of
+// the apps has an entry that does not record its module, as entries written
+// by older versions do.
+func ownedResponse(c *cache.Cache, names []string) func(url string) bool {
+	var repos []string
+	for key, e := range c.Entries {
+		if !slices.Contains(names, cache.AppName(key)) {
+			continue
+		}
+		if e.ModulePath == "" {
+			return func(string) bool { return true }
+		}
+		paths := []string{e.ModulePath}
+		if res, ok := c.Resolutions[e.ModulePath]; ok {
+			paths = append(paths, res.RepoPath, res.SourcePath)
+		}
+		for _, path := range paths {
+			// host/owner/repo is requested as .../repos/owner/repo/...
+			if parts := strings.SplitN(path, "/", 4); len(parts) >= 3 {
+				repos = append(repos, "/repos/"+parts[1]+"/"+parts[2]+"/")
+			}
+		}
+	}
+	return func(url string) bool {
+		return slices.ContainsFunc(repos, func(repo string) bool { return strings.Contains(url, repo) })
+	}
+}
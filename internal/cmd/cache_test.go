@@ -0,0 +1,60 @@
+package cmd
+
+import (
+	"testing"
+	"time"
+
+	"github.com/UnitVectorY-Labs/gogitup/internal/cache"
+)
+
+func TestCacheShowEntriesSortedByName(t *testing.T) {
+	checkedAt := time.Date(2024, 1, 15, 10, 30, 0, 0, time.UTC)
+	c := &cache.Cache{Entries: map[string]cache.Entry{
+		"zeta":            {LatestVersion: "v2.0.0", CheckedAt: checkedAt},
+		"alpha@linux/arm": {LatestVersion: "v1.0.0", CheckedAt: checkedAt},
+		"alpha":           {LatestVersion: "v1.0.0", InstalledVersion: "v0.9.0", CheckedAt: checkedAt},
+	}}
+
+	entries := cacheShowEntries(c)
+
+	if len(entries) != 3 {
+		t.Fatalf("expected 3 entries, got %d", len(entries))
+	}
+	if entries[0].Name != "alpha" || entries[1].Name != "alpha@linux/arm" || entries[2].Name != "zeta" {
+		t.Fatalf("unexpected order: %+v", entries)
+	}
+	if entries[0].InstalledVersion != "v0.9.0" || !entries[0].CheckedAt.Equal(checkedAt) {
+		t.Fatalf("unexpected entry: %+v", entries[0])
+	}
+}
+
+func TestOwnedResponse(t *testing.T) {
+	c := &cache.Cache{
+		Entries: map[string]cache.Entry{
+			"tool":                {ModulePath: "github.com/owner/tool/v2"},
+			"staticcheck":         {ModulePath: "honnef.co/go/tools"},
+			"removed@linux/amd64": {ModulePath: "github.com/owner/removed"},
+		},
+		Resolutions: map[string]cache.Resolution{
+			"honnef.co/go/tools": {Prefix: "honnef.co/go/tools", RepoPath: "github.com/dominikh/go-tools"},
+		},
+	}
+	keep := ownedResponse(c, []string{"tool", "staticcheck"})
+
+	tests := map[string]bool{
+		"https://api.github.com/repos/owner/tool/releases/latest":         true,
+		"https://api.github.com/repos/dominikh/go-tools/releases/latest":  true,
+		"https://api.github.com/repos/owner/removed/releases/latest":      false,
+		"https://ghe.example.com/api/v3/repos/other/tool/releases/latest": false,
+	}
+	for url, want := range tests {
+		if got := keep(url); got != want {
+			t.Errorf("keep(%q) = %t, want %t", url, got, want)
+		}
+	}
+
+	c.Entries["legacy"] = cache.Entry{LatestVersion: "v1.0.0"}
+	if keep := ownedResponse(c, []string{"tool", "legacy"}); !keep("https://api.github.com/repos/owner/removed/releases/latest") {
+		t.Fatal("expected every response to be kept while an entry has no module path")
+	}
+}
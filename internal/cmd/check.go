@@ -1,34 +1,130 @@
 package cmd
 
 import (
+	"errors"
 	"flag"
 	"fmt"
+	"io"
 	"os"
 	"strings"
+	"time"
 
 	"github.com/UnitVectorY-Labs/gogitup/internal/cache"
 	"github.com/UnitVectorY-Labs/gogitup/internal/config"
 	"github.com/UnitVectorY-Labs/gogitup/internal/github"
 	"github.com/UnitVectorY-Labs/gogitup/internal/gomodule"
 	"github.com/UnitVectorY-Labs/gogitup/internal/goversion"
+	"github.com/UnitVectorY-Labs/gogitup/internal/notify"
 	"github.com/UnitVectorY-Labs/gogitup/internal/output"
+	"github.com/UnitVectorY-Labs/gogitup/internal/release"
+	"github.com/UnitVectorY-Labs/gogitup/internal/vanity"
+)
+
+// Sources of a check result.
+const (
+	sourceNetwork = "network"
+	sourceCache   = "cache"
 )
 
 type checkEntry struct {
-	Name             string `json:"name"`
-	InstalledVersion string `json:"installed_version"`
-	LatestVersion    string `json:"latest_version"`
-	UpdateAvailable  bool   `json:"update_available"`
+	Name             string     `json:"name"`
+	InstalledVersion string     `json:"installed_version"`
+	LatestVersion    string     `json:"latest_version"`
+	UpdateAvailable  bool       `json:"update_available"`
+	Source           string     `json:"source,omitempty"`
+	CheckedAt        *time.Time `json:"checked_at,omitempty"`
+	AgeSeconds       int64      `json:"age_seconds"`
+	// Verification is the checksum database status of the latest version
+	// when an update is available.
+	Verification       string `json:"verification,omitempty"`
+	VerificationDetail string `json:"verification_detail,omitempty"`
+	// Retracted reports that the installed version was retracted by the
+	// module author, and Deprecated holds the module's deprecation message.
+	Retracted           bool   `json:"retracted,omitempty"`
+	RetractionRationale string `json:"retraction_rationale,omitempty"`
+	Deprecated          string `json:"deprecated,omitempty"`
+}
+
+type checkOptions struct {
+	JSON    bool
+	Force   bool
+	Offline bool
+	MaxAge  string
+	// Notify sends new updates to the configured notifiers.
+	Notify bool
+	// ttls holds the resolved cache TTL per app; apps without an entry use
+	// cache.DefaultTTL.
+	ttls map[string]time.Duration
+	// targets holds the resolved target of each cross-compiled app; apps
+	// without an entry are checked on PATH.
+	targets map[string]appTarget
+}
+
+type checkDependencies struct {
+	runner goversion.Runner
+	// crossRunner inspects cross-compiled apps; runner serves native ones.
+	crossRunner func(appTarget) goversion.Runner
+	ghClient    github.Client
+	releases    release.Hosts
+	vanity      vanity.Resolver
+	resolver    gomodule.Resolver
+	verifier    gomodule.Verifier
+	// reachable reports whether the network can be used. It is called only
+	// when a lookup is needed; nil means reachable.
+	reachable func() bool
+	out       *output.Writer
+	// errOut receives the retraction, deprecation, and checksum warnings,
+	// so they do not mix with --json output.
+	errOut *output.Writer
+}
+
+// runnerFor returns the runner that inspects binaries built for target.
+func (d checkDependencies) runnerFor(target appTarget) goversion.Runner {
+	if target.platform.IsZero() || d.crossRunner == nil {
+		return d.runner
+	}
+	return d.crossRunner(target)
+}
+
+func parseCheckOptions(args []string, stderr io.Writer) (checkOptions, error) {
+	fs := flag.NewFlagSet("check", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+
+	var opts checkOptions
+	fs.BoolVar(&opts.JSON, "json", false, "Output as JSON")
+	fs.BoolVar(&opts.Force, "force", false, "Refresh version information, ignoring cache")
+	fs.BoolVar(&opts.Offline, "offline", false, "Use cached version information only, without network access")
+	fs.StringVar(&opts.MaxAge, "max-age", "", "Maximum age of cached results to use, such as 6h or 2d")
+	fs.BoolVar(&opts.Notify, "notify", false, "Send newly found updates to the configured notifiers")
+	if err := fs.Parse(args); err != nil {
+		return checkOptions{}, err
+	}
+	if opts.Force && opts.Offline {
+		return checkOptions{}, errors.New("--force and --offline cannot be used together")
+	}
+	if opts.Notify && opts.Offline {
+		return checkOptions{}, errors.New("--notify and --offline cannot be used together")
+	}
+
+	return opts, nil
 }
 
 func runCheck(args []string) {
-	fs := flag.NewFlagSet("check", flag.ExitOnError)
-	jsonFlag := fs.Bool("json", false, "Output as JSON")
-	forceFlag := fs.Bool("force", false, "Refresh version information, ignoring cache")
-	_ = fs.Parse(args)
+	opts, err := parseCheckOptions(args, output.ErrorWriter.Out)
+	if err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return
+		}
+		output.Error(err.Error())
+		os.Exit(2)
+	}
 
-	cfgPath := config.DefaultPath()
-	cfg, err := config.Load(cfgPath)
+	if !opts.Offline {
+		defer lockState()()
+	}
+
+	cfgPath := configPath()
+	cfg, err := config.LoadProfile(cfgPath, profileName())
 	if err != nil {
 		output.Error(fmt.Sprintf("Failed to load config: %v", err))
 		os.Exit(1)
@@ -39,66 +135,311 @@ func runCheck(args []string) {
 		return
 	}
 
-	cachePath := cache.DefaultPath()
-	c, err := cache.Load(cachePath)
+	cachePath := cacheFilePath()
+	c, err := loadCache(cachePath)
 	if err != nil {
 		output.Error(fmt.Sprintf("Failed to load cache: %v", err))
 		os.Exit(1)
 	}
 
-	runner := &goversion.DefaultRunner{}
-	ghClient := github.NewDefaultClient(github.ResolveToken(cfg.GitHubAuth))
-	moduleResolver := gomodule.NewDefaultResolverWithGOPROXY(cfg.GOPROXY)
+	opts.ttls, err = resolveCacheTTLs(cfg, opts.MaxAge)
+	if err != nil {
+		output.Error(err.Error())
+		os.Exit(1)
+	}
+	opts.targets, err = resolveAppTargets(targetOptions{}, cfg)
+	if err != nil {
+		output.Error(fmt.Sprintf("Failed to resolve target platform: %v", err))
+		os.Exit(1)
+	}
+
+	var notifiers []notify.Notifier
+	if opts.Notify {
+		if len(cfg.Notifiers) == 0 {
+			output.Error("No notifiers configured; add them under 'notifiers' in the config file")
+			os.Exit(1)
+		}
+		notifiers, err = newNotifiers(cfg)
+		if err != nil {
+			output.Error(fmt.Sprintf("Failed to configure notifiers: %v", err))
+			os.Exit(1)
+		}
+	}
+
+	deps := checkDependencies{
+		runner:      &goversion.DefaultRunner{},
+		crossRunner: newTargetRunner,
+		ghClient:    newGitHubReleaseClient(cfg, c),
+		releases:    newReleaseHosts(cfg, c),
+		vanity:      newVanityResolver(cfg, c),
+		resolver:    newModuleResolver(cfg),
+		verifier:    newModuleVerifier(cfg),
+		reachable: func() bool {
+			return networkReachable(cfg)
+		},
+		out:    output.DefaultWriter,
+		errOut: output.ErrorWriter,
+	}
+	offline, notifyErr := checkAndReport(cfg, c, opts, notifiers, deps)
+
+	// Save updated cache
+	if !offline {
+		_ = cache.Save(cachePath, c)
+	}
+
+	if notifyErr != nil {
+		output.Error(fmt.Sprintf("Failed to send notifications: %v", notifyErr))
+		os.Exit(1)
+	}
+}
+
+// checkAndReport checks every app, sends new updates to notifiers when
+// --notify is set, and prints the results to deps.out as a table or JSON.
+// Warnings go to deps.errOut, so that the JSON output stays parseable. It
+// reports whether the check ran offline and any notification failure.
+func checkAndReport(cfg *config.Config, c *cache.Cache, opts checkOptions, notifiers []notify.Notifier, deps checkDependencies) (bool, error) {
+	entries, offline := checkApps(cfg, c, opts, deps)
+
+	// Notifications are sent only for updates confirmed online, so that an
+	// unreachable network does not repeat or invent them.
+	var notifyErr error
+	if opts.Notify {
+		if offline {
+			deps.errOut.Warn("Skipping notifications while offline")
+		} else {
+			notifyErr = notifyUpdates(c, entries, notifiers, deps.errOut)
+		}
+	}
+
+	if opts.JSON {
+		if err := deps.out.PrintJSON(entries); err != nil {
+			deps.errOut.Error(fmt.Sprintf("Failed to output JSON: %v", err))
+			os.Exit(1)
+		}
+	} else {
+		printCheckTable(deps.out, entries, offline)
+	}
+	return offline, notifyErr
+}
+
+// resolveCacheTTLs returns the cache TTL for each app: maxAge when provided,
+// otherwise the app or global cache_ttl, otherwise cache.DefaultTTL.
+func resolveCacheTTLs(cfg *config.Config, maxAge string) (map[string]time.Duration, error) {
+	ttls := make(map[string]time.Duration, len(cfg.Apps))
+	if maxAge != "" {
+		ttl, err := config.ParseDuration(maxAge)
+		if err != nil {
+			return nil, fmt.Errorf("invalid --max-age: %w", err)
+		}
+		for _, app := range cfg.Apps {
+			ttls[app.Name] = ttl
+		}
+		return ttls, nil
+	}
+	for _, app := range cfg.Apps {
+		ttl, err := config.CacheTTL(cfg, app.Name, cache.DefaultTTL)
+		if err != nil {
+			return nil, err
+		}
+		ttls[app.Name] = ttl
+	}
+	return ttls, nil
+}
+
+// networkProbeTimeout bounds how long check waits before assuming it is offline.
+const networkProbeTimeout = 3 * time.Second
 
+// checkApps determines the update status of every registered app, using
+// non-expired cache entries unless forced. In offline mode only the cache is
+// consulted, regardless of entry age, and no network lookups are made. When
+// lookups are needed but the network is unreachable, checkApps falls back to
+// offline mode and reports that it did.
+func checkApps(cfg *config.Config, c *cache.Cache, opts checkOptions, deps checkDependencies) ([]checkEntry, bool) {
 	entries := make([]checkEntry, 0, len(cfg.Apps))
+	// lookups are the apps whose latest version must be fetched, gathered
+	// first so that a batching GitHub client can look them up at once.
+	type lookup struct {
+		index  int
+		info   *goversion.Info
+		cached cache.Entry
+		found  bool
+	}
+	var lookups []lookup
+	infos := make(map[string]*goversion.Info, len(cfg.Apps))
 
 	for _, app := range cfg.Apps {
-		entry := checkEntry{Name: app.Name, InstalledVersion: "unknown", LatestVersion: "unknown"}
+		// Cross-compiled binaries are cached and reported per platform.
+		target := opts.targets[app.Name]
+		key := target.key(app.Name)
+		entry := checkEntry{Name: key, InstalledVersion: "unknown", LatestVersion: "unknown"}
 
-		info, err := runner.GetInfo(app.Name)
+		info, err := deps.runnerFor(target).GetInfo(app.Name)
 		if err != nil {
-			output.Warn(fmt.Sprintf("Could not get info for '%s': %v", app.Name, err))
+			deps.errOut.Warn(fmt.Sprintf("Could not get info for '%s': %v", key, err))
 			entries = append(entries, entry)
 			continue
 		}
 		entry.InstalledVersion = info.Version
+		infos[key] = info
 
-		// Cached update decisions are valid only for the installed version checked.
-		cached, found := cache.Get(c, app.Name)
-		if !*forceFlag && found && cached.InstalledVersion == info.Version && !cache.IsExpired(cached, cache.DefaultTTL) {
-			entry.LatestVersion = cached.LatestVersion
-			entry.UpdateAvailable = entry.InstalledVersion != entry.LatestVersion
-		} else {
-			result, err := checkForUpdate(info.Path, info.Version, ghClient, moduleResolver)
-			if err != nil {
-				output.Warn(fmt.Sprintf("Could not fetch latest version for '%s': %v", app.Name, err))
+		ttl, ok := opts.ttls[app.Name]
+		if !ok {
+			ttl = cache.DefaultTTL
+		}
+
+		cached, found := cache.Get(c, key)
+		if opts.Offline {
+			if !found {
+				deps.errOut.Warn(fmt.Sprintf("No cached version information for '%s'", key))
 				entries = append(entries, entry)
 				continue
 			}
-			entry.LatestVersion = result.latestVersion
-			entry.UpdateAvailable = result.updateAvailable
-			cache.SetForInstalledVersion(c, app.Name, info.Version, result.latestVersion)
+			entries = append(entries, cachedCheckEntry(entry, cached))
+			continue
 		}
 
+		// Cached update decisions are valid only for the installed version checked.
+		if !opts.Force && found && cached.InstalledVersion == info.Version && !cache.IsExpired(cached, ttl) {
+			entries = append(entries, cachedCheckEntry(entry, cached))
+			continue
+		}
+
+		lookups = append(lookups, lookup{index: len(entries), info: info, cached: cached, found: found})
 		entries = append(entries, entry)
 	}
 
-	// Save updated cache
-	_ = cache.Save(cachePath, c)
+	if len(lookups) > 0 && deps.reachable != nil && !deps.reachable() {
+		deps.errOut.Warn("Network unreachable; showing cached results only (offline)")
+		opts.Offline = true
+		for _, l := range lookups {
+			if !l.found {
+				deps.errOut.Warn(fmt.Sprintf("No cached version information for '%s'", entries[l.index].Name))
+				continue
+			}
+			entries[l.index] = cachedCheckEntry(entries[l.index], l.cached)
+		}
+		lookups = nil
+	}
 
-	if *jsonFlag {
-		if err := output.PrintJSON(entries); err != nil {
-			output.Error(fmt.Sprintf("Failed to output JSON: %v", err))
-			os.Exit(1)
+	modulePaths := make([]string, len(lookups))
+	for i, l := range lookups {
+		modulePaths[i] = l.info.Path
+	}
+	prefetchReleases(deps.ghClient, modulePaths)
+
+	rateLimited := false
+	for _, l := range lookups {
+		entry := &entries[l.index]
+		result, err := checkForUpdate(l.info.Path, l.info.Version, releaseSources{deps.ghClient, deps.releases, deps.vanity}, deps.resolver)
+		var limitErr *github.RateLimitError
+		if errors.As(err, &limitErr) {
+			// Later GitHub lookups fail the same way, so warn only once and
+			// fall back to cached results.
+			if !rateLimited {
+				deps.errOut.Warn(fmt.Sprintf("%s; showing cached results where available", rateLimitWarning(cfg, limitErr)))
+				rateLimited = true
+			}
+			if l.found {
+				*entry = cachedCheckEntry(*entry, l.cached)
+			}
+			continue
 		}
-		return
+		if err != nil {
+			deps.errOut.Warn(fmt.Sprintf("Could not fetch latest version for '%s': %v", entry.Name, err))
+			continue
+		}
+		cache.SetForInstalledVersion(c, entry.Name, l.info.Version, result.latestVersion)
+		recordModuleStatus(c, entry.Name, l.info.Path, result.status)
+		cached := c.Entries[entry.Name]
+		if result.updateAvailable && deps.verifier != nil {
+			v := deps.verifier.Verify(l.info.Path, result.latestVersion)
+			cached.Verification, cached.VerificationDetail = v.Status, v.Detail
+			c.Entries[entry.Name] = cached
+		}
+		entry.LatestVersion = result.latestVersion
+		entry.UpdateAvailable = result.updateAvailable
+		entry.Source = sourceNetwork
+		entry.CheckedAt = &cached.CheckedAt
+		entry.Verification = cached.Verification
+		entry.VerificationDetail = cached.VerificationDetail
+		entry.Retracted = cached.Retracted
+		entry.RetractionRationale = cached.RetractionRationale
+		entry.Deprecated = cached.Deprecated
 	}
 
+	if !opts.Offline {
+		for name, info := range infos {
+			recordBinary(c, name, info)
+		}
+	}
+
+	for _, entry := range entries {
+		if entry.Retracted {
+			deps.errOut.Warn(retractionWarning(entry.Name, entry.InstalledVersion, entry.RetractionRationale))
+		}
+		if entry.Deprecated != "" {
+			deps.errOut.Warn(fmt.Sprintf("'%s' is deprecated: %s", entry.Name, entry.Deprecated))
+		}
+		if entry.Verification == gomodule.VerifyMismatch {
+			deps.errOut.Warn(fmt.Sprintf("Checksum mismatch for '%s' %s: %s", entry.Name, entry.LatestVersion, entry.VerificationDetail))
+		}
+	}
+
+	return entries, opts.Offline
+}
+
+// recordModuleStatus stores the module path and the retraction and
+// deprecation status of the installed version in the cache entry for name.
+func recordModuleStatus(c *cache.Cache, name, modulePath string, status gomodule.Status) {
+	entry := c.Entries[name]
+	entry.ModulePath = modulePath
+	entry.Retracted = status.Retracted
+	entry.RetractionRationale = status.Rationale
+	entry.Deprecated = status.Deprecated
+	c.Entries[name] = entry
+}
+
+// retractionWarning explains that the installed version of an app was
+// retracted by its module author.
+func retractionWarning(name, version, rationale string) string {
+	msg := fmt.Sprintf("'%s' %s has been retracted by its author", name, version)
+	if rationale != "" {
+		msg += ": " + rationale
+	}
+	return msg
+}
+
+// cachedCheckEntry fills entry from a cached version check. The retraction
+// and verification results are only used when they were recorded for the
+// version installed now, since they describe another version otherwise.
+func cachedCheckEntry(entry checkEntry, cached cache.Entry) checkEntry {
+	checkedAt := cached.CheckedAt
+	entry.LatestVersion = cached.LatestVersion
+	entry.UpdateAvailable = entry.InstalledVersion != entry.LatestVersion
+	entry.Source = sourceCache
+	entry.CheckedAt = &checkedAt
+	entry.AgeSeconds = int64(time.Since(checkedAt) / time.Second)
+	entry.Deprecated = cached.Deprecated
+	if cached.InstalledVersion != entry.InstalledVersion {
+		return entry
+	}
+	entry.Retracted = cached.Retracted
+	entry.RetractionRationale = cached.RetractionRationale
+	if entry.UpdateAvailable {
+		entry.Verification = cached.Verification
+		entry.VerificationDetail = cached.VerificationDetail
+	}
+	return entry
+}
+
+func printCheckTable(w *output.Writer, entries []checkEntry, offline bool) {
 	// Calculate column widths
 	nameW := len("Name")
 	instW := len("Installed")
 	latW := len("Latest")
 	updW := len("Update")
+	ageW := len("Age")
+	srcW := len("Source")
 	for _, e := range entries {
 		if len(e.Name) > nameW {
 			nameW = len(e.Name)
@@ -109,29 +450,74 @@ func runCheck(args []string) {
 		if len(e.LatestVersion) > latW {
 			latW = len(e.LatestVersion)
 		}
+		if len(entryAge(e)) > ageW {
+			ageW = len(entryAge(e))
+		}
+		if len(e.Source) > srcW {
+			srcW = len(e.Source)
+		}
+		if e.Retracted {
+			updW = len("retracted")
+		}
 	}
 
-	output.Header("Update Check")
-	fmt.Println()
+	title := "Update Check"
+	if offline {
+		title += " (offline, cached results)"
+	}
+	w.Header(title)
+	fmt.Fprintln(w.Out)
 	// Header row
-	fmt.Printf("  %s%s%-*s  %-*s  %-*s  %-*s%s\n", output.Bold, output.Cyan,
-		nameW, "Name", instW, "Installed", latW, "Latest", updW, "Update", output.Reset)
+	fmt.Fprintf(w.Out, "  %s%s%-*s  %-*s  %-*s  %-*s  %-*s  %-*s%s\n", output.Bold, output.Cyan,
+		nameW, "Name", instW, "Installed", latW, "Latest", updW, "Update", ageW, "Age", srcW, "Source", output.Reset)
 	// Separator
-	fmt.Printf("  %s%s  %s  %s  %s%s\n", output.Gray,
-		strings.Repeat("─", nameW), strings.Repeat("─", instW), strings.Repeat("─", latW), strings.Repeat("─", updW), output.Reset)
+	fmt.Fprintf(w.Out, "  %s%s  %s  %s  %s  %s  %s%s\n", output.Gray,
+		strings.Repeat("─", nameW), strings.Repeat("─", instW), strings.Repeat("─", latW), strings.Repeat("─", updW),
+		strings.Repeat("─", ageW), strings.Repeat("─", srcW), output.Reset)
 	// Data rows
 	for _, e := range entries {
 		updateStr := "no"
 		updateColor := output.Gray
-		if e.UpdateAvailable {
+		switch {
+		case e.Retracted:
+			updateStr = "retracted"
+			updateColor = output.Red
+		case e.UpdateAvailable:
 			updateStr = "yes"
 			updateColor = output.Yellow
 		}
-		fmt.Printf("  %-*s  %s%-*s%s  %s%-*s%s  %s%-*s%s\n",
+		source := e.Source
+		if source == "" {
+			source = "-"
+		}
+		fmt.Fprintf(w.Out, "  %-*s  %s%-*s%s  %s%-*s%s  %s%-*s%s  %s%-*s  %-*s%s\n",
 			nameW, e.Name,
 			output.Green, instW, e.InstalledVersion, output.Reset,
 			output.Cyan, latW, e.LatestVersion, output.Reset,
-			updateColor, updW, updateStr, output.Reset)
+			updateColor, updW, updateStr, output.Reset,
+			output.Gray, ageW, entryAge(e), srcW, source, output.Reset)
+	}
+	fmt.Fprintln(w.Out)
+}
+
+// entryAge returns how long ago the entry's result was checked.
+func entryAge(e checkEntry) string {
+	if e.CheckedAt == nil {
+		return "-"
+	}
+	return formatAge(time.Duration(e.AgeSeconds) * time.Second)
+}
+
+// formatAge renders a duration in the largest whole unit, such as "5m ago".
+func formatAge(d time.Duration) string {
+	switch {
+	case d < time.Minute:
+		return "just now"
+	case d < time.Hour:
+		return fmt.Sprintf("%dm ago", int(d/time.Minute))
+	case d < 48*time.Hour:
+		return fmt.Sprintf("%dh ago", int(d/time.Hour))
+	default:
+		return fmt.Sprintf("%dd ago", int(d/(24*time.Hour)))
 	}
-	fmt.Println()
 }
@@ -0,0 +1,412 @@
+package cmd
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/UnitVectorY-Labs/gogitup/internal/cache"
+	"github.com/UnitVectorY-Labs/gogitup/internal/config"
+	"github.com/UnitVectorY-Labs/gogitup/internal/github"
+	"github.com/UnitVectorY-Labs/gogitup/internal/gomodule"
+	"github.com/UnitVectorY-Labs/gogitup/internal/goversion"
+	"github.com/UnitVectorY-Labs/gogitup/internal/installer"
+	"github.com/UnitVectorY-Labs/gogitup/internal/output"
+)
+
+func TestParseCheckOptionsRejectsForceWithOffline(t *testing.T) {
+	var stderr bytes.Buffer
+	if _, err := parseCheckOptions([]string{"--force", "--offline"}, &stderr); err == nil {
+		t.Fatal("expected an error for --force with --offline")
+	}
+
+	opts, err := parseCheckOptions([]string{"--offline", "--json"}, &stderr)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !opts.Offline || !opts.JSON {
+		t.Fatalf("unexpected options: %+v", opts)
+	}
+}
+
+func TestCheckAppsOfflineUsesExpiredCacheWithoutNetwork(t *testing.T) {
+	cfg := &config.Config{Apps: []config.App{{Name: "tool"}, {Name: "module"}, {Name: "uncached"}}}
+	checkedAt := time.Now().Add(-72 * time.Hour)
+	c := &cache.Cache{Entries: map[string]cache.Entry{
+		"tool":   {LatestVersion: "v1.1.0", InstalledVersion: "v1.0.0", CheckedAt: checkedAt},
+		"module": {LatestVersion: "v0.5.0", InstalledVersion: "v0.5.0", CheckedAt: checkedAt},
+	}}
+	runner := &stubRunner{infos: map[string]*goversion.Info{
+		"tool":     {Path: "github.com/acme/tool", Version: "v1.0.0"},
+		"module":   {Path: "example.com/module", Version: "v0.5.0"},
+		"uncached": {Path: "github.com/acme/uncached", Version: "v2.0.0"},
+	}}
+	ghClient := &stubGitHubClient{releases: map[string]string{"acme/tool": "v9.9.9"}}
+	resolver := &stubModuleResolver{}
+	var stdout bytes.Buffer
+
+	entries, _ := checkApps(cfg, c, checkOptions{Offline: true}, checkDependencies{
+		runner:   runner,
+		ghClient: ghClient,
+		resolver: resolver,
+		out:      &output.Writer{Out: &stdout},
+		errOut:   &output.Writer{Out: &bytes.Buffer{}},
+	})
+
+	if len(resolver.calls) != 0 {
+		t.Fatalf("expected no resolver calls offline, got %d", len(resolver.calls))
+	}
+	if len(entries) != 3 {
+		t.Fatalf("expected 3 entries, got %d", len(entries))
+	}
+	if entries[0].LatestVersion != "v1.1.0" || !entries[0].UpdateAvailable || entries[0].CheckedAt == nil {
+		t.Fatalf("expected cached update for tool, got %+v", entries[0])
+	}
+	if entries[1].UpdateAvailable {
+		t.Fatalf("expected no update for module, got %+v", entries[1])
+	}
+	if entries[2].LatestVersion != "unknown" {
+		t.Fatalf("expected unknown latest version for uncached app, got %+v", entries[2])
+	}
+	if !c.Entries["tool"].CheckedAt.Equal(checkedAt) {
+		t.Fatal("expected offline check to leave the cache untouched")
+	}
+}
+
+func TestCheckAppsUsesPlatformCacheKeys(t *testing.T) {
+	cfg := &config.Config{Apps: []config.App{{Name: "tool"}}}
+	arm64 := appTarget{platform: installer.Target{GOOS: "linux", GOARCH: "arm64"}, binDir: "/out"}
+	c := &cache.Cache{Entries: map[string]cache.Entry{
+		"tool@linux/arm64": {LatestVersion: "v1.1.0", InstalledVersion: "v1.0.0", CheckedAt: time.Now()},
+	}}
+	crossRunner := &stubRunner{infos: map[string]*goversion.Info{
+		"tool": {Path: "github.com/acme/tool", Version: "v1.0.0"},
+	}}
+	ghClient := &stubGitHubClient{releases: map[string]string{"acme/tool": "v9.9.9"}}
+
+	entries, _ := checkApps(cfg, c, checkOptions{targets: map[string]appTarget{"tool": arm64}}, checkDependencies{
+		runner: &stubRunner{},
+		crossRunner: func(target appTarget) goversion.Runner {
+			if target != arm64 {
+				t.Fatalf("cross-compiled runner requested for %+v, want %+v", target, arm64)
+			}
+			return crossRunner
+		},
+		ghClient: ghClient,
+		resolver: &stubModuleResolver{},
+		out:      &output.Writer{Out: &bytes.Buffer{}},
+		errOut:   &output.Writer{Out: &bytes.Buffer{}},
+	})
+
+	if entries[0].Name != "tool@linux/arm64" || entries[0].Source != sourceCache || entries[0].LatestVersion != "v1.1.0" {
+		t.Fatalf("unexpected entry: %+v", entries[0])
+	}
+	if _, ok := c.Entries["tool"]; ok {
+		t.Fatal("expected no native cache entry for a cross-compiled app")
+	}
+}
+
+func TestFormatAge(t *testing.T) {
+	tests := []struct {
+		age  time.Duration
+		want string
+	}{
+		{30 * time.Second, "just now"},
+		{5 * time.Minute, "5m ago"},
+		{3 * time.Hour, "3h ago"},
+		{72 * time.Hour, "3d ago"},
+	}
+	for _, tc := range tests {
+		if got := formatAge(tc.age); got != tc.want {
+			t.Errorf("formatAge(%v) = %q, want %q", tc.age, got, tc.want)
+		}
+	}
+}
+
+func TestCheckAppsHonorsPerAppTTL(t *testing.T) {
+	cfg := &config.Config{Apps: []config.App{{Name: "fresh"}, {Name: "stale"}}}
+	checkedAt := time.Now().Add(-2 * time.Hour)
+	c := &cache.Cache{Entries: map[string]cache.Entry{
+		"fresh": {LatestVersion: "v1.0.0", InstalledVersion: "v1.0.0", CheckedAt: checkedAt},
+		"stale": {LatestVersion: "v1.0.0", InstalledVersion: "v1.0.0", CheckedAt: checkedAt},
+	}}
+	runner := &stubRunner{infos: map[string]*goversion.Info{
+		"fresh": {Path: "github.com/acme/fresh", Version: "v1.0.0"},
+		"stale": {Path: "github.com/acme/stale", Version: "v1.0.0"},
+	}}
+	ghClient := &stubGitHubClient{releases: map[string]string{
+		"acme/fresh": "v2.0.0",
+		"acme/stale": "v2.0.0",
+	}}
+
+	entries, _ := checkApps(cfg, c, checkOptions{ttls: map[string]time.Duration{
+		"fresh": 24 * time.Hour,
+		"stale": time.Hour,
+	}}, checkDependencies{
+		runner:   runner,
+		ghClient: ghClient,
+		out:      &output.Writer{Out: &bytes.Buffer{}},
+		errOut:   &output.Writer{Out: &bytes.Buffer{}},
+	})
+
+	if entries[0].Source != sourceCache || entries[0].LatestVersion != "v1.0.0" || entries[0].AgeSeconds < 7200 {
+		t.Fatalf("expected cached result for fresh, got %+v", entries[0])
+	}
+	if entries[1].Source != sourceNetwork || entries[1].LatestVersion != "v2.0.0" || entries[1].AgeSeconds != 0 {
+		t.Fatalf("expected network result for stale, got %+v", entries[1])
+	}
+}
+
+func TestCheckAppsProbesNetworkOnlyForLookups(t *testing.T) {
+	cfg := &config.Config{Apps: []config.App{{Name: "fresh"}, {Name: "stale"}}}
+	c := &cache.Cache{Entries: map[string]cache.Entry{
+		"fresh": {LatestVersion: "v1.0.0", InstalledVersion: "v1.0.0", CheckedAt: time.Now()},
+		"stale": {LatestVersion: "v1.1.0", InstalledVersion: "v1.0.0", CheckedAt: time.Now().Add(-48 * time.Hour)},
+	}}
+	runner := &stubRunner{infos: map[string]*goversion.Info{
+		"fresh": {Path: "github.com/acme/fresh", Version: "v1.0.0"},
+		"stale": {Path: "github.com/acme/stale", Version: "v1.0.0"},
+	}}
+	probes := 0
+	var stderr bytes.Buffer
+	deps := checkDependencies{
+		runner:    runner,
+		ghClient:  &stubGitHubClient{releases: map[string]string{"acme/stale": "v2.0.0"}},
+		reachable: func() bool { probes++; return false },
+		out:       &output.Writer{Out: &bytes.Buffer{}},
+		errOut:    &output.Writer{Out: &stderr},
+	}
+
+	fresh := &config.Config{Apps: cfg.Apps[:1]}
+	if _, offline := checkApps(fresh, c, checkOptions{}, deps); offline || probes != 0 {
+		t.Fatalf("expected no probe when every result is cached, got %d probes (offline=%v)", probes, offline)
+	}
+
+	entries, offline := checkApps(cfg, c, checkOptions{}, deps)
+	if !offline || probes != 1 {
+		t.Fatalf("expected one probe and offline mode, got %d probes (offline=%v)", probes, offline)
+	}
+	if entries[1].Source != sourceCache || entries[1].LatestVersion != "v1.1.0" {
+		t.Fatalf("expected the expired cached result for stale, got %+v", entries[1])
+	}
+	if !strings.Contains(stderr.String(), "Network unreachable") {
+		t.Fatalf("expected the offline warning on errOut, got %q", stderr.String())
+	}
+
+	stderr.Reset()
+	probes = 0
+	checkAndReport(cfg, c, checkOptions{JSON: true, Notify: true}, nil, deps)
+	if !strings.Contains(stderr.String(), "Skipping notifications while offline") {
+		t.Fatalf("expected notifications to be skipped on errOut, got %q", stderr.String())
+	}
+}
+
+func TestResolveCacheTTLsMaxAgeOverridesConfig(t *testing.T) {
+	cfg := &config.Config{CacheTTL: "1h", Apps: []config.App{{Name: "tool", CacheTTL: "2h"}}}
+
+	ttls, err := resolveCacheTTLs(cfg, "")
+	if err != nil || ttls["tool"] != 2*time.Hour {
+		t.Fatalf("expected per-app TTL, got %v (err=%v)", ttls, err)
+	}
+	ttls, err = resolveCacheTTLs(cfg, "3d")
+	if err != nil || ttls["tool"] != 72*time.Hour {
+		t.Fatalf("expected --max-age TTL, got %v (err=%v)", ttls, err)
+	}
+	if _, err := resolveCacheTTLs(cfg, "later"); err == nil {
+		t.Fatal("expected an error for an invalid --max-age")
+	}
+}
+
+func TestCheckAppsFallsBackToCacheWhenRateLimited(t *testing.T) {
+	cfg := &config.Config{Apps: []config.App{{Name: "tool"}, {Name: "other"}, {Name: "uncached"}}}
+	checkedAt := time.Now().Add(-48 * time.Hour)
+	c := &cache.Cache{Entries: map[string]cache.Entry{
+		"tool":  {LatestVersion: "v1.1.0", InstalledVersion: "v1.0.0", CheckedAt: checkedAt},
+		"other": {LatestVersion: "v2.0.0", InstalledVersion: "v2.0.0", CheckedAt: checkedAt},
+	}}
+	runner := &stubRunner{infos: map[string]*goversion.Info{
+		"tool":     {Path: "github.com/acme/tool", Version: "v1.0.0"},
+		"other":    {Path: "github.com/acme/other", Version: "v2.0.0"},
+		"uncached": {Path: "github.com/acme/uncached", Version: "v3.0.0"},
+	}}
+	limitErr := &github.RateLimitError{Reset: time.Now().Add(time.Hour)}
+	ghClient := &stubGitHubClient{errs: map[string]error{
+		"acme/tool":     limitErr,
+		"acme/other":    limitErr,
+		"acme/uncached": limitErr,
+	}}
+	var stdout, stderr bytes.Buffer
+
+	entries, _ := checkApps(cfg, c, checkOptions{}, checkDependencies{
+		runner:   runner,
+		ghClient: ghClient,
+		out:      &output.Writer{Out: &stdout},
+		errOut:   &output.Writer{Out: &stderr},
+	})
+
+	if entries[0].Source != sourceCache || entries[0].LatestVersion != "v1.1.0" || !entries[0].UpdateAvailable {
+		t.Fatalf("expected cached result for tool, got %+v", entries[0])
+	}
+	if entries[1].Source != sourceCache || entries[1].LatestVersion != "v2.0.0" {
+		t.Fatalf("expected cached result for other, got %+v", entries[1])
+	}
+	if entries[2].LatestVersion != "unknown" {
+		t.Fatalf("expected unknown latest version for uncached, got %+v", entries[2])
+	}
+	if n := strings.Count(stderr.String(), "rate limit exceeded"); n != 1 {
+		t.Fatalf("expected one rate limit warning, got %d:\n%s", n, stderr.String())
+	}
+	if !strings.Contains(stderr.String(), "github_auth: true") {
+		t.Fatalf("expected a hint to enable github_auth:\n%s", stderr.String())
+	}
+	if stdout.Len() != 0 {
+		t.Fatalf("expected no warnings on stdout, got %q", stdout.String())
+	}
+}
+
+func TestCheckAppsReportsVerification(t *testing.T) {
+	cfg := &config.Config{Apps: []config.App{{Name: "tampered"}, {Name: "cached"}, {Name: "current"}}}
+	c := &cache.Cache{Entries: map[string]cache.Entry{
+		"cached": {LatestVersion: "v2.0.0", InstalledVersion: "v1.0.0", CheckedAt: time.Now(), Verification: gomodule.VerifyOK},
+	}}
+	runner := &stubRunner{infos: map[string]*goversion.Info{
+		"tampered": {Path: "github.com/acme/tampered", Version: "v1.0.0"},
+		"cached":   {Path: "github.com/acme/cached", Version: "v1.0.0"},
+		"current":  {Path: "github.com/acme/current", Version: "v1.0.0"},
+	}}
+	ghClient := &stubGitHubClient{releases: map[string]string{
+		"acme/tampered": "v2.0.0",
+		"acme/current":  "v1.0.0",
+	}}
+	verifier := &stubVerifier{results: map[string]gomodule.Verification{
+		"github.com/acme/tampered@v2.0.0": {Status: gomodule.VerifyMismatch, Detail: "go.mod hash differs"},
+	}}
+	var stdout, stderr bytes.Buffer
+
+	entries, _ := checkApps(cfg, c, checkOptions{}, checkDependencies{
+		runner:   runner,
+		ghClient: ghClient,
+		verifier: verifier,
+		out:      &output.Writer{Out: &stdout},
+		errOut:   &output.Writer{Out: &stderr},
+	})
+
+	if entries[0].Verification != gomodule.VerifyMismatch || entries[0].VerificationDetail != "go.mod hash differs" {
+		t.Fatalf("expected mismatch for tampered, got %+v", entries[0])
+	}
+	if c.Entries["tampered"].Verification != gomodule.VerifyMismatch {
+		t.Fatalf("expected mismatch to be cached, got %+v", c.Entries["tampered"])
+	}
+	if entries[1].Source != sourceCache || entries[1].Verification != gomodule.VerifyOK {
+		t.Fatalf("expected cached verification for cached, got %+v", entries[1])
+	}
+	if entries[2].Verification != "" {
+		t.Fatalf("expected no verification without an update, got %+v", entries[2])
+	}
+	if !strings.Contains(stderr.String(), "Checksum mismatch for 'tampered' v2.0.0: go.mod hash differs") {
+		t.Fatalf("expected mismatch warning on stderr, got %q", stderr.String())
+	}
+	if stdout.Len() != 0 {
+		t.Fatalf("expected no warnings on stdout, got %q", stdout.String())
+	}
+}
+
+func TestCheckAppsReportsRetractedAndDeprecated(t *testing.T) {
+	cfg := &config.Config{Apps: []config.App{{Name: "tool"}, {Name: "old"}}}
+	c := &cache.Cache{Entries: map[string]cache.Entry{
+		"old": {LatestVersion: "v1.0.0", InstalledVersion: "v1.0.0", CheckedAt: time.Now(), Deprecated: "use example.com/new"},
+	}}
+	runner := &stubRunner{infos: map[string]*goversion.Info{
+		"tool": {Path: "github.com/acme/tool", Version: "v1.2.0"},
+		"old":  {Path: "example.com/old", Version: "v1.0.0"},
+	}}
+	ghClient := &stubGitHubClient{releases: map[string]string{"acme/tool": "v1.3.0"}}
+	resolver := &stubStatusResolver{statuses: map[string]gomodule.Status{
+		"github.com/acme/tool@v1.2.0": {Retracted: true, Rationale: "Corrupts the config file."},
+	}}
+	var stdout, stderr bytes.Buffer
+
+	entries, _ := checkApps(cfg, c, checkOptions{}, checkDependencies{
+		runner:   runner,
+		ghClient: ghClient,
+		resolver: resolver,
+		out:      &output.Writer{Out: &stdout},
+		errOut:   &output.Writer{Out: &stderr},
+	})
+
+	if !entries[0].Retracted || entries[0].RetractionRationale != "Corrupts the config file." || entries[0].LatestVersion != "v1.3.0" {
+		t.Fatalf("expected retracted tool with update, got %+v", entries[0])
+	}
+	if !c.Entries["tool"].Retracted {
+		t.Fatalf("expected retraction to be cached, got %+v", c.Entries["tool"])
+	}
+	if entries[1].Source != sourceCache || entries[1].Deprecated != "use example.com/new" {
+		t.Fatalf("expected cached deprecation for old, got %+v", entries[1])
+	}
+	for _, want := range []string{
+		"'tool' v1.2.0 has been retracted by its author: Corrupts the config file.",
+		"'old' is deprecated: use example.com/new",
+	} {
+		if !strings.Contains(stderr.String(), want) {
+			t.Fatalf("expected %q on stderr, got %q", want, stderr.String())
+		}
+	}
+	if stdout.Len() != 0 {
+		t.Fatalf("expected no warnings on stdout, got %q", stdout.String())
+	}
+}
+
+func TestCheckJSONStaysParseableWhenRateLimited(t *testing.T) {
+	cfg := &config.Config{Apps: []config.App{{Name: "tool"}, {Name: "uncached"}}}
+	c := &cache.Cache{Entries: map[string]cache.Entry{
+		"tool": {LatestVersion: "v1.1.0", InstalledVersion: "v1.0.0", CheckedAt: time.Now().Add(-48 * time.Hour)},
+	}}
+	runner := &stubRunner{infos: map[string]*goversion.Info{
+		"tool":     {Path: "github.com/acme/tool", Version: "v1.0.0"},
+		"uncached": {Path: "github.com/acme/uncached", Version: "v3.0.0"},
+	}}
+	limitErr := &github.RateLimitError{Reset: time.Now().Add(time.Hour)}
+	ghClient := &stubGitHubClient{errs: map[string]error{"acme/tool": limitErr, "acme/uncached": limitErr}}
+	var stdout, stderr bytes.Buffer
+
+	checkAndReport(cfg, c, checkOptions{JSON: true}, nil, checkDependencies{
+		runner:   runner,
+		ghClient: ghClient,
+		out:      &output.Writer{Out: &stdout},
+		errOut:   &output.Writer{Out: &stderr},
+	})
+
+	var entries []checkEntry
+	if err := json.Unmarshal(stdout.Bytes(), &entries); err != nil {
+		t.Fatalf("stdout is not JSON: %v\n%s", err, stdout.String())
+	}
+	if len(entries) != 2 || entries[0].LatestVersion != "v1.1.0" || entries[1].LatestVersion != "unknown" {
+		t.Fatalf("unexpected entries: %+v", entries)
+	}
+	if !strings.Contains(stderr.String(), "rate limit exceeded") {
+		t.Fatalf("expected the rate limit warning on stderr, got %q", stderr.String())
+	}
+}
+
+func TestCachedCheckEntryIgnoresResultsForAnotherVersion(t *testing.T) {
+	cached := cache.Entry{
+		InstalledVersion: "v1.0.0", LatestVersion: "v1.2.0", CheckedAt: time.Now(),
+		Retracted: true, RetractionRationale: "Broken build.", Deprecated: "use example.com/new",
+		Verification: gomodule.VerifyMismatch, VerificationDetail: "go.mod hash differs",
+	}
+
+	same := cachedCheckEntry(checkEntry{Name: "tool", InstalledVersion: "v1.0.0"}, cached)
+	if !same.Retracted || same.Verification != gomodule.VerifyMismatch {
+		t.Fatalf("expected cached results for the same version, got %+v", same)
+	}
+
+	other := cachedCheckEntry(checkEntry{Name: "tool", InstalledVersion: "v1.1.0"}, cached)
+	if other.Retracted || other.RetractionRationale != "" || other.Verification != "" || other.VerificationDetail != "" {
+		t.Fatalf("expected no retraction or verification for another version, got %+v", other)
+	}
+	if !other.UpdateAvailable || other.LatestVersion != "v1.2.0" || other.Deprecated != "use example.com/new" {
+		t.Fatalf("expected the latest version and module deprecation to be kept, got %+v", other)
+	}
+}
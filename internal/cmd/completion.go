@@ -0,0 +1,449 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"runtime"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/UnitVectorY-Labs/gogitup/internal/config"
+	"github.com/UnitVectorY-Labs/gogitup/internal/installer"
+	"github.com/UnitVectorY-Labs/gogitup/internal/output"
+)
+
+// completeFiles is printed by __complete when the shell should complete file
+// names itself.
+const completeFiles = ":file"
+
+// argKind is what a command's positional arguments are completed with.
+type argKind int
+
+const (
+	argNone argKind = iota
+	// argApps completes registered app names.
+	argApps
+	// argUntracked completes binaries in the go install directory that are
+	// not registered.
+	argUntracked
+	// argConfigKeys completes the keys of gogitup config.
+	argConfigKeys
+	// argFiles leaves completion to the shell's file name completion.
+	argFiles
+	// argShells completes the shells in completionSpec.values.
+	argShells
+)
+
+// completionSpec describes a command, or a subcommand, for completion.
+type completionSpec struct {
+	// flags are the flag names; those ending in "=" take a value.
+	flags       []string
+	args        argKind
+	values      []string
+	subcommands map[string]completionSpec
+}
+
+var targetFlagNames = []string{"os=", "arch=", "bin-dir="}
+
+// completionCommands are the commands in the order help lists them, with
+// what each accepts. Keep them in sync with the flag sets of the commands.
+var completionCommands = []struct {
+	name string
+	spec completionSpec
+}{
+	{"add", completionSpec{flags: []string{"create-profile"}, args: argUntracked}},
+	{"install", completionSpec{flags: append([]string{"create-profile"}, targetFlagNames...)}},
+	{"remove", completionSpec{flags: []string{"delete"}, args: argApps}},
+	{"list", completionSpec{flags: []string{"json"}}},
+	{"check", completionSpec{flags: []string{"json", "force", "offline", "max-age=", "notify"}}},
+	{"status", completionSpec{flags: []string{"short", "refresh"}}},
+	{"upgrade", completionSpec{flags: append([]string{"verbose"}, targetFlagNames...)}},
+	{"bundle", completionSpec{flags: append([]string{"out="}, targetFlagNames...)}},
+	{"unbundle", completionSpec{flags: []string{"bin-dir="}, args: argFiles}},
+	{"cache", completionSpec{subcommands: map[string]completionSpec{
+		"show":  {flags: []string{"json"}},
+		"clear": {args: argApps},
+		"prune": {},
+	}}},
+	{"config", completionSpec{subcommands: map[string]completionSpec{
+		"get":   {args: argConfigKeys},
+		"set":   {args: argConfigKeys},
+		"unset": {args: argConfigKeys},
+		"list":  {},
+	}}},
+	{"schedule", completionSpec{subcommands: map[string]completionSpec{
+		"enable":  {flags: []string{"daily", "weekly", "auto-upgrade"}},
+		"disable": {},
+		"status":  {},
+	}}},
+	{"shell-init", completionSpec{args: argShells, values: []string{"bash", "zsh", "fish"}}},
+	{"completion", completionSpec{args: argShells, values: completionShells}},
+	{"doctor", completionSpec{}},
+	{"help", completionSpec{}},
+}
+
+var globalFlagNames = []string{"config=", "profile=", "version", "help"}
+
+// Common values of --os and --arch. Others can still be typed.
+var (
+	completionGOOS   = []string{"aix", "android", "darwin", "dragonfly", "freebsd", "illumos", "ios", "js", "linux", "netbsd", "openbsd", "plan9", "solaris", "wasip1", "windows"}
+	completionGOARCH = []string{"386", "amd64", "arm", "arm64", "loong64", "mips", "mips64", "mips64le", "mipsle", "ppc64", "ppc64le", "riscv64", "s390x", "wasm"}
+)
+
+// completionSource looks up the dynamic candidates.
+type completionSource interface {
+	apps() []string
+	untracked() []string
+	configKeys() []string
+	profiles() []string
+}
+
+// complete returns the candidates for the last of words, the arguments typed
+// after gogitup, filtered by what has been typed of it. It returns
+// completeFiles when the shell should complete file names.
+func complete(words []string, src completionSource) []string {
+	if len(words) == 0 {
+		words = []string{""}
+	}
+	cur, words := words[len(words)-1], words[:len(words)-1]
+
+	// Global flags come first.
+	for len(words) > 0 && strings.HasPrefix(words[0], "-") {
+		name, _, hasValue := strings.Cut(words[0], "=")
+		words = words[1:]
+		if !hasValue && (name == "--config" || name == "--profile") {
+			if len(words) == 0 {
+				return completeFlagValue(name, cur, src)
+			}
+			words = words[1:]
+		}
+	}
+	if len(words) == 0 {
+		if value, ok := strings.CutPrefix(cur, "--config="); ok {
+			return prefixed("--config=", completeFlagValue("--config", value, src))
+		}
+		if value, ok := strings.CutPrefix(cur, "--profile="); ok {
+			return prefixed("--profile=", completeFlagValue("--profile", value, src))
+		}
+		if strings.HasPrefix(cur, "-") {
+			return matching(flagCandidates(globalFlagNames), cur)
+		}
+		var names []string
+		for _, c := range completionCommands {
+			names = append(names, c.name)
+		}
+		return matching(names, cur)
+	}
+
+	spec, ok := completionSpecFor(words[0])
+	if !ok {
+		return nil
+	}
+	words = words[1:]
+	if spec.subcommands != nil {
+		if len(words) == 0 {
+			return matching(sortedKeys(spec.subcommands), cur)
+		}
+		spec, ok = spec.subcommands[words[0]]
+		if !ok {
+			return nil
+		}
+		words = words[1:]
+	}
+
+	// Skip the flags typed so far, noticing when cur is a flag's value.
+	positional := 0
+	for i := 0; i < len(words); i++ {
+		if !strings.HasPrefix(words[i], "-") {
+			positional++
+			continue
+		}
+		if takesValue(spec, words[i]) && !strings.Contains(words[i], "=") {
+			if i == len(words)-1 {
+				return completeFlagValue(words[i], cur, src)
+			}
+			i++
+		}
+	}
+	if strings.HasPrefix(cur, "-") {
+		if name, value, ok := strings.Cut(cur, "="); ok {
+			return prefixed(name+"=", completeFlagValue(name, value, src))
+		}
+		return matching(flagCandidates(spec.flags), cur)
+	}
+	if positional > 0 {
+		// Every command takes at most one completed argument.
+		return nil
+	}
+
+	switch spec.args {
+	case argApps:
+		return matching(src.apps(), cur)
+	case argUntracked:
+		return matching(src.untracked(), cur)
+	case argConfigKeys:
+		return matching(src.configKeys(), cur)
+	case argFiles:
+		return []string{completeFiles}
+	case argShells:
+		return matching(spec.values, cur)
+	}
+	return nil
+}
+
+func completionSpecFor(name string) (completionSpec, bool) {
+	for _, c := range completionCommands {
+		if c.name == name {
+			return c.spec, true
+		}
+	}
+	return completionSpec{}, false
+}
+
+// takesValue reports whether flag, such as "--os", takes a value.
+func takesValue(spec completionSpec, flag string) bool {
+	name, _, _ := strings.Cut(strings.TrimLeft(flag, "-"), "=")
+	return slices.Contains(spec.flags, name+"=")
+}
+
+// completeFlagValue completes value as the value of flag.
+func completeFlagValue(flag, value string, src completionSource) []string {
+	switch strings.TrimLeft(flag, "-") {
+	case "config", "out", "bin-dir":
+		return []string{completeFiles}
+	case "profile":
+		return matching(src.profiles(), value)
+	case "os":
+		return matching(completionGOOS, value)
+	case "arch":
+		return matching(completionGOARCH, value)
+	}
+	return nil
+}
+
+func flagCandidates(names []string) []string {
+	flags := make([]string, len(names))
+	for i, name := range names {
+		flags[i] = "--" + strings.TrimSuffix(name, "=")
+	}
+	return flags
+}
+
+func matching(candidates []string, prefix string) []string {
+	var out []string
+	for _, c := range candidates {
+		if strings.HasPrefix(c, prefix) {
+			out = append(out, c)
+		}
+	}
+	return out
+}
+
+// prefixed adds prefix to candidates, for values completed as --flag=value.
+func prefixed(prefix string, candidates []string) []string {
+	if slices.Equal(candidates, []string{completeFiles}) {
+		return candidates
+	}
+	out := make([]string, len(candidates))
+	for i, c := range candidates {
+		out[i] = prefix + c
+	}
+	return out
+}
+
+func sortedKeys(m map[string]completionSpec) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// configCompletionSource reads candidates from the config file and the go
+// install directory. Errors leave the candidates empty, since completion
+// must not print them.
+type configCompletionSource struct{}
+
+func (configCompletionSource) config() *config.Config {
+	cfg, err := config.LoadProfile(configPath(), profileName())
+	if err != nil {
+		return &config.Config{}
+	}
+	return cfg
+}
+
+func (s configCompletionSource) apps() []string {
+	var names []string
+	for _, app := range s.config().Apps {
+		names = append(names, app.Name)
+	}
+	return names
+}
+
+func (s configCompletionSource) untracked() []string {
+	dir, err := installer.DefaultBinDir()
+	if err != nil {
+		return nil
+	}
+	return untrackedBinaries(dir, s.config())
+}
+
+func (s configCompletionSource) configKeys() []string {
+	return config.Keys(s.config())
+}
+
+func (configCompletionSource) profiles() []string {
+	cfg, err := config.Load(configPath())
+	if err != nil {
+		return nil
+	}
+	var names []string
+	for name := range cfg.Profiles {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// untrackedBinaries lists the executables in dir that are not registered in
+// cfg.
+func untrackedBinaries(dir string, cfg *config.Config) []string {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil
+	}
+	var names []string
+	for _, e := range entries {
+		info, err := e.Info()
+		if err != nil || !info.Mode().IsRegular() {
+			continue
+		}
+		name := e.Name()
+		if runtime.GOOS == "windows" {
+			var ok bool
+			if name, ok = strings.CutSuffix(name, ".exe"); !ok {
+				continue
+			}
+		} else if info.Mode().Perm()&0111 == 0 {
+			continue
+		}
+		if !config.HasApp(cfg, name) {
+			names = append(names, name)
+		}
+	}
+	return names
+}
+
+// runComplete prints the completion candidates for the arguments typed
+// after gogitup, one per line. The shell scripts from gogitup completion
+// call it as gogitup __complete <args...> <word being completed>.
+func runComplete(args []string) {
+	// The global flags on the command line being completed select the
+	// config file and profile.
+	words := args[:max(len(args)-1, 0)]
+	for len(words) > 0 {
+		name, value, hasValue := strings.Cut(words[0], "=")
+		if name != "--config" && name != "--profile" {
+			break
+		}
+		if !hasValue {
+			if len(words) < 2 {
+				break
+			}
+			value, words = words[1], words[1:]
+		}
+		words = words[1:]
+		if name == "--config" {
+			configPathOverride = value
+		} else {
+			profileOverride = value
+		}
+	}
+	for _, c := range complete(args, configCompletionSource{}) {
+		fmt.Println(c)
+	}
+}
+
+// completionShells are the shells gogitup completion supports.
+var completionShells = []string{"bash", "zsh", "fish", "powershell"}
+
+const completionUsage = "Usage: gogitup completion <bash|zsh|fish|powershell>"
+
+func runCompletion(args []string) {
+	if len(args) != 1 {
+		output.Error(completionUsage)
+		os.Exit(1)
+	}
+	script, ok := completionScripts[args[0]]
+	if !ok {
+		output.Error(fmt.Sprintf("unsupported shell %q", args[0]))
+		output.Error(completionUsage)
+		os.Exit(1)
+	}
+	fmt.Print(script)
+}
+
+// completionScripts ask gogitup __complete for candidates, so that they stay
+// in sync with the commands and see the current config.
+var completionScripts = map[string]string{
+	"bash": `# bash completion for gogitup
+_gogitup() {
+    local cur="${COMP_WORDS[COMP_CWORD]}" out
+    out=$(gogitup __complete "${COMP_WORDS[@]:1:COMP_CWORD}" 2>/dev/null)
+    if [[ $out == ":file" ]]; then
+        compopt -o filenames 2>/dev/null
+        COMPREPLY=($(compgen -f -- "$cur"))
+        return
+    fi
+    local IFS=$'\n'
+    COMPREPLY=($(compgen -W "$out" -- "$cur"))
+}
+complete -F _gogitup gogitup
+`,
+	"zsh": `#compdef gogitup
+# zsh completion for gogitup
+_gogitup() {
+    local out
+    local -a candidates
+    out=$(gogitup __complete "${(@)words[2,CURRENT]}" 2>/dev/null)
+    if [[ $out == ":file" ]]; then
+        _files
+        return
+    fi
+    candidates=("${(@f)out}")
+    [[ -n $out ]] && compadd -a candidates
+}
+if [[ $funcstack[1] == _gogitup ]]; then
+    _gogitup "$@"
+else
+    compdef _gogitup gogitup
+fi
+`,
+	"fish": `# fish completion for gogitup
+function __gogitup_complete
+    set -l args (commandline -opc)[2..-1] (commandline -ct)
+    set -l out (gogitup __complete $args 2>/dev/null)
+    if test "$out" = ":file"
+        __fish_complete_path (commandline -ct)
+        return
+    end
+    printf '%s\n' $out
+end
+complete -c gogitup -f -a '(__gogitup_complete)'
+`,
+	"powershell": `# PowerShell completion for gogitup
+Register-ArgumentCompleter -Native -CommandName gogitup -ScriptBlock {
+    param($wordToComplete, $commandAst, $cursorPosition)
+    $words = @($commandAst.CommandElements | Select-Object -Skip 1 |
+        Where-Object { $_.Extent.EndOffset -le $cursorPosition } | ForEach-Object { $_.ToString() })
+    if ($wordToComplete -eq '') { $words += '' }
+    $out = @(& gogitup __complete @words 2>$null)
+    if ($out.Count -eq 1 -and $out[0] -eq ':file') { return }
+    $out | ForEach-Object {
+        [System.Management.Automation.CompletionResult]::new($_, $_, 'ParameterValue', $_)
+    }
+}
+`,
+}
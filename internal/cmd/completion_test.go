@@ -0,0 +1,141 @@
+package cmd
+
+import (
+	"bytes"
+	"io"
+	"os"
+	"path/filepath"
+	"runtime"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/UnitVectorY-Labs/gogitup/internal/config"
+)
+
+type stubCompletionSource struct{}
+
+func (stubCompletionSource) apps() []string      { return []string{"gopls", "golangci-lint", "staticcheck"} }
+func (stubCompletionSource) untracked() []string { return []string{"dlv", "gofumpt"} }
+func (stubCompletionSource) configKeys() []string {
+	return []string{"cache_ttl", "goproxy", "apps.gopls.version"}
+}
+func (stubCompletionSource) profiles() []string { return []string{"personal", "work"} }
+
+func TestComplete(t *testing.T) {
+	tests := []struct {
+		words []string
+		want  []string
+	}{
+		{[]string{"c"}, []string{"check", "cache", "config", "completion"}},
+		{[]string{"--"}, []string{"--config", "--profile", "--version", "--help"}},
+		{[]string{"--profile", "w"}, []string{"work"}},
+		{[]string{"--profile=p"}, []string{"--profile=personal"}},
+		{[]string{"--config", ""}, []string{completeFiles}},
+		{[]string{"--profile", "work", "rem"}, []string{"remove"}},
+		{[]string{"remove", "go"}, []string{"gopls", "golangci-lint"}},
+		{[]string{"remove", "gopls", ""}, nil},
+		{[]string{"remove", "--"}, []string{"--delete"}},
+		{[]string{"add", ""}, []string{"dlv", "gofumpt"}},
+		{[]string{"check", "--o"}, []string{"--offline"}},
+		{[]string{"upgrade", "--os", "l"}, []string{"linux"}},
+		{[]string{"upgrade", "--arch=arm"}, []string{"--arch=arm", "--arch=arm64"}},
+		{[]string{"upgrade", "--os", "linux", "--v"}, []string{"--verbose"}},
+		{[]string{"bundle", "--out", ""}, []string{completeFiles}},
+		{[]string{"unbundle", ""}, []string{completeFiles}},
+		{[]string{"cache", ""}, []string{"clear", "prune", "show"}},
+		{[]string{"cache", "clear", "s"}, []string{"staticcheck"}},
+		{[]string{"config", "set", "apps."}, []string{"apps.gopls.version"}},
+		{[]string{"schedule", "enable", "--"}, []string{"--daily", "--weekly", "--auto-upgrade"}},
+		{[]string{"completion", "p"}, []string{"powershell"}},
+		{[]string{"shell-init", "p"}, nil},
+		{[]string{"nope", ""}, nil},
+	}
+	for _, tt := range tests {
+		got := complete(tt.words, stubCompletionSource{})
+		if !slices.Equal(got, tt.want) {
+			t.Errorf("complete(%q) = %q, want %q", tt.words, got, tt.want)
+		}
+	}
+}
+
+// TestCompletionFlagsMatchCommands guards against the completion table
+// drifting from the flag sets of the commands.
+func TestCompletionFlagsMatchCommands(t *testing.T) {
+	parsers := map[string]func([]string, io.Writer) error{
+		"add":     func(a []string, w io.Writer) error { _, err := parseAddOptions(append(a, "tool"), w); return err },
+		"install": func(a []string, w io.Writer) error { _, err := parseInstallOptions(a, w); return err },
+		"check":   func(a []string, w io.Writer) error { _, err := parseCheckOptions(a, w); return err },
+		"status":  func(a []string, w io.Writer) error { _, err := parseStatusOptions(a, w); return err },
+		"upgrade": func(a []string, w io.Writer) error { _, err := parseUpgradeOptions(a, w); return err },
+		"bundle":  func(a []string, w io.Writer) error { _, err := parseBundleOptions(a, w); return err },
+		"unbundle": func(a []string, w io.Writer) error {
+			_, err := parseUnbundleOptions(a, w)
+			return err
+		},
+	}
+	for name, parse := range parsers {
+		spec, ok := completionSpecFor(name)
+		if !ok {
+			t.Fatalf("no completion for %s", name)
+		}
+		for _, flag := range spec.flags {
+			arg := "--" + flag
+			if strings.HasSuffix(flag, "=") {
+				arg += "x"
+			}
+			var stderr bytes.Buffer
+			if err := parse([]string{arg}, &stderr); err != nil && strings.Contains(err.Error(), "not defined") {
+				t.Errorf("%s does not accept %s", name, arg)
+			}
+		}
+	}
+
+	spec, _ := completionSpecFor("schedule")
+	for _, flag := range spec.subcommands["enable"].flags {
+		var stderr bytes.Buffer
+		if _, err := parseScheduleEnableOptions([]string{"--" + flag}, &stderr); err != nil {
+			t.Errorf("schedule enable does not accept --%s: %v", flag, err)
+		}
+	}
+}
+
+func TestCompletionScripts(t *testing.T) {
+	for _, shell := range completionShells {
+		script, ok := completionScripts[shell]
+		if !ok {
+			t.Errorf("no completion script for %s", shell)
+			continue
+		}
+		if !strings.Contains(script, "gogitup __complete") {
+			t.Errorf("%s script does not call gogitup __complete", shell)
+		}
+	}
+}
+
+func TestUntrackedBinaries(t *testing.T) {
+	dir := t.TempDir()
+	ext := ""
+	if runtime.GOOS == "windows" {
+		ext = ".exe"
+	}
+	for _, name := range []string{"gopls", "dlv"} {
+		if err := os.WriteFile(filepath.Join(dir, name+ext), nil, 0755); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := os.WriteFile(filepath.Join(dir, "notes.txt"), nil, 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Mkdir(filepath.Join(dir, "sub"+ext), 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	cfg := &config.Config{Apps: []config.App{{Name: "gopls"}}}
+	if got := untrackedBinaries(dir, cfg); !slices.Equal(got, []string{"dlv"}) {
+		t.Fatalf("untrackedBinaries() = %q, want [dlv]", got)
+	}
+	if got := untrackedBinaries(filepath.Join(dir, "missing"), cfg); got != nil {
+		t.Fatalf("untrackedBinaries() for a missing directory = %q", got)
+	}
+}
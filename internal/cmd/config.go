@@ -0,0 +1,127 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/UnitVectorY-Labs/gogitup/internal/config"
+	"github.com/UnitVectorY-Labs/gogitup/internal/output"
+)
+
+const configUsage = "Usage: gogitup config <get <key>|set <key> <value>|unset <key>|list>"
+
+func runConfig(args []string) {
+	if len(args) < 1 {
+		output.Error(configUsage)
+		os.Exit(1)
+	}
+
+	switch args[0] {
+	case "get":
+		expectConfigArgs(args, 2)
+		doc := loadDocumentOrExit()
+		value, ok, err := config.GetValue(doc, profileKeyOrExit(doc, args[1]))
+		if err != nil {
+			output.Error(err.Error())
+			os.Exit(1)
+		}
+		if !ok {
+			// Like git config, an unset key prints nothing and exits 1.
+			os.Exit(1)
+		}
+		fmt.Println(value)
+	case "set":
+		expectConfigArgs(args, 3)
+		defer lockState()()
+		doc := loadDocumentOrExit()
+		key := profileKeyOrExit(doc, args[1])
+		if err := config.SetValue(doc, key, args[2]); err != nil {
+			output.Error(err.Error())
+			os.Exit(1)
+		}
+		saveDocumentOrExit(doc)
+		output.Success(fmt.Sprintf("Set %s", key))
+	case "unset":
+		expectConfigArgs(args, 2)
+		defer lockState()()
+		doc := loadDocumentOrExit()
+		key := profileKeyOrExit(doc, args[1])
+		removed, err := config.UnsetValue(doc, key)
+		if err != nil {
+			output.Error(err.Error())
+			os.Exit(1)
+		}
+		if !removed {
+			output.Info(fmt.Sprintf("%s is not set", key))
+			return
+		}
+		saveDocumentOrExit(doc)
+		output.Success(fmt.Sprintf("Unset %s", key))
+	case "list":
+		expectConfigArgs(args, 1)
+		for _, kv := range config.ListValues(loadDocumentOrExit()) {
+			fmt.Printf("%s=%s\n", kv.Key, kv.Value)
+		}
+	default:
+		output.Error("Unknown config command: " + args[0])
+		output.Error(configUsage)
+		os.Exit(1)
+	}
+}
+
+func expectConfigArgs(args []string, n int) {
+	if len(args) != n {
+		output.Error(configUsage)
+		os.Exit(1)
+	}
+}
+
+// profileKey scopes key to the profile selected with --profile, unless it
+// already names a profile or applies to every profile. The selected profile
+// must exist; a key that names the profile explicitly creates it.
+func profileKey(doc *config.Document, key string) (string, error) {
+	name := profileName()
+	if name == "" || strings.HasPrefix(key, "profiles.") || config.IsGlobalKey(key) {
+		return key, nil
+	}
+	scoped := "profiles." + name + "." + key
+	if !config.HasProfile(doc, name) {
+		return "", fmt.Errorf("%w %q; use the key %s to create it", config.ErrUnknownProfile, name, scoped)
+	}
+	return scoped, nil
+}
+
+func profileKeyOrExit(doc *config.Document, key string) string {
+	scoped, err := profileKey(doc, key)
+	if err != nil {
+		output.Error(err.Error())
+		os.Exit(1)
+	}
+	return scoped
+}
+
+func loadDocumentOrExit() *config.Document {
+	doc, err := config.LoadDocument(configPath())
+	if err != nil {
+		output.Error(fmt.Sprintf("Failed to load config: %v", err))
+		os.Exit(1)
+	}
+	return doc
+}
+
+func saveDocumentOrExit(doc *config.Document) {
+	if err := config.SaveDocument(configPath(), doc); err != nil {
+		output.Error(fmt.Sprintf("Failed to save config: %v", err))
+		os.Exit(1)
+	}
+	warnConfigProblems(configPath(), config.Problems(doc))
+}
+
+// warnConfigProblems reports the problems a config file still has after an
+// edit that was allowed to leave them in place.
+func warnConfigProblems(path string, problems []string) {
+	for _, problem := range problems {
+		output.Warn(fmt.Sprintf("%s: %s", path, problem))
+	}
+}
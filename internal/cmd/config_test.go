@@ -0,0 +1,74 @@
+package cmd
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/UnitVectorY-Labs/gogitup/internal/config"
+)
+
+func TestProfileKey(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	if err := os.WriteFile(path, []byte("profiles:\n  work:\n    apps: []\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	doc, err := config.LoadDocument(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	t.Setenv(envProfile, "")
+	profileOverride = ""
+	if got, err := profileKey(doc, "goproxy"); err != nil || got != "goproxy" {
+		t.Fatalf("expected unscoped key, got %q, %v", got, err)
+	}
+
+	profileOverride = "work"
+	t.Cleanup(func() { profileOverride = "" })
+	if got, err := profileKey(doc, "apps.tool.cache_ttl"); err != nil || got != "profiles.work.apps.tool.cache_ttl" {
+		t.Fatalf("expected profile-scoped key, got %q, %v", got, err)
+	}
+	if got, err := profileKey(doc, "profiles.home.goos"); err != nil || got != "profiles.home.goos" {
+		t.Fatalf("expected explicit profile key to be kept, got %q, %v", got, err)
+	}
+
+	profileOverride = "wrok"
+	if _, err := profileKey(doc, "goproxy"); !errors.Is(err, config.ErrUnknownProfile) {
+		t.Fatalf("expected an unknown profile error, got %v", err)
+	}
+}
+
+func TestLoadProfileToEdit(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	if err := config.Save(path, &config.Config{Profiles: map[string]*config.Profile{"work": {}}}); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv(envProfile, "")
+	t.Cleanup(func() { profileOverride = "" })
+
+	profileOverride = ""
+	if _, err := loadProfileToEdit(path, true); err == nil {
+		t.Fatal("expected --create-profile without a profile to fail")
+	}
+
+	profileOverride = "wrok"
+	if _, err := loadProfileToEdit(path, false); !errors.Is(err, config.ErrUnknownProfile) {
+		t.Fatalf("expected an unknown profile error, got %v", err)
+	}
+
+	cfg, err := loadProfileToEdit(path, true)
+	if err != nil {
+		t.Fatalf("loadProfileToEdit() error: %v", err)
+	}
+	if err := config.AddApp(cfg, "tool"); err != nil {
+		t.Fatal(err)
+	}
+	if err := config.Save(path, cfg); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := config.LoadProfile(path, "wrok"); err != nil {
+		t.Fatalf("expected the created profile to be saved, got %v", err)
+	}
+}
@@ -0,0 +1,9 @@
+//go:build !unix
+
+package cmd
+
+import "os/exec"
+
+// detach does nothing where sessions are not supported; the started process
+// already outlives gogitup.
+func detach(cmd *exec.Cmd) {}
@@ -0,0 +1,386 @@
+package cmd
+
+import (
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/UnitVectorY-Labs/gogitup/internal/config"
+	"github.com/UnitVectorY-Labs/gogitup/internal/github"
+	"github.com/UnitVectorY-Labs/gogitup/internal/goversion"
+	"github.com/UnitVectorY-Labs/gogitup/internal/installer"
+	"github.com/UnitVectorY-Labs/gogitup/internal/netconfig"
+	"github.com/UnitVectorY-Labs/gogitup/internal/output"
+)
+
+// rateLimiter reports the GitHub API rate limit for the configured credentials.
+type rateLimiter interface {
+	GetRateLimit() (github.RateLimit, error)
+}
+
+type doctorDependencies struct {
+	runner goversion.Runner
+	// limiter returns the rate limit client for the github.com API using
+	// token, and hostLimiter the one for a GitHub Enterprise host.
+	limiter     func(token string) rateLimiter
+	hostLimiter func(h config.GitHubHost, token string) rateLimiter
+}
+
+// doctorReport prints check results and counts problems.
+type doctorReport struct {
+	out      *output.Writer
+	failures int
+	warnings int
+}
+
+func (r *doctorReport) ok(msg string) {
+	r.out.Success(msg)
+}
+
+func (r *doctorReport) info(msg string) {
+	r.out.Info(msg)
+}
+
+func (r *doctorReport) warn(msg string) {
+	r.warnings++
+	r.out.Warn(msg)
+}
+
+func (r *doctorReport) fail(msg string) {
+	r.failures++
+	r.out.Error(msg)
+}
+
+func (r *doctorReport) section(title string) {
+	fmt.Fprintln(r.out.Out)
+	r.out.Header(title)
+}
+
+func runDoctor(args []string) {
+	if len(args) != 0 {
+		output.Error("Usage: gogitup doctor")
+		os.Exit(1)
+	}
+
+	report := &doctorReport{out: output.DefaultWriter}
+
+	report.section("Config")
+	cfg := doctorConfig(report, configPath())
+
+	report.section("Go toolchain")
+	doctorGo(report)
+	doctorGOBIN(report)
+
+	if cfg != nil {
+		report.section("Network")
+		networkOK := doctorNetwork(report, networkOptions(cfg), doctorProbeURLs(cfg))
+
+		deps := doctorDependencies{
+			runner: &goversion.DefaultRunner{},
+			limiter: func(token string) rateLimiter {
+				return newGitHubClientWithToken(cfg, token, nil)
+			},
+			hostLimiter: func(h config.GitHubHost, token string) rateLimiter {
+				return newGitHubHostClientWithToken(cfg, h, token, nil)
+			},
+		}
+		if networkOK {
+			report.section("GitHub API")
+			doctorGitHub(report, cfg, deps)
+			for _, h := range cfg.GitHubHosts {
+				doctorGitHubHost(report, h, deps)
+			}
+		}
+
+		report.section("Registered binaries")
+		doctorApps(report, cfg, deps)
+	}
+
+	fmt.Println()
+	switch {
+	case report.failures > 0:
+		output.Error(fmt.Sprintf("%d problem(s) found, %d warning(s)", report.failures, report.warnings))
+		os.Exit(1)
+	case report.warnings > 0:
+		output.Warn(fmt.Sprintf("No problems found, %d warning(s)", report.warnings))
+	default:
+		output.Success("No problems found")
+	}
+}
+
+// doctorConfig loads and validates the config, returning nil if it is unusable.
+func doctorConfig(r *doctorReport, path string) *config.Config {
+	if _, err := os.Stat(path); errors.Is(err, os.ErrNotExist) {
+		r.info(fmt.Sprintf("No config file at %s; defaults are used", path))
+	}
+
+	cfg, err := config.LoadProfile(path, profileName())
+	var invalid *config.ValidationError
+	switch {
+	case errors.As(err, &invalid):
+		for _, problem := range invalid.Problems {
+			r.fail(fmt.Sprintf("%s: %s", path, problem))
+		}
+		return nil
+	case err != nil:
+		r.fail(err.Error())
+		return nil
+	}
+
+	r.ok(fmt.Sprintf("Config file %s is valid", path))
+	if name := config.ProfileName(cfg); name != "" {
+		r.info(fmt.Sprintf("Using profile %q", name))
+	}
+	return cfg
+}
+
+func doctorGo(r *doctorReport) {
+	goPath, err := exec.LookPath("go")
+	if err != nil {
+		r.fail("go is not on PATH; gogitup needs the Go toolchain to inspect and install binaries")
+		return
+	}
+	version, err := exec.Command("go", "env", "GOVERSION").Output()
+	if err != nil {
+		r.fail(fmt.Sprintf("go at %s could not be run: %v", goPath, err))
+		return
+	}
+	r.ok(fmt.Sprintf("go %s found at %s", strings.TrimPrefix(strings.TrimSpace(string(version)), "go"), goPath))
+}
+
+func doctorGOBIN(r *doctorReport) {
+	binDir, err := installer.DefaultBinDir()
+	if err != nil {
+		r.warn(fmt.Sprintf("Could not determine the go install directory: %v", err))
+		return
+	}
+	if dirOnPath(binDir, os.Getenv("PATH")) {
+		r.ok(fmt.Sprintf("go install directory %s is on PATH", binDir))
+		return
+	}
+	r.warn(fmt.Sprintf("go install directory %s is not on PATH; installed binaries will not be found", binDir))
+}
+
+// dirOnPath reports whether dir is one of the entries of the PATH list.
+func dirOnPath(dir, pathList string) bool {
+	want := filepath.Clean(dir)
+	for _, entry := range filepath.SplitList(pathList) {
+		if entry != "" && filepath.Clean(entry) == want {
+			return true
+		}
+	}
+	return false
+}
+
+// doctorProbeTimeout bounds each connectivity check.
+const doctorProbeTimeout = 10 * time.Second
+
+// doctorProbeURLs returns the servers whose connectivity doctor checks: the
+// GitHub API and the first module proxy in GOPROXY.
+func doctorProbeURLs(cfg *config.Config) []string {
+	urls := []string{github.DefaultBaseURL}
+	goproxy := cfg.GOPROXY
+	if goproxy == "" {
+		goproxy = os.Getenv("GOPROXY")
+	}
+	if goproxy == "" {
+		goproxy = "https://proxy.golang.org"
+	}
+	if first := strings.FieldsFunc(goproxy, func(r rune) bool { return r == ',' || r == '|' }); len(first) > 0 && strings.Contains(first[0], "://") {
+		urls = append(urls, first[0])
+	}
+	return urls
+}
+
+// doctorNetwork reports the proxy and TLS settings in use and checks that
+// each of urls can be reached with them. It returns false when the settings
+// cannot be loaded, in which case no network request can succeed.
+func doctorNetwork(r *doctorReport, opts netconfig.Options, urls []string) bool {
+	proxy, err := opts.ProxyURL()
+	if err != nil {
+		r.fail(err.Error())
+		return false
+	}
+	if proxy != nil {
+		r.info(fmt.Sprintf("Using proxy %s from https_proxy", proxy.Redacted()))
+	} else if req, err := http.NewRequest("GET", github.DefaultBaseURL, nil); err == nil {
+		if envProxy, err := http.ProxyFromEnvironment(req); err == nil && envProxy != nil {
+			r.info(fmt.Sprintf("Using proxy %s from the environment", envProxy.Redacted()))
+		}
+	}
+
+	if opts.CABundle != "" {
+		n, err := opts.CountCertificates()
+		if err != nil {
+			r.fail(err.Error())
+			return false
+		}
+		r.ok(fmt.Sprintf("CA bundle %s adds %d certificate(s) to the system roots", opts.CABundle, n))
+	}
+
+	cert, err := opts.ClientCertificate()
+	if err != nil {
+		r.fail(err.Error())
+		return false
+	}
+	if cert != nil {
+		doctorClientCert(r, cert)
+	}
+
+	transport, err := opts.Transport()
+	if err != nil {
+		r.fail(err.Error())
+		return false
+	}
+	client := &http.Client{Transport: transport, Timeout: doctorProbeTimeout}
+	for _, u := range urls {
+		resp, err := client.Head(u)
+		if err != nil {
+			r.warn(fmt.Sprintf("Could not connect to %s: %s", u, describeNetworkError(err)))
+			continue
+		}
+		resp.Body.Close()
+		r.ok(fmt.Sprintf("Connected to %s", u))
+	}
+	return true
+}
+
+// clientCertWarning is how long before expiry a client certificate is
+// reported as expiring soon.
+const clientCertWarning = 30 * 24 * time.Hour
+
+func doctorClientCert(r *doctorReport, cert *x509.Certificate) {
+	name := cert.Subject.CommonName
+	if name == "" {
+		name = cert.Subject.String()
+	}
+	expires := cert.NotAfter.Local().Format("2006-01-02")
+	switch until := time.Until(cert.NotAfter); {
+	case until <= 0:
+		r.fail(fmt.Sprintf("Client certificate %q expired on %s", name, expires))
+	case until < clientCertWarning:
+		r.warn(fmt.Sprintf("Client certificate %q expires soon, on %s", name, expires))
+	default:
+		r.ok(fmt.Sprintf("Client certificate %q is valid until %s", name, expires))
+	}
+}
+
+// describeNetworkError explains common causes of failed connections on
+// corporate networks and the setting that addresses each.
+func describeNetworkError(err error) string {
+	var unknownAuthority x509.UnknownAuthorityError
+	var hostname x509.HostnameError
+	var invalid x509.CertificateInvalidError
+	var dnsErr *net.DNSError
+	var netErr net.Error
+	msg := err.Error()
+	switch {
+	case errors.As(err, &unknownAuthority):
+		return "the server certificate is signed by an unknown authority; set ca_bundle to a PEM file with your organization's CA certificates"
+	case errors.As(err, &hostname):
+		return fmt.Sprintf("the server certificate does not match the host name: %v", hostname)
+	case errors.As(err, &invalid):
+		return fmt.Sprintf("the server certificate is invalid: %v", invalid)
+	case strings.Contains(msg, "proxyconnect"):
+		return fmt.Sprintf("could not connect through the proxy; check https_proxy: %v", err)
+	case strings.Contains(msg, "certificate required") || strings.Contains(msg, "bad certificate"):
+		return "the server requires a client certificate; set client_cert and client_key"
+	case errors.As(err, &dnsErr):
+		return fmt.Sprintf("could not resolve %s", dnsErr.Name)
+	case errors.As(err, &netErr) && netErr.Timeout():
+		return "the connection timed out"
+	}
+	return msg
+}
+
+func doctorGitHub(r *doctorReport, cfg *config.Config, deps doctorDependencies) {
+	var token github.Token
+	if !cfg.GitHubAuth {
+		r.info("github_auth is disabled; requests are unauthenticated")
+	} else {
+		tc := tokenConfig(cfg)
+		token = doctorToken(r, tc)
+		if token.Value == "" {
+			r.warn(fmt.Sprintf("github_auth is enabled but no token was found (tried %s)", strings.Join(tokenSources(tc), ", ")))
+		} else {
+			r.ok(fmt.Sprintf("Using the GitHub token from %s", token.Source))
+		}
+	}
+
+	doctorRateLimit(r, "GitHub", deps.limiter(token.Value))
+}
+
+// doctorGitHubHost reports where the token of a GitHub Enterprise host was
+// found and checks the host's rate limit with it.
+func doctorGitHubHost(r *doctorReport, h config.GitHubHost, deps doctorDependencies) {
+	tc := hostTokenConfig(h)
+	token := doctorToken(r, tc)
+	if token.Value == "" {
+		r.info(fmt.Sprintf("No token was found for %s (tried %s); requests are unauthenticated", h.Host, strings.Join(tokenSources(tc), ", ")))
+	} else {
+		r.ok(fmt.Sprintf("Using the %s token from %s", h.Host, token.Source))
+	}
+
+	doctorRateLimit(r, h.Host, deps.hostLimiter(h, token.Value))
+}
+
+// doctorToken looks up the token configured by tc, warning about sources that
+// are configured but fail.
+func doctorToken(r *doctorReport, tc github.TokenConfig) github.Token {
+	token, errs := github.LookupToken(tc)
+	for _, err := range errs {
+		r.warn(err.Error())
+	}
+	return token
+}
+
+// tokenSources returns the sources tc is looked up in, in order.
+func tokenSources(tc github.TokenConfig) []string {
+	if tc.Sources == nil {
+		return github.TokenSources
+	}
+	return tc.Sources
+}
+
+// doctorRateLimit checks that the API behind limiter accepts its token and
+// reports the remaining rate limit. label names the GitHub instance.
+func doctorRateLimit(r *doctorReport, label string, limiter rateLimiter) {
+	limit, err := limiter.GetRateLimit()
+	switch {
+	case errors.Is(err, github.ErrUnauthorized):
+		r.fail(fmt.Sprintf("The %s token was rejected; check that it is valid and not expired", label))
+		return
+	case err != nil:
+		r.warn(fmt.Sprintf("Could not reach the %s API: %v", label, err))
+		return
+	}
+
+	msg := fmt.Sprintf("%s rate limit: %d of %d requests remaining (resets at %s)", label, limit.Remaining, limit.Limit, limit.Reset.Local().Format("15:04"))
+	if limit.Remaining == 0 {
+		r.warn(msg)
+		return
+	}
+	r.ok(msg)
+}
+
+func doctorApps(r *doctorReport, cfg *config.Config, deps doctorDependencies) {
+	if len(cfg.Apps) == 0 {
+		r.info("No binaries registered")
+		return
+	}
+	for _, app := range cfg.Apps {
+		info, err := deps.runner.GetInfo(app.Name)
+		if err != nil {
+			r.fail(fmt.Sprintf("%s: %v", app.Name, err))
+			continue
+		}
+		r.ok(fmt.Sprintf("%s: %s@%s", app.Name, info.Path, info.Version))
+	}
+}
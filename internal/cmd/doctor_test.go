@@ -0,0 +1,146 @@
+package cmd
+
+import (
+	"bytes"
+	"encoding/pem"
+	"errors"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/UnitVectorY-Labs/gogitup/internal/config"
+	"github.com/UnitVectorY-Labs/gogitup/internal/github"
+	"github.com/UnitVectorY-Labs/gogitup/internal/goversion"
+	"github.com/UnitVectorY-Labs/gogitup/internal/netconfig"
+	"github.com/UnitVectorY-Labs/gogitup/internal/output"
+)
+
+type stubRateLimiter struct {
+	limit github.RateLimit
+	err   error
+}
+
+func (s *stubRateLimiter) GetRateLimit() (github.RateLimit, error) {
+	return s.limit, s.err
+}
+
+func TestDirOnPath(t *testing.T) {
+	pathList := strings.Join([]string{"/usr/bin", "/home/user/go/bin/"}, ":")
+	if !dirOnPath("/home/user/go/bin", pathList) {
+		t.Fatal("expected go bin dir to be found on PATH")
+	}
+	if dirOnPath("/opt/bin", pathList) {
+		t.Fatal("expected /opt/bin not to be found on PATH")
+	}
+}
+
+func TestDoctorGitHubRejectedToken(t *testing.T) {
+	var stdout bytes.Buffer
+	report := &doctorReport{out: &output.Writer{Out: &stdout}}
+	deps := doctorDependencies{limiter: func(string) rateLimiter { return &stubRateLimiter{err: github.ErrUnauthorized} }}
+
+	doctorGitHub(report, &config.Config{}, deps)
+
+	if report.failures != 1 {
+		t.Fatalf("expected 1 failure, got %d", report.failures)
+	}
+	if !strings.Contains(stdout.String(), "token was rejected") {
+		t.Fatalf("unexpected output: %s", stdout.String())
+	}
+}
+
+func TestDoctorGitHubExhaustedRateLimit(t *testing.T) {
+	report := &doctorReport{out: &output.Writer{Out: &bytes.Buffer{}}}
+	deps := doctorDependencies{limiter: func(string) rateLimiter {
+		return &stubRateLimiter{limit: github.RateLimit{Limit: 60, Remaining: 0, Reset: time.Now()}}
+	}}
+
+	doctorGitHub(report, &config.Config{}, deps)
+
+	if report.failures != 0 || report.warnings != 1 {
+		t.Fatalf("expected 0 failures and 1 warning, got %d and %d", report.failures, report.warnings)
+	}
+}
+
+func TestDoctorGitHubHostUsesTokenSources(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "token")
+	if err := os.WriteFile(file, []byte("ghe-file-token\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	var stdout bytes.Buffer
+	report := &doctorReport{out: &output.Writer{Out: &stdout}}
+	var gotToken string
+	deps := doctorDependencies{hostLimiter: func(h config.GitHubHost, token string) rateLimiter {
+		gotToken = token
+		return &stubRateLimiter{limit: github.RateLimit{Limit: 5000, Remaining: 5000, Reset: time.Now()}}
+	}}
+	h := config.GitHubHost{Host: "github.corp.example", Token: &config.TokenSettings{Sources: []string{"file"}, File: file}}
+
+	doctorGitHubHost(report, h, deps)
+
+	if gotToken != "ghe-file-token" || report.failures != 0 || report.warnings != 0 {
+		t.Fatalf("expected the file token to be used, got %q with %d failures and %d warnings", gotToken, report.failures, report.warnings)
+	}
+	if !strings.Contains(stdout.String(), "Using the github.corp.example token from file "+file) || strings.Contains(stdout.String(), "ghe-file-token") {
+		t.Fatalf("unexpected output: %s", stdout.String())
+	}
+}
+
+func TestDoctorAppsReportsMissingBinaries(t *testing.T) {
+	var stdout bytes.Buffer
+	report := &doctorReport{out: &output.Writer{Out: &stdout}}
+	deps := doctorDependencies{runner: &stubRunner{
+		infos: map[string]*goversion.Info{"present": {Path: "github.com/example/present", Version: "v1.0.0"}},
+		errs:  map[string]error{"missing": errors.New("binary not found: missing")},
+	}}
+	cfg := &config.Config{Apps: []config.App{{Name: "present"}, {Name: "missing"}}}
+
+	doctorApps(report, cfg, deps)
+
+	if report.failures != 1 {
+		t.Fatalf("expected 1 failure, got %d", report.failures)
+	}
+	if !strings.Contains(stdout.String(), "present: github.com/example/present@v1.0.0") {
+		t.Fatalf("unexpected output: %s", stdout.String())
+	}
+}
+
+func TestDoctorNetworkUsesCABundle(t *testing.T) {
+	server := httptest.NewTLSServer(nil)
+	defer server.Close()
+
+	var stdout bytes.Buffer
+	report := &doctorReport{out: &output.Writer{Out: &stdout}}
+	if !doctorNetwork(report, netconfig.Options{}, []string{server.URL}) {
+		t.Fatal("expected settings to load")
+	}
+	if report.warnings != 1 || !strings.Contains(stdout.String(), "set ca_bundle") {
+		t.Fatalf("expected an unknown authority warning, got %d warnings:\n%s", report.warnings, stdout.String())
+	}
+
+	bundle := filepath.Join(t.TempDir(), "ca.pem")
+	data := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: server.Certificate().Raw})
+	if err := os.WriteFile(bundle, data, 0600); err != nil {
+		t.Fatalf("failed to write CA bundle: %v", err)
+	}
+	stdout.Reset()
+	report = &doctorReport{out: &output.Writer{Out: &stdout}}
+	if !doctorNetwork(report, netconfig.Options{CABundle: bundle}, []string{server.URL}) {
+		t.Fatal("expected settings to load")
+	}
+	if report.failures != 0 || report.warnings != 0 || !strings.Contains(stdout.String(), "Connected to "+server.URL) {
+		t.Fatalf("expected the connection to succeed:\n%s", stdout.String())
+	}
+}
+
+func TestDoctorNetworkRejectsMissingCABundle(t *testing.T) {
+	var stdout bytes.Buffer
+	report := &doctorReport{out: &output.Writer{Out: &stdout}}
+	opts := netconfig.Options{CABundle: filepath.Join(t.TempDir(), "missing.pem")}
+	if doctorNetwork(report, opts, nil) || report.failures != 1 {
+		t.Fatalf("expected a failure for a missing CA bundle:\n%s", stdout.String())
+	}
+}
@@ -0,0 +1,370 @@
+package cmd
+
+import (
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/UnitVectorY-Labs/gogitup/internal/cache"
+	"github.com/UnitVectorY-Labs/gogitup/internal/config"
+	"github.com/UnitVectorY-Labs/gogitup/internal/github"
+	"github.com/UnitVectorY-Labs/gogitup/internal/gomodule"
+	"github.com/UnitVectorY-Labs/gogitup/internal/goversion"
+	"github.com/UnitVectorY-Labs/gogitup/internal/netconfig"
+	"github.com/UnitVectorY-Labs/gogitup/internal/output"
+	"github.com/UnitVectorY-Labs/gogitup/internal/release"
+	"github.com/UnitVectorY-Labs/gogitup/internal/retry"
+	"github.com/UnitVectorY-Labs/gogitup/internal/vanity"
+)
+
+// newGitHubClient creates the github.com API client. When c is not nil, it
+// sends conditional requests using the responses stored in the cache.
+func newGitHubClient(cfg *config.Config, c *cache.Cache) *github.DefaultClient {
+	return newGitHubClientWithToken(cfg, githubToken(cfg), c)
+}
+
+// newGitHubClientWithToken is newGitHubClient with a token that has already
+// been looked up.
+func newGitHubClientWithToken(cfg *config.Config, token string, c *cache.Cache) *github.DefaultClient {
+	client := github.NewDefaultClient(token)
+	client.SetHTTPClient(newHTTPClient(cfg))
+	if c != nil {
+		client.SetResponseCache(responseCache{c})
+	}
+	return client
+}
+
+// retryPolicy returns the timeout of each network request and the retry
+// policy set by the http_timeout and retries settings.
+func retryPolicy(cfg *config.Config) (time.Duration, retry.Policy) {
+	// Invalid values are rejected when the config is loaded.
+	timeout, err := config.HTTPTimeout(cfg, retry.DefaultTimeout)
+	if err != nil {
+		timeout = retry.DefaultTimeout
+	}
+	return timeout, retry.NewPolicy(config.Retries(cfg, retry.DefaultRetries))
+}
+
+// networkOptions returns the configured proxy and TLS settings.
+func networkOptions(cfg *config.Config) netconfig.Options {
+	return netconfig.Options{
+		Proxy:      cfg.HTTPSProxy,
+		CABundle:   cfg.CABundle,
+		ClientCert: cfg.ClientCert,
+		ClientKey:  cfg.ClientKey,
+	}
+}
+
+// newHTTPClient returns an HTTP client that applies the configured proxy, TLS
+// settings, timeout, and retry policy. It exits when the CA bundle or client
+// certificate cannot be loaded.
+func newHTTPClient(cfg *config.Config) *http.Client {
+	transport, err := networkOptions(cfg).Transport()
+	if err != nil {
+		output.Error(fmt.Sprintf("Failed to configure network access: %v", err))
+		os.Exit(1)
+	}
+	timeout, policy := retryPolicy(cfg)
+	return retry.NewHTTPClient(timeout, policy, transport)
+}
+
+// caBundlePath is where the configured CA bundle is combined with the system
+// roots for the go command.
+func caBundlePath() string {
+	return filepath.Join(filepath.Dir(cacheFilePath()), "ca-bundle.pem")
+}
+
+// goEnv returns the environment entries that pass the configured proxy and
+// TLS settings on to go commands. It exits when the CA bundle cannot be read.
+func goEnv(cfg *config.Config) []string {
+	env, err := networkOptions(cfg).Env(caBundlePath())
+	if err != nil {
+		output.Error(fmt.Sprintf("Failed to configure network access: %v", err))
+		os.Exit(1)
+	}
+	return env
+}
+
+// newModuleResolver returns the resolver for the configured GOPROXY: the
+// module proxy protocol client, falling back to go list for modules that must
+// be fetched directly. Both use the network settings, timeout, and retry
+// policy.
+func newModuleResolver(cfg *config.Config) gomodule.Resolver {
+	goList := gomodule.NewDefaultResolverWithGOPROXY(cfg.GOPROXY)
+	goList.SetRetryPolicy(retryPolicy(cfg))
+	goList.SetExtraEnv(goEnv(cfg))
+	return &gomodule.FallbackResolver{
+		Primary:  gomodule.NewProxyResolver(cfg.GOPROXY, newHTTPClient(cfg)),
+		Fallback: goList,
+	}
+}
+
+// newModuleVerifier returns the verifier that checks module versions against
+// the checksum database set by GOSUMDB, reading them through the configured
+// GOPROXY. The database's latest tree head is kept in the cache directory.
+func newModuleVerifier(cfg *config.Config) gomodule.Verifier {
+	sumdbDir := filepath.Join(filepath.Dir(cacheFilePath()), "sumdb")
+	return gomodule.NewSumDBVerifier(gomodule.NewProxyResolver(cfg.GOPROXY, newHTTPClient(cfg)), sumdbDir)
+}
+
+// newVanityResolver returns the cached resolver for vanity import paths.
+func newVanityResolver(cfg *config.Config, c *cache.Cache) vanity.Resolver {
+	return vanity.NewCachedResolver(vanity.NewDefaultResolverWithClient(newHTTPClient(cfg)), c)
+}
+
+// newGitHubReleaseClient creates the github.com client used for release
+// lookups. Authenticated clients batch their lookups through the GraphQL API.
+func newGitHubReleaseClient(cfg *config.Config, c *cache.Cache) github.Client {
+	client := newGitHubClient(cfg, c)
+	if client.HasToken() {
+		return github.NewBatchClient(client)
+	}
+	return client
+}
+
+// prefetchReleases lets a batching GitHub client look up the latest releases
+// of the github.com modules among modulePaths in as few requests as possible.
+// Failures are ignored: the client then looks up each repository on its own.
+func prefetchReleases(ghClient github.Client, modulePaths []string) {
+	p, ok := ghClient.(github.Prefetcher)
+	if !ok {
+		return
+	}
+	seen := make(map[github.Repo]bool)
+	var repos []github.Repo
+	for _, path := range modulePaths {
+		owner, name, err := goversion.ParseGitHubRepo(path)
+		if err != nil {
+			continue
+		}
+		if r := (github.Repo{Owner: owner, Name: name}); !seen[r] {
+			seen[r] = true
+			repos = append(repos, r)
+		}
+	}
+	if len(repos) > 0 {
+		_ = p.Prefetch(repos)
+	}
+}
+
+// tokenConfig returns where the github.com token is looked up.
+func tokenConfig(cfg *config.Config) github.TokenConfig {
+	return tokenSettingsConfig(cfg.GitHubToken)
+}
+
+// hostTokenConfig returns where the token of a GitHub Enterprise host is
+// looked up: its token_env, then the sources of its token settings.
+func hostTokenConfig(h config.GitHubHost) github.TokenConfig {
+	tc := tokenSettingsConfig(h.Token)
+	tc.Host, tc.TokenEnv = h.Host, h.TokenEnv
+	return tc
+}
+
+func tokenSettingsConfig(t *config.TokenSettings) github.TokenConfig {
+	if t == nil {
+		return github.TokenConfig{}
+	}
+	tc := github.TokenConfig{
+		File:           t.File,
+		Command:        t.Command,
+		KeyringService: t.KeyringService,
+	}
+	if len(t.Sources) > 0 {
+		tc.Sources = t.Sources
+	}
+	return tc
+}
+
+// githubToken returns the github.com token, or an empty string when
+// github_auth is disabled or no token source has one.
+func githubToken(cfg *config.Config) string {
+	if !cfg.GitHubAuth {
+		return ""
+	}
+	return lookupToken(tokenConfig(cfg))
+}
+
+// lookupToken returns the token found in the sources of tc, warning about
+// sources that are configured but fail, such as a missing token file.
+func lookupToken(tc github.TokenConfig) string {
+	token, errs := github.LookupToken(tc)
+	for _, err := range errs {
+		output.ErrorWriter.Warn(fmt.Sprintf("Could not read the GitHub token: %v", err))
+	}
+	return token.Value
+}
+
+// newGitHubHostClient creates the API client for a GitHub Enterprise host,
+// using its own token. When c is not nil, it sends conditional requests using
+// the responses stored in the cache.
+func newGitHubHostClient(cfg *config.Config, h config.GitHubHost, c *cache.Cache) *github.DefaultClient {
+	return newGitHubHostClientWithToken(cfg, h, lookupToken(hostTokenConfig(h)), c)
+}
+
+// newGitHubHostClientWithToken is newGitHubHostClient with a token that has
+// already been looked up.
+func newGitHubHostClientWithToken(cfg *config.Config, h config.GitHubHost, token string, c *cache.Cache) *github.DefaultClient {
+	client := github.NewClientWithBaseURL(githubHostBaseURL(h), token)
+	client.SetHTTPClient(newHTTPClient(cfg))
+	if c != nil {
+		client.SetResponseCache(responseCache{c})
+	}
+	return client
+}
+
+// responseCache stores GitHub API responses in the cache file.
+type responseCache struct {
+	c *cache.Cache
+}
+
+func (r responseCache) GetResponse(url string) (github.CachedResponse, bool) {
+	resp, ok := cache.GetResponse(r.c, url)
+	return github.CachedResponse{ETag: resp.ETag, LastModified: resp.LastModified, Value: resp.Value}, ok
+}
+
+func (r responseCache) SetResponse(url string, resp github.CachedResponse) {
+	cache.SetResponse(r.c, url, cache.Response{ETag: resp.ETag, LastModified: resp.LastModified, Value: resp.Value})
+}
+
+// rateLimitWarning describes an exhausted GitHub rate limit, suggesting
+// authentication when requests are unauthenticated.
+func rateLimitWarning(cfg *config.Config, err *github.RateLimitError) string {
+	msg := err.Error()
+	if !cfg.GitHubAuth {
+		msg += "; set github_auth: true to raise the limit to 5,000 requests per hour"
+	}
+	return msg
+}
+
+func githubHostBaseURL(h config.GitHubHost) string {
+	if h.APIURL != "" {
+		return h.APIURL
+	}
+	return github.EnterpriseBaseURL(h.Host)
+}
+
+func releaseHostBaseURL(h config.ReleaseHost) string {
+	if h.APIURL != "" {
+		return h.APIURL
+	}
+	return release.DefaultBaseURL(h.Provider, h.Host)
+}
+
+// releaseToken reads the token for a release provider from tokenEnv, or from
+// the provider's default variable when tokenEnv is empty.
+func releaseToken(kind, tokenEnv string) string {
+	if tokenEnv == "" {
+		tokenEnv = release.TokenEnv(kind)
+	}
+	return os.Getenv(tokenEnv)
+}
+
+// newReleaseHosts creates the release provider for every module host other
+// than github.com: the well-known public hosts, then the GitHub Enterprise
+// and other hosts in the config, which override the defaults. GitHub
+// Enterprise clients store their responses in c.
+func newReleaseHosts(cfg *config.Config, c *cache.Cache) release.Hosts {
+	hosts := make(release.Hosts)
+	for _, d := range release.Defaults {
+		provider, _ := release.NewProvider(d.Kind, d.BaseURL, releaseToken(d.Kind, ""), newHTTPClient(cfg))
+		hosts[d.Host] = release.Host{Kind: d.Kind, Provider: provider}
+	}
+	for _, h := range cfg.GitHubHosts {
+		hosts[h.Host] = release.Host{Kind: release.KindGitHub, Provider: newGitHubHostClient(cfg, h, c)}
+	}
+	for _, h := range cfg.ReleaseHosts {
+		provider, err := release.NewProvider(h.Provider, releaseHostBaseURL(h), releaseToken(h.Provider, h.TokenEnv), newHTTPClient(cfg))
+		if err != nil {
+			// Validation rejects unknown providers when the config is loaded.
+			continue
+		}
+		hosts[h.Host] = release.Host{Kind: h.Provider, Provider: provider}
+	}
+	return hosts
+}
+
+// releaseSources are where checkForUpdate looks for a module's latest
+// release before falling back to the Go module resolver.
+type releaseSources struct {
+	ghClient github.Client
+	hosts    release.Hosts
+	// vanity resolves vanity import paths; nil disables resolution.
+	vanity vanity.Resolver
+}
+
+// releaseProviderFor returns the release provider for the host of modulePath
+// along with its kind and host, or nil when no provider serves the module and
+// the Go module resolver must be used instead.
+func releaseProviderFor(modulePath string, ghClient github.Client, releases release.Hosts) (release.Provider, string, string) {
+	if goversion.IsGitHubRepo(modulePath) {
+		return ghClient, release.KindGitHub, "github.com"
+	}
+	host := goversion.ModuleHost(modulePath)
+	if h, ok := releases[host]; ok {
+		return h.Provider, h.Kind, host
+	}
+	return nil, "", ""
+}
+
+// networkReachable reports whether github.com, any configured release host,
+// or a module proxy of the effective GOPROXY can be reached, so that machines
+// with access only to an internal server are not treated as offline.
+func networkReachable(cfg *config.Config) bool {
+	// Invalid proxy URLs are rejected when the config is loaded.
+	proxy, _ := networkOptions(cfg).ProxyURL()
+	if github.Reachable(github.DefaultBaseURL, proxy, networkProbeTimeout) {
+		return true
+	}
+	for _, h := range cfg.GitHubHosts {
+		if github.Reachable(githubHostBaseURL(h), proxy, networkProbeTimeout) {
+			return true
+		}
+	}
+	for _, h := range cfg.ReleaseHosts {
+		if github.Reachable(releaseHostBaseURL(h), proxy, networkProbeTimeout) {
+			return true
+		}
+	}
+	for _, u := range gomodule.NewProxyResolver(cfg.GOPROXY, nil).ProxyURLs() {
+		if github.Reachable(u, proxy, networkProbeTimeout) {
+			return true
+		}
+	}
+	return false
+}
+
+// vanityRelease looks up the latest release of a module with a vanity import
+// path, such as honnef.co/go/tools, in the repository serving it. It reports
+// false when the path does not resolve to a host with a release provider or
+// the latest release is not a version of the module.
+func vanityRelease(modulePath string, sources releaseSources) (string, bool) {
+	if sources.vanity == nil {
+		return "", false
+	}
+	repo, err := sources.vanity.Resolve(modulePath)
+	if err != nil {
+		return "", false
+	}
+
+	for _, repoPath := range []string{repo.RepoPath, repo.SourcePath} {
+		if repoPath == "" {
+			continue
+		}
+		provider, kind, host := releaseProviderFor(repoPath, sources.ghClient, sources.hosts)
+		if provider == nil {
+			continue
+		}
+		owner, name, err := release.ParseRepoPath(kind, repoPath, host)
+		if err != nil {
+			continue
+		}
+		tag, err := provider.GetLatestRelease(owner, name)
+		if err != nil {
+			return "", false
+		}
+		return vanity.ModuleVersion(modulePath, repo.Prefix, tag)
+	}
+	return "", false
+}
@@ -0,0 +1,101 @@
+package cmd
+
+import (
+	"testing"
+
+	"github.com/UnitVectorY-Labs/gogitup/internal/config"
+	"github.com/UnitVectorY-Labs/gogitup/internal/gomodule"
+	"github.com/UnitVectorY-Labs/gogitup/internal/release"
+	"github.com/UnitVectorY-Labs/gogitup/internal/vanity"
+)
+
+func TestCheckForUpdateUsesEnterpriseHostClient(t *testing.T) {
+	publicClient := &stubGitHubClient{releases: map[string]string{"acme/tool": "v1.0.0"}}
+	enterpriseClient := &stubGitHubClient{releases: map[string]string{"platform/tool": "v2.0.0"}}
+	resolver := &stubModuleResolver{results: map[string]gomodule.Result{
+		"gitlab.com/acme/tool@v1.0.0": {LatestVersion: "v1.0.0"},
+	}}
+	hosts := release.Hosts{"github.corp.example": {Kind: release.KindGitHub, Provider: enterpriseClient}}
+
+	result, err := checkForUpdate("github.corp.example/platform/tool", "v1.5.0", releaseSources{ghClient: publicClient, hosts: hosts}, resolver)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if result.latestVersion != "v2.0.0" || !result.updateAvailable {
+		t.Fatalf("expected v2.0.0 from the enterprise host, got %+v", result)
+	}
+
+	result, err = checkForUpdate("github.com/acme/tool", "v1.0.0", releaseSources{ghClient: publicClient, hosts: hosts}, resolver)
+	if err != nil || result.latestVersion != "v1.0.0" {
+		t.Fatalf("expected v1.0.0 from github.com, got %+v (err=%v)", result, err)
+	}
+
+	if _, err := checkForUpdate("gitlab.com/acme/tool", "v1.0.0", releaseSources{ghClient: publicClient, hosts: hosts}, resolver); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(resolver.calls) != 1 {
+		t.Fatalf("expected modules on other hosts to use the module resolver, got %d calls", len(resolver.calls))
+	}
+}
+
+func TestNewReleaseHostsAppliesConfigOverrides(t *testing.T) {
+	cfg := &config.Config{
+		GitHubHosts:  []config.GitHubHost{{Host: "github.corp.example"}},
+		ReleaseHosts: []config.ReleaseHost{{Host: "gitlab.com", Provider: release.KindGitea}, {Host: "git.corp.example", Provider: release.KindGitLab}},
+	}
+
+	hosts := newReleaseHosts(cfg, nil)
+
+	want := map[string]string{
+		"codeberg.org":        release.KindForgejo,
+		"bitbucket.org":       release.KindBitbucket,
+		"github.corp.example": release.KindGitHub,
+		"gitlab.com":          release.KindGitea,
+		"git.corp.example":    release.KindGitLab,
+	}
+	for host, kind := range want {
+		if hosts[host].Kind != kind || hosts[host].Provider == nil {
+			t.Errorf("expected %s provider for %s, got %+v", kind, host, hosts[host])
+		}
+	}
+}
+
+type stubVanityResolver struct {
+	repos map[string]vanity.Repo
+}
+
+func (s *stubVanityResolver) Resolve(importPath string) (vanity.Repo, error) {
+	repo, ok := s.repos[importPath]
+	if !ok {
+		return vanity.Repo{}, vanity.ErrNoMeta
+	}
+	return repo, nil
+}
+
+func TestCheckForUpdateResolvesVanityPaths(t *testing.T) {
+	ghClient := &stubGitHubClient{releases: map[string]string{
+		"dominikh/go-tools": "2024.1.1",
+		"golang/tools":      "gopls/v0.16.2",
+	}}
+	sources := releaseSources{ghClient: ghClient, vanity: &stubVanityResolver{repos: map[string]vanity.Repo{
+		"honnef.co/go/tools":       {Prefix: "honnef.co/go/tools", RepoPath: "github.com/dominikh/go-tools"},
+		"golang.org/x/tools/gopls": {Prefix: "golang.org/x/tools", RepoPath: "go.googlesource.com/tools", SourcePath: "github.com/golang/tools"},
+	}}}
+	resolver := &stubModuleResolver{results: map[string]gomodule.Result{
+		"honnef.co/go/tools@v0.5.0": {LatestVersion: "v0.5.1", UpdateAvailable: true},
+	}}
+
+	result, err := checkForUpdate("golang.org/x/tools/gopls", "v0.16.1", sources, resolver)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if result.latestVersion != "v0.16.2" || !result.updateAvailable {
+		t.Fatalf("expected v0.16.2 from the go-source repository, got %+v", result)
+	}
+
+	// Release tags that are not module versions fall back to the resolver.
+	result, err = checkForUpdate("honnef.co/go/tools", "v0.5.0", sources, resolver)
+	if err != nil || result.latestVersion != "v0.5.1" || len(resolver.calls) != 1 {
+		t.Fatalf("expected resolver result v0.5.1, got %+v (err=%v, calls=%d)", result, err, len(resolver.calls))
+	}
+}
@@ -1,7 +1,10 @@
 package cmd
 
 import (
+	"errors"
+	"flag"
 	"fmt"
+	"io"
 	"os"
 	"strings"
 
@@ -18,37 +21,78 @@ type installDependencies struct {
 	runner    goversion.Runner
 	out       *output.Writer
 	errOut    *output.Writer
+	// platform and binDir are set for cross-compiled installs.
+	platform installer.Target
+	binDir   string
+}
+
+type installOptions struct {
+	Target        string
+	CreateProfile bool
+	targetOptions
+}
+
+const installUsage = "Usage: gogitup install <owner/repo|package-path> [--os <goos>] [--arch <goarch>] [--bin-dir <dir>] [--create-profile]"
+
+func parseInstallOptions(args []string, stderr io.Writer) (installOptions, error) {
+	fs := flag.NewFlagSet("install", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+
+	var opts installOptions
+	addTargetFlags(fs, &opts.targetOptions)
+	addCreateProfileFlag(fs, &opts.CreateProfile)
+	positional, err := parseInterspersed(fs, args)
+	if err != nil {
+		return installOptions{}, err
+	}
+	if len(positional) != 1 {
+		return installOptions{}, errors.New(installUsage)
+	}
+	opts.Target = positional[0]
+	return opts, nil
 }
 
 func runInstall(args []string) {
-	if len(args) < 1 {
-		output.Error("Usage: gogitup install <owner/repo|package-path>")
+	opts, err := parseInstallOptions(args, output.ErrorWriter.Out)
+	if err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return
+		}
+		output.Error(err.Error())
 		os.Exit(1)
 	}
 
-	target, err := parseInstallTarget(args[0])
+	target, err := parseInstallTarget(opts.Target)
 	if err != nil {
 		output.Error(err.Error())
 		os.Exit(1)
 	}
 
-	cfgPath := config.DefaultPath()
-	cfg, err := config.Load(cfgPath)
+	defer lockState()()
+
+	cfgPath := configPath()
+	cfg, err := loadProfileToEdit(cfgPath, opts.CreateProfile)
 	if err != nil {
 		output.Error(fmt.Sprintf("Failed to load config: %v", err))
 		os.Exit(1)
 	}
 
-	ghClient := github.NewDefaultClient(github.ResolveToken(cfg.GitHubAuth))
-	inst := installer.NewDefaultInstallerWithOptions(cfg.GOPROXY, cfg.CGOEnabled)
-	runner := &goversion.DefaultRunner{}
+	buildTarget, err := resolveTarget(opts.targetOptions, cfg, config.App{})
+	if err != nil {
+		output.Error(fmt.Sprintf("Failed to resolve target platform: %v", err))
+		os.Exit(1)
+	}
+
+	ghClient := newGitHubClient(cfg, nil)
 
 	deps := installDependencies{
 		ghClient:  ghClient,
-		installer: inst,
-		runner:    runner,
+		installer: newTargetInstaller(cfg, buildTarget),
+		runner:    newTargetRunner(buildTarget),
 		out:       output.DefaultWriter,
 		errOut:    output.ErrorWriter,
+		platform:  buildTarget.platform,
+		binDir:    buildTarget.binDir,
 	}
 
 	binaryName, err := runInstallTarget(target, deps)
@@ -57,7 +101,8 @@ func runInstall(args []string) {
 		os.Exit(1)
 	}
 
-	if err := config.AddAppWithInstallPath(cfg, binaryName, target.installPath()); err != nil {
+	app := registeredApp(binaryName, target.installPath(), opts.targetOptions, buildTarget)
+	if err := config.AddAppEntry(cfg, app); err != nil {
 		output.Warn(err.Error())
 		return
 	}
@@ -67,7 +112,19 @@ func runInstall(args []string) {
 		os.Exit(1)
 	}
 
-	output.Success(fmt.Sprintf("Added '%s' to tracking", binaryName))
+	output.Success(fmt.Sprintf("Added '%s' to tracking", buildTarget.key(binaryName)))
+}
+
+// registeredApp returns the config entry for an installed binary. For a
+// cross-compiled binary the target flags are recorded on it, so that check and
+// upgrade inspect and rebuild the binary for the platform and directory it was
+// installed for; settings taken from the profile are left to the profile.
+func registeredApp(name, installPath string, opts targetOptions, target appTarget) config.App {
+	app := config.App{Name: name, InstallPath: installPath}
+	if !target.platform.IsZero() {
+		app.GOOS, app.GOARCH, app.CrossBinDir = opts.GOOS, opts.GOARCH, opts.BinDir
+	}
+	return app
 }
 
 type installTarget struct {
@@ -167,18 +224,25 @@ func runInstallTarget(target installTarget, deps installDependencies) (string, e
 		}
 	}
 
-	deps.out.StartProgress(fmt.Sprintf("Installing %s@%s", target.packagePath, version))
+	label := target.packagePath + "@" + version
+	if !deps.platform.IsZero() {
+		label += " for " + deps.platform.String()
+	}
+	deps.out.StartProgress("Installing " + label)
 
 	_, err := deps.installer.Install(target.packagePath, version)
 	if err != nil {
 		return "", fmt.Errorf("installation failed: %w", err)
 	}
 
-	deps.out.Success(fmt.Sprintf("Installed %s@%s", target.packagePath, version))
+	deps.out.Success("Installed " + label)
 
 	parts := strings.Split(target.packagePath, "/")
 	binaryName := parts[len(parts)-1]
 	if _, err := deps.runner.GetInfo(binaryName); err != nil {
+		if !deps.platform.IsZero() {
+			return "", fmt.Errorf("binary %q not found in %s after install", binaryName, deps.binDir)
+		}
 		return "", fmt.Errorf("binary %q not found on PATH after install; use 'gogitup add <name>' to track it manually", binaryName)
 	}
 
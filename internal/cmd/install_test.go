@@ -6,7 +6,9 @@ import (
 	"strings"
 	"testing"
 
+	"github.com/UnitVectorY-Labs/gogitup/internal/config"
 	"github.com/UnitVectorY-Labs/gogitup/internal/goversion"
+	"github.com/UnitVectorY-Labs/gogitup/internal/installer"
 	"github.com/UnitVectorY-Labs/gogitup/internal/output"
 )
 
@@ -326,3 +328,79 @@ func TestRunInstallAppBinaryNotOnPath(t *testing.T) {
 		t.Fatalf("expected 'not found on PATH' in error, got %q", err.Error())
 	}
 }
+
+func TestParseInstallOptions(t *testing.T) {
+	var stderr bytes.Buffer
+	opts, err := parseInstallOptions([]string{"owner/repo", "--os", "linux", "--arch", "arm64"}, &stderr)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if opts.Target != "owner/repo" || opts.GOOS != "linux" || opts.GOARCH != "arm64" {
+		t.Fatalf("unexpected options: %+v", opts)
+	}
+
+	if _, err := parseInstallOptions([]string{"--os", "linux"}, &stderr); err == nil {
+		t.Fatal("expected an error when the install target is missing")
+	}
+	if _, err := parseInstallOptions([]string{"a/b", "c/d"}, &stderr); err == nil {
+		t.Fatal("expected an error for multiple install targets")
+	}
+}
+
+func TestRunInstallTargetCrossCompiledBinaryMissing(t *testing.T) {
+	_, err := runInstallTarget(installTarget{packagePath: "example.com/tool"}, installDependencies{
+		ghClient:  &stubGitHubClient{},
+		installer: &stubInstaller{},
+		runner:    &stubRunner{},
+		out:       &output.Writer{Out: &bytes.Buffer{}},
+		errOut:    &output.Writer{Out: &bytes.Buffer{}},
+		platform:  installer.Target{GOOS: "linux", GOARCH: "arm64"},
+		binDir:    "/out/linux_arm64",
+	})
+	if err == nil || !strings.Contains(err.Error(), "/out/linux_arm64") {
+		t.Fatalf("expected error mentioning the output directory, got %v", err)
+	}
+}
+
+func TestRegisteredAppRecordsCrossCompiledTarget(t *testing.T) {
+	opts := targetOptions{GOOS: "linux", GOARCH: "arm64", BinDir: "/out"}
+
+	app := registeredApp("tool", "example.com/tool/cmd/tool", opts, appTarget{platform: installer.Target{GOOS: "linux", GOARCH: "arm64"}, binDir: "/out"})
+	want := config.App{Name: "tool", InstallPath: "example.com/tool/cmd/tool", GOOS: "linux", GOARCH: "arm64", CrossBinDir: "/out"}
+	if app != want {
+		t.Fatalf("registeredApp() = %+v, want %+v", app, want)
+	}
+
+	if app := registeredApp("tool", "", targetOptions{BinDir: "/out"}, appTarget{}); app != (config.App{Name: "tool"}) {
+		t.Fatalf("registeredApp() for a native install = %+v, want no target", app)
+	}
+}
+
+func TestResolveTargetPrefersFlagsThenApp(t *testing.T) {
+	cfg := &config.Config{GOOS: "windows", GOARCH: "amd64", CrossBinDir: "/profile"}
+	app := config.App{Name: "tool", GOOS: "linux", GOARCH: "arm64", CrossBinDir: "/app"}
+
+	target, err := resolveTarget(targetOptions{}, cfg, app)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if target.platform.String() != "linux/arm64" || target.binDir != "/app" {
+		t.Fatalf("resolveTarget() = %+v, want the app's target", target)
+	}
+
+	target, err = resolveTarget(targetOptions{GOARCH: "riscv64", BinDir: "/flag"}, cfg, app)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if target.platform.String() != "linux/riscv64" || target.binDir != "/flag" {
+		t.Fatalf("resolveTarget() = %+v, want the flags to override the app", target)
+	}
+
+	target, err = resolveTarget(targetOptions{}, cfg, config.App{Name: "other"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if target.platform.String() != "windows/amd64" || target.binDir != "/profile" {
+		t.Fatalf("resolveTarget() = %+v, want the profile's target", target)
+	}
+}
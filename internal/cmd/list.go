@@ -22,8 +22,8 @@ func runList(args []string) {
 	jsonFlag := fs.Bool("json", false, "Output as JSON")
 	_ = fs.Parse(args)
 
-	cfgPath := config.DefaultPath()
-	cfg, err := config.Load(cfgPath)
+	cfgPath := configPath()
+	cfg, err := config.LoadProfile(cfgPath, profileName())
 	if err != nil {
 		output.Error(fmt.Sprintf("Failed to load config: %v", err))
 		os.Exit(1)
@@ -0,0 +1,73 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/UnitVectorY-Labs/gogitup/internal/cache"
+	"github.com/UnitVectorY-Labs/gogitup/internal/config"
+	"github.com/UnitVectorY-Labs/gogitup/internal/notify"
+	"github.com/UnitVectorY-Labs/gogitup/internal/output"
+)
+
+// newNotifiers creates the notifiers configured for check --notify.
+func newNotifiers(cfg *config.Config) ([]notify.Notifier, error) {
+	notifiers := make([]notify.Notifier, 0, len(cfg.Notifiers))
+	client := newHTTPClient(cfg)
+	for i, n := range cfg.Notifiers {
+		url := n.URL
+		if n.URLEnv != "" {
+			url = os.Getenv(n.URLEnv)
+			if url == "" {
+				return nil, fmt.Errorf("notifiers[%d]: environment variable %s is not set", i, n.URLEnv)
+			}
+		}
+		notifier, err := notify.New(notify.Options{Kind: n.Type, URL: url, Body: n.Body, Headers: n.Headers}, client)
+		if err != nil {
+			return nil, fmt.Errorf("notifiers[%d]: %w", i, err)
+		}
+		notifiers = append(notifiers, notifier)
+	}
+	return notifiers, nil
+}
+
+// notifyUpdates sends the updates among entries that have not been notified
+// yet to every notifier. An update counts as notified, and is not sent again,
+// once at least one notifier delivered it; the version is recorded in the
+// cache. It returns an error when no notifier could deliver the updates.
+func notifyUpdates(c *cache.Cache, entries []checkEntry, notifiers []notify.Notifier, out *output.Writer) error {
+	var updates []notify.Update
+	for _, e := range entries {
+		if !e.UpdateAvailable {
+			continue
+		}
+		cached, ok := c.Entries[e.Name]
+		if !ok || cached.NotifiedVersion == e.LatestVersion {
+			continue
+		}
+		updates = append(updates, notify.Update{Name: e.Name, InstalledVersion: e.InstalledVersion, LatestVersion: e.LatestVersion})
+	}
+	if len(updates) == 0 {
+		return nil
+	}
+
+	delivered := false
+	for i, n := range notifiers {
+		if err := n.Notify(updates); err != nil {
+			out.Warn(fmt.Sprintf("Notifier %d failed: %v", i+1, err))
+			continue
+		}
+		delivered = true
+	}
+	if !delivered {
+		return errors.New("no notifier could deliver the notification")
+	}
+
+	for _, u := range updates {
+		entry := c.Entries[u.Name]
+		entry.NotifiedVersion = u.LatestVersion
+		c.Entries[u.Name] = entry
+	}
+	return nil
+}
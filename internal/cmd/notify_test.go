@@ -0,0 +1,97 @@
+package cmd
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/UnitVectorY-Labs/gogitup/internal/cache"
+	"github.com/UnitVectorY-Labs/gogitup/internal/config"
+	"github.com/UnitVectorY-Labs/gogitup/internal/notify"
+	"github.com/UnitVectorY-Labs/gogitup/internal/output"
+)
+
+type stubNotifier struct {
+	err   error
+	calls [][]notify.Update
+}
+
+func (s *stubNotifier) Notify(updates []notify.Update) error {
+	s.calls = append(s.calls, updates)
+	return s.err
+}
+
+func TestNotifyUpdatesSendsEachUpdateOnce(t *testing.T) {
+	c := &cache.Cache{Entries: map[string]cache.Entry{
+		"tool":    {InstalledVersion: "v1.0.0", LatestVersion: "v1.1.0"},
+		"seen":    {InstalledVersion: "v2.0.0", LatestVersion: "v2.1.0", NotifiedVersion: "v2.1.0"},
+		"current": {InstalledVersion: "v3.0.0", LatestVersion: "v3.0.0"},
+	}}
+	entries := []checkEntry{
+		{Name: "tool", InstalledVersion: "v1.0.0", LatestVersion: "v1.1.0", UpdateAvailable: true},
+		{Name: "seen", InstalledVersion: "v2.0.0", LatestVersion: "v2.1.0", UpdateAvailable: true},
+		{Name: "current", InstalledVersion: "v3.0.0", LatestVersion: "v3.0.0"},
+	}
+	failing := &stubNotifier{err: errors.New("boom")}
+	working := &stubNotifier{}
+	var stderr bytes.Buffer
+	out := &output.Writer{Out: &stderr}
+
+	if err := notifyUpdates(c, entries, []notify.Notifier{failing, working}, out); err != nil {
+		t.Fatalf("notifyUpdates() error: %v", err)
+	}
+	if len(working.calls) != 1 || len(working.calls[0]) != 1 || working.calls[0][0].Name != "tool" {
+		t.Fatalf("expected only the new update for tool, got %+v", working.calls)
+	}
+	if !strings.Contains(stderr.String(), "Notifier 1 failed: boom") {
+		t.Errorf("expected a warning for the failing notifier, got %q", stderr.String())
+	}
+	if got := c.Entries["tool"].NotifiedVersion; got != "v1.1.0" {
+		t.Fatalf("NotifiedVersion = %q, want v1.1.0", got)
+	}
+
+	// A second check finds nothing new to send.
+	if err := notifyUpdates(c, entries, []notify.Notifier{working}, out); err != nil {
+		t.Fatalf("notifyUpdates() error: %v", err)
+	}
+	if len(working.calls) != 1 {
+		t.Fatalf("expected no repeated notification, got %+v", working.calls)
+	}
+}
+
+func TestNotifyUpdatesRetriesWhenUndelivered(t *testing.T) {
+	c := &cache.Cache{Entries: map[string]cache.Entry{
+		"tool": {InstalledVersion: "v1.0.0", LatestVersion: "v1.1.0"},
+	}}
+	entries := []checkEntry{{Name: "tool", InstalledVersion: "v1.0.0", LatestVersion: "v1.1.0", UpdateAvailable: true}}
+	failing := &stubNotifier{err: errors.New("boom")}
+
+	if err := notifyUpdates(c, entries, []notify.Notifier{failing}, &output.Writer{Out: &bytes.Buffer{}}); err == nil {
+		t.Fatal("expected an error when no notifier delivered")
+	}
+	if got := c.Entries["tool"].NotifiedVersion; got != "" {
+		t.Fatalf("expected the update to stay unnotified, got %q", got)
+	}
+}
+
+func TestNewNotifiersReadsURLFromEnvironment(t *testing.T) {
+	cfg := &config.Config{Notifiers: []config.Notifier{{Type: notify.KindSlack, URLEnv: "GOGITUP_TEST_SLACK_URL"}}}
+	t.Setenv("GOGITUP_TEST_SLACK_URL", "")
+	if _, err := newNotifiers(cfg); err == nil || !strings.Contains(err.Error(), "GOGITUP_TEST_SLACK_URL is not set") {
+		t.Fatalf("newNotifiers() error = %v, want unset variable", err)
+	}
+
+	t.Setenv("GOGITUP_TEST_SLACK_URL", "https://hooks.slack.com/services/T/B/X")
+	notifiers, err := newNotifiers(cfg)
+	if err != nil || len(notifiers) != 1 {
+		t.Fatalf("newNotifiers() = %v, %v", notifiers, err)
+	}
+}
+
+func TestParseCheckOptionsRejectsNotifyWithOffline(t *testing.T) {
+	var stderr bytes.Buffer
+	if _, err := parseCheckOptions([]string{"--notify", "--offline"}, &stderr); err == nil {
+		t.Fatal("expected an error for --notify with --offline")
+	}
+}
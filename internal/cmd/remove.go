@@ -4,7 +4,6 @@ import (
 	"errors"
 	"fmt"
 	"os"
-	"os/exec"
 
 	"github.com/UnitVectorY-Labs/gogitup/internal/cache"
 	"github.com/UnitVectorY-Labs/gogitup/internal/config"
@@ -18,8 +17,12 @@ func runRemove(args []string) {
 		os.Exit(1)
 	}
 
-	cfgPath := config.DefaultPath()
-	cfg, err := config.Load(cfgPath)
+	defer lockState()()
+
+	// remove is how a broken config is repaired, so problems other than
+	// unparseable YAML do not stop it.
+	cfgPath := configPath()
+	cfg, _, err := config.LoadProfileLenient(cfgPath, profileName())
 	if err != nil {
 		output.Error(fmt.Sprintf("Failed to load config: %v", err))
 		os.Exit(1)
@@ -31,24 +34,32 @@ func runRemove(args []string) {
 	}
 
 	if opts.deleteBinary {
-		if err := deleteInstalledBinary(opts.name); err != nil {
+		if err := deleteInstalledBinary(cfg, opts.name); err != nil {
 			output.Error(fmt.Sprintf("Failed to delete binary %q: %v", opts.name, err))
 			os.Exit(1)
 		}
 	}
 
-	if err := config.RemoveApp(cfg, opts.name); err != nil {
+	// The file is edited in place, so that settings the lenient load could
+	// not read are kept.
+	doc, err := config.LoadDocument(cfgPath)
+	if err != nil {
+		output.Error(fmt.Sprintf("Failed to load config: %v", err))
+		os.Exit(1)
+	}
+	if _, err := config.RemoveAppEntry(doc, profileName(), opts.name); err != nil {
 		output.Error(err.Error())
 		os.Exit(1)
 	}
 
-	if err := config.Save(cfgPath, cfg); err != nil {
+	if err := config.SaveDocument(cfgPath, doc); err != nil {
 		output.Error(fmt.Sprintf("Failed to save config: %v", err))
 		os.Exit(1)
 	}
+	warnConfigProblems(cfgPath, config.Problems(doc))
 
-	cachePath := cache.DefaultPath()
-	c, err := cache.Load(cachePath)
+	cachePath := cacheFilePath()
+	c, err := loadCache(cachePath)
 	if err == nil {
 		cache.Remove(c, opts.name)
 		_ = cache.Save(cachePath, c)
@@ -89,11 +100,23 @@ func parseRemoveArgs(args []string) (removeOptions, error) {
 	return opts, nil
 }
 
-// deleteInstalledBinary deletes the executable resolved from PATH for name.
-func deleteInstalledBinary(name string) error {
-	path, err := exec.LookPath(name)
+// deleteInstalledBinary deletes the executable of the named app: the one
+// resolved from PATH, or for a cross-compiled app the one in its bin dir.
+func deleteInstalledBinary(cfg *config.Config, name string) error {
+	var app config.App
+	for _, a := range cfg.Apps {
+		if a.Name == name {
+			app = a
+			break
+		}
+	}
+	target, err := resolveTarget(targetOptions{}, cfg, app)
 	if err != nil {
-		return fmt.Errorf("binary not found on PATH: %s", name)
+		return err
+	}
+	path, err := binaryPath(name, target.platform, target.binDir)
+	if err != nil {
+		return err
 	}
 
 	info, err := os.Stat(path)
@@ -5,6 +5,8 @@ import (
 	"os"
 	"path/filepath"
 	"testing"
+
+	"github.com/UnitVectorY-Labs/gogitup/internal/config"
 )
 
 func TestParseRemoveArgs(t *testing.T) {
@@ -50,7 +52,8 @@ func TestDeleteInstalledBinary(t *testing.T) {
 	}
 	t.Setenv("PATH", dir)
 
-	if err := deleteInstalledBinary("tool"); err != nil {
+	cfg := &config.Config{Apps: []config.App{{Name: "tool"}}}
+	if err := deleteInstalledBinary(cfg, "tool"); err != nil {
 		t.Fatalf("deleteInstalledBinary() error = %v", err)
 	}
 	if _, err := os.Stat(binaryPath); !errors.Is(err, os.ErrNotExist) {
@@ -60,7 +63,29 @@ func TestDeleteInstalledBinary(t *testing.T) {
 
 func TestDeleteInstalledBinaryNotFound(t *testing.T) {
 	t.Setenv("PATH", t.TempDir())
-	if err := deleteInstalledBinary("missing-tool"); err == nil {
+	if err := deleteInstalledBinary(&config.Config{}, "missing-tool"); err == nil {
 		t.Fatal("expected an error")
 	}
 }
+
+func TestDeleteInstalledBinaryCrossCompiled(t *testing.T) {
+	binDir := t.TempDir()
+	native := filepath.Join(t.TempDir(), "tool")
+	for _, path := range []string{native, filepath.Join(binDir, "tool.exe")} {
+		if err := os.WriteFile(path, []byte("binary"), 0700); err != nil {
+			t.Fatalf("write test binary: %v", err)
+		}
+	}
+	t.Setenv("PATH", filepath.Dir(native))
+
+	cfg := &config.Config{Apps: []config.App{{Name: "tool", GOOS: "windows", GOARCH: "amd64", CrossBinDir: binDir}}}
+	if err := deleteInstalledBinary(cfg, "tool"); err != nil {
+		t.Fatalf("deleteInstalledBinary() error = %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(binDir, "tool.exe")); !errors.Is(err, os.ErrNotExist) {
+		t.Fatalf("cross-compiled binary still exists or could not be checked: %v", err)
+	}
+	if _, err := os.Stat(native); err != nil {
+		t.Fatalf("expected the native binary to be kept: %v", err)
+	}
+}
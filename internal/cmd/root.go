@@ -1,37 +1,106 @@
 package cmd
 
 import (
+	"flag"
 	"fmt"
 	"os"
+	"strings"
+	"sync"
 
+	"github.com/UnitVectorY-Labs/gogitup/internal/cache"
+	"github.com/UnitVectorY-Labs/gogitup/internal/config"
 	"github.com/UnitVectorY-Labs/gogitup/internal/output"
 )
 
-// Execute is the main entry point for the CLI. It parses os.Args to determine
-// the subcommand and dispatches accordingly.
+// configPathOverride holds the value of the global --config flag.
+var configPathOverride string
+
+// profileOverride holds the value of the global --profile flag.
+var profileOverride string
+
+// envProfile is the environment variable that selects a config profile.
+const envProfile = "GOGITUP_PROFILE"
+
+// Legacy files are moved the first time a command asks for their path, so
+// commands that read neither pay nothing for the migration.
+var migrateConfigOnce, migrateCacheOnce sync.Once
+
+// configPath returns the config file path, honoring the global --config flag.
+func configPath() string {
+	if configPathOverride != "" {
+		return configPathOverride
+	}
+	migrateConfigOnce.Do(migrateLegacyConfig)
+	return config.DefaultPath()
+}
+
+// cacheFilePath returns the cache file path.
+func cacheFilePath() string {
+	migrateCacheOnce.Do(migrateLegacyCache)
+	return cache.DefaultPath()
+}
+
+// profileName returns the selected config profile from the global --profile
+// flag or $GOGITUP_PROFILE. An empty name selects the top-level config.
+func profileName() string {
+	if profileOverride != "" {
+		return profileOverride
+	}
+	return os.Getenv(envProfile)
+}
+
+// Execute is the main entry point for the CLI. It parses global flags from
+// os.Args, then determines the subcommand and dispatches accordingly.
 func Execute(version string) {
-	if len(os.Args) < 2 {
+	args, err := parseGlobalFlags(os.Args[1:])
+	if err != nil {
+		output.Error(err.Error())
+		os.Exit(1)
+	}
+
+	if len(args) < 1 {
 		printHelp()
 		os.Exit(0)
 	}
 
-	subcmd := os.Args[1]
+	subcmd := args[0]
+	args = args[1:]
 
 	switch subcmd {
 	case "--version", "-v":
 		fmt.Println("gogitup version " + version)
 	case "add":
-		runAdd(os.Args[2:])
+		runAdd(args)
 	case "install":
-		runInstall(os.Args[2:])
+		runInstall(args)
 	case "remove":
-		runRemove(os.Args[2:])
+		runRemove(args)
 	case "list":
-		runList(os.Args[2:])
+		runList(args)
 	case "check":
-		runCheck(os.Args[2:])
+		runCheck(args)
+	case "status":
+		runStatus(args)
 	case "upgrade":
-		runUpgrade(os.Args[2:])
+		runUpgrade(args)
+	case "bundle":
+		runBundle(args)
+	case "unbundle":
+		runUnbundle(args)
+	case "cache":
+		runCache(args)
+	case "doctor":
+		runDoctor(args)
+	case "config":
+		runConfig(args)
+	case "schedule":
+		runSchedule(args)
+	case "shell-init":
+		runShellInit(args)
+	case "completion":
+		runCompletion(args)
+	case "__complete":
+		runComplete(args)
 	case "--help", "-h", "help":
 		printHelp()
 	default:
@@ -42,21 +111,99 @@ func Execute(version string) {
 	}
 }
 
+// parseGlobalFlags consumes the global flags that precede the subcommand and
+// returns the remaining arguments.
+func parseGlobalFlags(args []string) ([]string, error) {
+	for len(args) > 0 {
+		name, value, hasValue := strings.Cut(args[0], "=")
+		var target *string
+		switch name {
+		case "--config":
+			target = &configPathOverride
+		case "--profile":
+			target = &profileOverride
+		default:
+			return args, nil
+		}
+		if !hasValue {
+			if len(args) < 2 {
+				return nil, fmt.Errorf("%s requires a value", name)
+			}
+			value = args[1]
+			args = args[1:]
+		}
+		if value == "" {
+			return nil, fmt.Errorf("%s requires a value", name)
+		}
+		*target = value
+		args = args[1:]
+	}
+	return args, nil
+}
+
+// migrateLegacyConfig moves ~/.gogitup to the XDG config location, unless
+// its path is overridden.
+func migrateLegacyConfig() {
+	if path, err := config.MigrateLegacy(); err != nil {
+		output.ErrorWriter.Warn(fmt.Sprintf("Could not move %s to the XDG config directory: %v", config.LegacyPath(), err))
+	} else if path != "" {
+		output.ErrorWriter.Info(fmt.Sprintf("Moved %s to %s", config.LegacyPath(), path))
+	}
+}
+
+// migrateLegacyCache moves ~/.gogitup.cache to the XDG cache location,
+// unless its path is overridden.
+func migrateLegacyCache() {
+	if path, err := cache.MigrateLegacy(); err != nil {
+		output.ErrorWriter.Warn(fmt.Sprintf("Could not move %s to the XDG cache directory: %v", cache.LegacyPath(), err))
+	} else if path != "" {
+		output.ErrorWriter.Info(fmt.Sprintf("Moved %s to %s", cache.LegacyPath(), path))
+	}
+}
+
 func printHelp() {
 	output.Header("gogitup - Keep your Go-installed binaries up to date")
 	fmt.Println()
-	fmt.Printf("  %sUsage:%s gogitup <command> [arguments]\n", output.Bold, output.Reset)
+	fmt.Printf("  %sUsage:%s gogitup [--config <path>] [--profile <name>] <command> [arguments]\n", output.Bold, output.Reset)
 	fmt.Println()
 	fmt.Printf("  %sCommands:%s\n", output.Bold, output.Reset)
-	fmt.Printf("    %sadd%s <name>       Register a Go-installed binary\n", output.Cyan, output.Reset)
-	fmt.Printf("    %sinstall%s <path>   Install a Go binary and register it\n", output.Cyan, output.Reset)
+	fmt.Printf("    %sadd%s <name> [--create-profile]  Register a Go-installed binary\n", output.Cyan, output.Reset)
+	fmt.Printf("    %sinstall%s <path> [--os <goos>] [--arch <goarch>]  Install a Go binary and register it\n", output.Cyan, output.Reset)
 	fmt.Printf("    %sremove%s <name> [--delete]  Remove a registered binary; optionally delete it\n", output.Cyan, output.Reset)
 	fmt.Printf("    %slist%s             List registered binaries and installed versions\n", output.Cyan, output.Reset)
 	fmt.Printf("    %scheck%s            Check for available updates\n", output.Cyan, output.Reset)
-	fmt.Printf("    %supgrade%s          Upgrade all binaries with available updates\n", output.Cyan, output.Reset)
+	fmt.Printf("    %sstatus%s [--short] [--refresh]  Summarize updates from the cache, without network access\n", output.Cyan, output.Reset)
+	fmt.Printf("    %supgrade%s [--os <goos>] [--arch <goarch>]  Upgrade all binaries with available updates\n", output.Cyan, output.Reset)
+	fmt.Printf("    %sbundle%s --out <file>  Package registered binaries into a tarball\n", output.Cyan, output.Reset)
+	fmt.Printf("    %sunbundle%s <file>  Install and register binaries from a tarball\n", output.Cyan, output.Reset)
+	fmt.Printf("    %scache%s show|clear|prune  Inspect or maintain the version-check cache\n", output.Cyan, output.Reset)
+	fmt.Printf("    %sconfig%s get|set|unset|list  View or change config settings\n", output.Cyan, output.Reset)
+	fmt.Printf("    %sschedule%s enable|disable|status  Check or upgrade automatically in the background\n", output.Cyan, output.Reset)
+	fmt.Printf("    %sshell-init%s bash|zsh|fish  Print a snippet that reports updates when a shell starts\n", output.Cyan, output.Reset)
+	fmt.Printf("    %scompletion%s bash|zsh|fish|powershell  Print a shell completion script\n", output.Cyan, output.Reset)
+	fmt.Printf("    %sdoctor%s           Diagnose config, toolchain, and GitHub API problems\n", output.Cyan, output.Reset)
 	fmt.Println()
 	fmt.Printf("  %sFlags:%s\n", output.Bold, output.Reset)
+	fmt.Printf("    %s--config%s <path>  Use the given config file\n", output.Cyan, output.Reset)
+	fmt.Printf("    %s--profile%s <name> Use the named config profile\n", output.Cyan, output.Reset)
 	fmt.Printf("    %s--version, -v%s    Print version\n", output.Cyan, output.Reset)
 	fmt.Printf("    %s--help, -h%s       Show this help message\n", output.Cyan, output.Reset)
 	fmt.Println()
 }
+
+// parseInterspersed parses flags that may appear before or after positional
+// arguments and returns the positional arguments in order.
+func parseInterspersed(fs *flag.FlagSet, args []string) ([]string, error) {
+	var positional []string
+	for {
+		if err := fs.Parse(args); err != nil {
+			return nil, err
+		}
+		args = fs.Args()
+		if len(args) == 0 {
+			return positional, nil
+		}
+		positional = append(positional, args[0])
+		args = args[1:]
+	}
+}
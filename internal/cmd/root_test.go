@@ -0,0 +1,142 @@
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"sync"
+	"testing"
+)
+
+func TestParseGlobalFlags(t *testing.T) {
+	tests := []struct {
+		name        string
+		args        []string
+		wantArgs    []string
+		wantPath    string
+		wantProfile string
+		wantErr     bool
+	}{
+		{name: "no flags", args: []string{"list", "--json"}, wantArgs: []string{"list", "--json"}},
+		{name: "separate value", args: []string{"--config", "/tmp/cfg", "check"}, wantArgs: []string{"check"}, wantPath: "/tmp/cfg"},
+		{name: "inline value", args: []string{"--config=/tmp/cfg", "list"}, wantArgs: []string{"list"}, wantPath: "/tmp/cfg"},
+		{name: "subcommand flag untouched", args: []string{"check", "--config", "x"}, wantArgs: []string{"check", "--config", "x"}},
+		{name: "profile", args: []string{"--profile", "work", "--config=/tmp/cfg", "list"}, wantArgs: []string{"list"}, wantPath: "/tmp/cfg", wantProfile: "work"},
+		{name: "missing value", args: []string{"--config"}, wantErr: true},
+		{name: "missing profile", args: []string{"--profile"}, wantErr: true},
+		{name: "empty inline value", args: []string{"--config=", "list"}, wantErr: true},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			configPathOverride, profileOverride = "", ""
+			t.Cleanup(func() { configPathOverride, profileOverride = "", "" })
+
+			got, err := parseGlobalFlags(tc.args)
+			if tc.wantErr {
+				if err == nil {
+					t.Fatal("expected an error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if !slices.Equal(got, tc.wantArgs) || configPathOverride != tc.wantPath || profileOverride != tc.wantProfile {
+				t.Fatalf("parseGlobalFlags(%q) = %q (config %q, profile %q), want %q (config %q, profile %q)",
+					tc.args, got, configPathOverride, profileOverride, tc.wantArgs, tc.wantPath, tc.wantProfile)
+			}
+		})
+	}
+}
+
+func TestConfigPathHonorsOverride(t *testing.T) {
+	t.Setenv("GOGITUP_CONFIG", "/env/config")
+	configPathOverride = ""
+	if got := configPath(); got != "/env/config" {
+		t.Fatalf("expected GOGITUP_CONFIG path, got %s", got)
+	}
+
+	configPathOverride = "/flag/config"
+	t.Cleanup(func() { configPathOverride = "" })
+	if got := configPath(); got != "/flag/config" {
+		t.Fatalf("expected --config path, got %s", got)
+	}
+}
+
+func TestProfileNameHonorsOverride(t *testing.T) {
+	t.Setenv("GOGITUP_PROFILE", "ci")
+	profileOverride = ""
+	if got := profileName(); got != "ci" {
+		t.Fatalf("expected GOGITUP_PROFILE profile, got %s", got)
+	}
+
+	profileOverride = "work"
+	t.Cleanup(func() { profileOverride = "" })
+	if got := profileName(); got != "work" {
+		t.Fatalf("expected --profile profile, got %s", got)
+	}
+}
+
+func TestCacheFilePathMovesLegacyCacheOnce(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv("XDG_CACHE_HOME", filepath.Join(home, "cache"))
+	t.Setenv("GOGITUP_CACHE", "")
+	migrateCacheOnce = sync.Once{}
+	t.Cleanup(func() { migrateCacheOnce = sync.Once{} })
+
+	legacy := filepath.Join(home, ".gogitup.cache")
+	if err := os.WriteFile(legacy, []byte("entries: {}\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	want := filepath.Join(home, "cache", "gogitup", "cache.yaml")
+	if got := cacheFilePath(); got != want {
+		t.Fatalf("cacheFilePath() = %s, want %s", got, want)
+	}
+	if _, err := os.Stat(legacy); !os.IsNotExist(err) {
+		t.Fatal("expected the legacy cache to be moved")
+	}
+
+	// A legacy file that appears later is left alone within the same run.
+	if err := os.WriteFile(legacy, []byte("entries: {}\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	os.Remove(want)
+	if got := cacheFilePath(); got != legacy {
+		t.Fatalf("cacheFilePath() = %s, want the legacy path %s", got, legacy)
+	}
+}
+
+func TestLockStateMovesLegacyConfigAfterLocking(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv("XDG_CONFIG_HOME", filepath.Join(home, "config"))
+	t.Setenv("XDG_CACHE_HOME", filepath.Join(home, "cache"))
+	t.Setenv("GOGITUP_CONFIG", "")
+	t.Setenv("GOGITUP_CACHE", "")
+	configPathOverride = ""
+	migrateConfigOnce, migrateCacheOnce = sync.Once{}, sync.Once{}
+	t.Cleanup(func() { migrateConfigOnce, migrateCacheOnce = sync.Once{}, sync.Once{} })
+
+	legacy := filepath.Join(home, ".gogitup")
+	if err := os.WriteFile(legacy, []byte("apps: []\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	want := filepath.Join(home, "config", "gogitup", "config.yaml")
+	if got := lockPath(); got != want+".lock" {
+		t.Fatalf("lockPath() = %s, want %s.lock", got, want)
+	}
+	if _, err := os.Stat(legacy); err != nil {
+		t.Fatal("expected resolving the lock path to leave the legacy config in place")
+	}
+
+	unlock := lockState()
+	defer unlock()
+	if _, err := os.Stat(want); err != nil {
+		t.Fatalf("expected the legacy config to be moved once locked: %v", err)
+	}
+	if got := configPath(); got != want {
+		t.Fatalf("configPath() = %s, want %s", got, want)
+	}
+}
@@ -0,0 +1,255 @@
+package cmd
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"github.com/UnitVectorY-Labs/gogitup/internal/cache"
+	"github.com/UnitVectorY-Labs/gogitup/internal/config"
+	"github.com/UnitVectorY-Labs/gogitup/internal/output"
+	"github.com/UnitVectorY-Labs/gogitup/internal/schedule"
+)
+
+const scheduleUsage = "Usage: gogitup schedule <enable [--daily|--weekly] [--auto-upgrade]|disable|status>"
+
+// scheduleEnv lists the environment variables copied into the scheduled job,
+// which does not run in the user's login shell. Tokens are left out so that
+// they are not written to unit files or the crontab.
+var scheduleEnv = []string{
+	"PATH", "GOPATH", "GOBIN", "GOROOT", "GOFLAGS", "GOTOOLCHAIN",
+	"GOPROXY", "GONOPROXY", "GOPRIVATE", "GOSUMDB", "GONOSUMDB", "GOINSECURE",
+	"HTTPS_PROXY", "HTTP_PROXY", "NO_PROXY",
+	envProfile, config.EnvPath, cache.EnvPath,
+	"XDG_CONFIG_HOME", "XDG_CACHE_HOME", "XDG_STATE_HOME",
+	"DBUS_SESSION_BUS_ADDRESS",
+}
+
+type scheduleEnableOptions struct {
+	Frequency   schedule.Frequency
+	AutoUpgrade bool
+}
+
+func parseScheduleEnableOptions(args []string, stderr io.Writer) (scheduleEnableOptions, error) {
+	fs := flag.NewFlagSet("schedule enable", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+
+	var opts scheduleEnableOptions
+	daily := fs.Bool("daily", false, "Run once a day (default)")
+	weekly := fs.Bool("weekly", false, "Run once a week")
+	fs.BoolVar(&opts.AutoUpgrade, "auto-upgrade", false, "Upgrade binaries instead of only checking for updates")
+	if err := fs.Parse(args); err != nil {
+		return scheduleEnableOptions{}, err
+	}
+	if fs.NArg() > 0 {
+		return scheduleEnableOptions{}, errors.New(scheduleUsage)
+	}
+	if *daily && *weekly {
+		return scheduleEnableOptions{}, errors.New("--daily and --weekly cannot be used together")
+	}
+	opts.Frequency = schedule.Daily
+	if *weekly {
+		opts.Frequency = schedule.Weekly
+	}
+	return opts, nil
+}
+
+func runSchedule(args []string) {
+	if len(args) < 1 {
+		output.Error(scheduleUsage)
+		os.Exit(1)
+	}
+
+	switch args[0] {
+	case "enable":
+		runScheduleEnable(args[1:])
+	case "disable":
+		expectScheduleArgs(args)
+		runScheduleDisable(schedule.Schedulers())
+	case "status":
+		expectScheduleArgs(args)
+		runScheduleStatus(schedule.Schedulers())
+	default:
+		output.Error("Unknown schedule command: " + args[0])
+		output.Error(scheduleUsage)
+		os.Exit(1)
+	}
+}
+
+func expectScheduleArgs(args []string) {
+	if len(args) != 1 {
+		output.Error(scheduleUsage)
+		os.Exit(1)
+	}
+}
+
+func runScheduleEnable(args []string) {
+	opts, err := parseScheduleEnableOptions(args, output.ErrorWriter.Out)
+	if err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return
+		}
+		output.Error(err.Error())
+		os.Exit(2)
+	}
+
+	cfg, err := config.LoadProfile(configPath(), profileName())
+	if err != nil {
+		output.Error(fmt.Sprintf("Failed to load config: %v", err))
+		os.Exit(1)
+	}
+
+	executable, err := executablePath()
+	if err != nil {
+		output.Error(fmt.Sprintf("Failed to find the gogitup executable: %v", err))
+		os.Exit(1)
+	}
+	job, err := scheduleJob(opts, cfg, executable)
+	if err != nil {
+		output.Error(err.Error())
+		os.Exit(1)
+	}
+	if err := os.MkdirAll(filepath.Dir(job.LogFile), 0755); err != nil {
+		output.Error(fmt.Sprintf("Failed to create log directory: %v", err))
+		os.Exit(1)
+	}
+
+	backend, err := enableSchedule(job, schedule.Schedulers())
+	if err != nil {
+		output.Error(fmt.Sprintf("Failed to schedule gogitup: %v", err))
+		os.Exit(1)
+	}
+	output.Success(fmt.Sprintf("Scheduled 'gogitup %s' to run %s with a %s", strings.Join(job.Command[1:], " "), opts.Frequency, backend.Name()))
+	output.Info("Output is appended to " + job.LogFile)
+}
+
+// executablePath returns the path gogitup was started from. Symlinks are kept,
+// so the job keeps working when a package manager replaces the binary a
+// symlink points to.
+func executablePath() (string, error) {
+	path := os.Args[0]
+	if !strings.ContainsRune(path, filepath.Separator) {
+		found, err := exec.LookPath(path)
+		if err != nil {
+			return os.Executable()
+		}
+		path = found
+	}
+	return filepath.Abs(path)
+}
+
+// scheduleJob builds the job that runs executable on the schedule in opts. The
+// job checks for updates, notifying about them when notifiers are configured,
+// or upgrades with --auto-upgrade. The config file and profile in use are
+// passed on.
+func scheduleJob(opts scheduleEnableOptions, cfg *config.Config, executable string) (schedule.Job, error) {
+	// go run builds in a temporary directory that is removed on exit.
+	if strings.Contains(executable, string(filepath.Separator)+"go-build") {
+		return schedule.Job{}, fmt.Errorf("gogitup is running from the temporary directory %s; install it before scheduling it", filepath.Dir(executable))
+	}
+
+	command := append([]string{executable}, globalArgs()...)
+	switch {
+	case opts.AutoUpgrade:
+		command = append(command, "upgrade")
+	case len(cfg.Notifiers) > 0:
+		command = append(command, "check", "--notify")
+	default:
+		command = append(command, "check")
+	}
+
+	var env []string
+	for _, key := range scheduleEnv {
+		if value := os.Getenv(key); value != "" {
+			env = append(env, key+"="+value)
+		}
+	}
+	return schedule.Job{Frequency: opts.Frequency, Command: command, Env: env, LogFile: schedule.DefaultLogPath()}, nil
+}
+
+// enableSchedule installs job with the first available backend, and removes
+// any job another backend installed before.
+func enableSchedule(job schedule.Job, backends []schedule.Scheduler) (schedule.Scheduler, error) {
+	var chosen schedule.Scheduler
+	for _, b := range backends {
+		if !b.Available() {
+			continue
+		}
+		if chosen != nil {
+			if _, err := b.Disable(); err != nil {
+				return nil, fmt.Errorf("failed to remove the job from the %s: %w", b.Name(), err)
+			}
+			continue
+		}
+		chosen = b
+	}
+	if chosen == nil {
+		return nil, errors.New("neither systemd user timers nor crontab are available")
+	}
+	return chosen, chosen.Enable(job)
+}
+
+func runScheduleDisable(backends []schedule.Scheduler) {
+	removed := false
+	for _, b := range backends {
+		if !b.Available() {
+			continue
+		}
+		found, err := b.Disable()
+		if err != nil {
+			output.Error(fmt.Sprintf("Failed to remove the job from the %s: %v", b.Name(), err))
+			os.Exit(1)
+		}
+		if found {
+			output.Success("Removed the scheduled job from the " + b.Name())
+			removed = true
+		}
+	}
+	if !removed {
+		output.Info("gogitup is not scheduled")
+	}
+}
+
+func runScheduleStatus(backends []schedule.Scheduler) {
+	for _, b := range backends {
+		if !b.Available() {
+			continue
+		}
+		st, err := b.Status()
+		if err != nil {
+			output.Error(fmt.Sprintf("Failed to read the %s: %v", b.Name(), err))
+			os.Exit(1)
+		}
+		if st.Command == "" && !st.Enabled {
+			continue
+		}
+		printScheduleStatus(b, st)
+		return
+	}
+	output.Info("gogitup is not scheduled. Use 'gogitup schedule enable' to schedule it.")
+}
+
+func printScheduleStatus(b schedule.Scheduler, st schedule.Status) {
+	state := output.Green + "enabled" + output.Reset
+	if !st.Enabled {
+		state = output.Yellow + "disabled" + output.Reset
+	}
+	output.Header("Schedule")
+	fmt.Println()
+	fmt.Printf("  %-9s %s (%s)\n", "Backend", b.Name(), state)
+	fmt.Printf("  %-9s %s\n", "Schedule", st.Schedule)
+	fmt.Printf("  %-9s %s\n", "Command", st.Command)
+	if st.LastRun != "" && st.LastRun != "n/a" {
+		fmt.Printf("  %-9s %s\n", "Last run", st.LastRun)
+	}
+	if st.NextRun != "" && st.NextRun != "n/a" {
+		fmt.Printf("  %-9s %s\n", "Next run", st.NextRun)
+	}
+	fmt.Printf("  %-9s %s\n", "Log", schedule.DefaultLogPath())
+	fmt.Println()
+}
@@ -0,0 +1,103 @@
+package cmd
+
+import (
+	"bytes"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/UnitVectorY-Labs/gogitup/internal/config"
+	"github.com/UnitVectorY-Labs/gogitup/internal/schedule"
+)
+
+func TestParseScheduleEnableOptions(t *testing.T) {
+	var stderr bytes.Buffer
+	opts, err := parseScheduleEnableOptions(nil, &stderr)
+	if err != nil || opts.Frequency != schedule.Daily || opts.AutoUpgrade {
+		t.Fatalf("default options = %+v, %v", opts, err)
+	}
+
+	opts, err = parseScheduleEnableOptions([]string{"--weekly", "--auto-upgrade"}, &stderr)
+	if err != nil || opts.Frequency != schedule.Weekly || !opts.AutoUpgrade {
+		t.Fatalf("options = %+v, %v", opts, err)
+	}
+
+	if _, err := parseScheduleEnableOptions([]string{"--daily", "--weekly"}, &stderr); err == nil {
+		t.Fatal("expected an error for --daily with --weekly")
+	}
+}
+
+func TestScheduleJob(t *testing.T) {
+	t.Setenv("PATH", "/usr/local/go/bin:/usr/bin")
+	t.Setenv("GOPROXY", "")
+	t.Setenv("GITHUB_TOKEN", "secret")
+	oldConfig, oldProfile := configPathOverride, profileOverride
+	t.Cleanup(func() { configPathOverride, profileOverride = oldConfig, oldProfile })
+	configPathOverride, profileOverride = "/home/me/gogitup.yaml", "work"
+
+	job, err := scheduleJob(scheduleEnableOptions{Frequency: schedule.Weekly, AutoUpgrade: true}, &config.Config{}, "/home/me/go/bin/gogitup")
+	if err != nil {
+		t.Fatalf("scheduleJob() error: %v", err)
+	}
+	want := []string{"/home/me/go/bin/gogitup", "--config", "/home/me/gogitup.yaml", "--profile", "work", "upgrade"}
+	if !slices.Equal(job.Command, want) || job.Frequency != schedule.Weekly {
+		t.Fatalf("job = %+v, want command %q", job, want)
+	}
+	if !slices.Contains(job.Env, "PATH=/usr/local/go/bin:/usr/bin") {
+		t.Errorf("expected PATH in %q", job.Env)
+	}
+	for _, env := range job.Env {
+		if strings.HasPrefix(env, "GITHUB_TOKEN=") || strings.HasPrefix(env, "GOPROXY=") {
+			t.Errorf("unexpected environment variable %q", env)
+		}
+	}
+
+	configPathOverride, profileOverride = "", ""
+	cfg := &config.Config{Notifiers: []config.Notifier{{Type: "desktop"}}}
+	job, err = scheduleJob(scheduleEnableOptions{Frequency: schedule.Daily}, cfg, "/home/me/go/bin/gogitup")
+	if err != nil {
+		t.Fatalf("scheduleJob() error: %v", err)
+	}
+	if !slices.Equal(job.Command, []string{"/home/me/go/bin/gogitup", "check", "--notify"}) {
+		t.Fatalf("command = %q, want check --notify", job.Command)
+	}
+}
+
+type stubScheduler struct {
+	name      string
+	available bool
+	enabled   *schedule.Job
+	disabled  bool
+}
+
+func (s *stubScheduler) Name() string    { return s.name }
+func (s *stubScheduler) Available() bool { return s.available }
+func (s *stubScheduler) Enable(job schedule.Job) error {
+	s.enabled = &job
+	return nil
+}
+func (s *stubScheduler) Disable() (bool, error) {
+	s.disabled = true
+	return false, nil
+}
+func (s *stubScheduler) Status() (schedule.Status, error) { return schedule.Status{}, nil }
+
+func TestEnableScheduleUsesFirstAvailableBackend(t *testing.T) {
+	systemd := &stubScheduler{name: "systemd user timer"}
+	cron := &stubScheduler{name: "crontab", available: true}
+	backend, err := enableSchedule(schedule.Job{Frequency: schedule.Daily}, []schedule.Scheduler{systemd, cron})
+	if err != nil || backend != cron || cron.enabled == nil || systemd.enabled != nil {
+		t.Fatalf("enableSchedule() = %v, %v; want crontab", backend, err)
+	}
+
+	systemd.available = true
+	cron.enabled = nil
+	backend, err = enableSchedule(schedule.Job{Frequency: schedule.Daily}, []schedule.Scheduler{systemd, cron})
+	if err != nil || backend != systemd || !cron.disabled || cron.enabled != nil {
+		t.Fatalf("expected systemd to be used and the crontab entry removed, got %v, %v", backend, err)
+	}
+
+	if _, err := enableSchedule(schedule.Job{}, []schedule.Scheduler{&stubScheduler{}}); err == nil {
+		t.Fatal("expected an error without an available backend")
+	}
+}
@@ -0,0 +1,74 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/UnitVectorY-Labs/gogitup/internal/cache"
+	"github.com/UnitVectorY-Labs/gogitup/internal/config"
+	"github.com/UnitVectorY-Labs/gogitup/internal/fileutil"
+	"github.com/UnitVectorY-Labs/gogitup/internal/output"
+)
+
+// lockState takes the advisory lock that serializes commands modifying the
+// config or cache, waiting for other gogitup processes when needed, then moves
+// any legacy config and cache files while the lock is held. The lock file sits
+// next to the config file. The returned function releases the lock; exiting
+// the process releases it as well.
+func lockState() func() {
+	path := lockPath()
+	l, err := fileutil.TryLock(path)
+	if errors.Is(err, fileutil.ErrLocked) {
+		output.ErrorWriter.Info("Waiting for another gogitup process to finish...")
+		l, err = fileutil.WaitLock(path)
+	}
+	if err != nil {
+		output.ErrorWriter.Warn(fmt.Sprintf("Could not lock %s: %v", path, err))
+		migrateLegacyFiles()
+		return func() {}
+	}
+	migrateLegacyFiles()
+	return func() { _ = l.Unlock() }
+}
+
+// lockPath returns the path of the state lock, honoring the global --config
+// flag. Resolving it has no side effects, so the lock is taken before any
+// legacy file is moved.
+func lockPath() string {
+	if configPathOverride != "" {
+		return configPathOverride + ".lock"
+	}
+	return config.LockPath()
+}
+
+// migrateLegacyFiles moves the legacy config and cache files that the paths
+// in use would otherwise still point to.
+func migrateLegacyFiles() {
+	if configPathOverride == "" {
+		migrateConfigOnce.Do(migrateLegacyConfig)
+	}
+	migrateCacheOnce.Do(migrateLegacyCache)
+}
+
+// loadCache loads the cache file, warning about and replacing a corrupt one.
+func loadCache(path string) (*cache.Cache, error) {
+	c, err := cache.Load(path)
+	var corrupt *cache.CorruptError
+	if errors.As(err, &corrupt) {
+		output.ErrorWriter.Warn(corrupt.Error())
+		return c, nil
+	}
+	return c, err
+}
+
+// readCache loads the cache file without changing it, warning about a
+// corrupt one, for commands that do not take the state lock.
+func readCache(path string) (*cache.Cache, error) {
+	c, err := cache.LoadReadOnly(path)
+	var corrupt *cache.CorruptError
+	if errors.As(err, &corrupt) {
+		output.ErrorWriter.Warn(corrupt.Error())
+		return c, nil
+	}
+	return c, err
+}
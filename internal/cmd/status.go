@@ -0,0 +1,365 @@
+package cmd
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/UnitVectorY-Labs/gogitup/internal/cache"
+	"github.com/UnitVectorY-Labs/gogitup/internal/config"
+	"github.com/UnitVectorY-Labs/gogitup/internal/goversion"
+	"github.com/UnitVectorY-Labs/gogitup/internal/output"
+	"golang.org/x/mod/semver"
+)
+
+// refreshInterval is how long status waits before starting another
+// background refresh.
+const refreshInterval = 15 * time.Minute
+
+type statusOptions struct {
+	Short   bool
+	Refresh bool
+}
+
+// appStatus is what the cache says about one registered app.
+type appStatus struct {
+	Name             string
+	InstalledVersion string
+	LatestVersion    string
+	UpdateAvailable  bool
+	// Checked is false when the cache holds no result for the app.
+	Checked bool
+	// Missing is set when the binary cannot be found.
+	Missing bool
+	// Stale is set when the cached result has expired or no longer matches
+	// the installed binary.
+	Stale     bool
+	CheckedAt time.Time
+}
+
+func parseStatusOptions(args []string, stderr io.Writer) (statusOptions, error) {
+	fs := flag.NewFlagSet("status", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+
+	var opts statusOptions
+	fs.BoolVar(&opts.Short, "short", false, "Print a one-line summary, or nothing when everything is up to date")
+	fs.BoolVar(&opts.Refresh, "refresh", false, "Start a check in the background when cached results are stale")
+	if err := fs.Parse(args); err != nil {
+		return statusOptions{}, err
+	}
+	if fs.NArg() > 0 {
+		return statusOptions{}, errors.New("Usage: gogitup status [--short] [--refresh]")
+	}
+	return opts, nil
+}
+
+// runStatus reports updates from the cache alone. It makes no network
+// requests and does not take the state lock, so it is fast enough to run
+// whenever a shell starts.
+func runStatus(args []string) {
+	opts, err := parseStatusOptions(args, output.ErrorWriter.Out)
+	if err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return
+		}
+		output.Error(err.Error())
+		os.Exit(2)
+	}
+
+	cfg, err := config.LoadProfile(configPath(), profileName())
+	if err != nil {
+		output.Error(fmt.Sprintf("Failed to load config: %v", err))
+		os.Exit(1)
+	}
+	cachePath := cacheFilePath()
+	c, err := readCache(cachePath)
+	if err != nil {
+		output.Error(fmt.Sprintf("Failed to load cache: %v", err))
+		os.Exit(1)
+	}
+	ttls, err := resolveCacheTTLs(cfg, "")
+	if err != nil {
+		output.Error(err.Error())
+		os.Exit(1)
+	}
+
+	targets, err := resolveAppTargets(targetOptions{}, cfg)
+	if err != nil {
+		output.Error(fmt.Sprintf("Failed to resolve target platform: %v", err))
+		os.Exit(1)
+	}
+
+	statuses := appStatuses(cfg, c, ttls, targets, newTargetRunner)
+	if opts.Refresh && needsRefresh(statuses) {
+		if err := startRefresh(filepath.Join(filepath.Dir(cachePath), "refresh"), time.Now()); err != nil {
+			output.ErrorWriter.Warn(fmt.Sprintf("Could not start a background check: %v", err))
+		}
+	}
+
+	if opts.Short {
+		if line := shortStatus(statuses); line != "" {
+			fmt.Println(line)
+		}
+		return
+	}
+	printStatus(statuses)
+}
+
+// appStatuses reads the update status of every registered app from the
+// cache. The installed version is taken from the cache while the binary is
+// the one it was read from and has not been modified; otherwise it is read
+// with the runner for the app's target. Cross-compiled apps are reported
+// under their platform cache keys, as check reports them.
+func appStatuses(cfg *config.Config, c *cache.Cache, ttls map[string]time.Duration, targets map[string]appTarget, runnerFor func(appTarget) goversion.Runner) []appStatus {
+	statuses := make([]appStatus, 0, len(cfg.Apps))
+	for _, app := range cfg.Apps {
+		target := targets[app.Name]
+		key := target.key(app.Name)
+		st := appStatus{Name: key}
+		path, pathErr := binaryPath(app.Name, target.platform, target.binDir)
+		entry, ok := c.Entries[key]
+		if !ok {
+			if pathErr == nil {
+				_, pathErr = os.Stat(path)
+			}
+			st.Missing = pathErr != nil
+			statuses = append(statuses, st)
+			continue
+		}
+		st.Checked = true
+		st.LatestVersion = entry.LatestVersion
+		st.CheckedAt = entry.CheckedAt
+		ttl, ok := ttls[app.Name]
+		if !ok {
+			ttl = cache.DefaultTTL
+		}
+		st.Stale = cache.IsExpired(entry, ttl)
+
+		st.InstalledVersion = entry.InstalledVersion
+		if pathErr != nil || !binaryUnchanged(path, entry) {
+			info, err := runnerFor(target).GetInfo(app.Name)
+			if err != nil {
+				// The binary is gone, so there is nothing to update.
+				st.InstalledVersion = ""
+				st.Missing = true
+				statuses = append(statuses, st)
+				continue
+			}
+			st.InstalledVersion = info.Version
+			st.Stale = st.Stale || info.Version != entry.InstalledVersion
+		}
+		st.UpdateAvailable = olderVersion(st.InstalledVersion, st.LatestVersion)
+		statuses = append(statuses, st)
+	}
+	return statuses
+}
+
+// olderVersion reports whether installed is older than latest. A binary built
+// from a commit after the latest release, whose pseudo-version sorts above
+// it, or a (devel) build, which has no version to compare, is up to date.
+func olderVersion(installed, latest string) bool {
+	if !semver.IsValid(installed) || !semver.IsValid(latest) {
+		return false
+	}
+	return semver.Compare(installed, latest) < 0
+}
+
+// binaryUnchanged reports whether path is the binary the cache entry's
+// installed version was read from, unmodified.
+func binaryUnchanged(path string, entry cache.Entry) bool {
+	if entry.BinaryPath == "" || path != entry.BinaryPath {
+		return false
+	}
+	info, err := os.Stat(path)
+	return err == nil && info.ModTime().Equal(entry.BinaryModTime)
+}
+
+// recordBinary stores in the cache which binary the installed version of key
+// was read from, for binaryUnchanged.
+func recordBinary(c *cache.Cache, key string, info *goversion.Info) {
+	if info.File == "" {
+		return
+	}
+	fi, err := os.Stat(info.File)
+	if err != nil {
+		return
+	}
+	cache.SetBinary(c, key, info.Version, info.File, fi.ModTime())
+}
+
+// needsRefresh reports whether any app that is installed has no cached
+// result or a stale one.
+func needsRefresh(statuses []appStatus) bool {
+	for _, st := range statuses {
+		if !st.Missing && (!st.Checked || st.Stale) {
+			return true
+		}
+	}
+	return false
+}
+
+// startRefresh starts gogitup check in the background, unless one was
+// started within refreshInterval, as recorded by the modification time of
+// stamp. The check runs detached with its output discarded, and takes the
+// state lock like any other check.
+func startRefresh(stamp string, now time.Time) error {
+	if info, err := os.Stat(stamp); err == nil && now.Sub(info.ModTime()) < refreshInterval {
+		return nil
+	}
+	executable, err := executablePath()
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(stamp), 0700); err != nil {
+		return err
+	}
+	if err := os.WriteFile(stamp, nil, 0600); err != nil {
+		return err
+	}
+	if err := os.Chtimes(stamp, now, now); err != nil {
+		return err
+	}
+
+	cmd := exec.Command(executable, append(globalArgs(), "check")...)
+	detach(cmd)
+	if err := cmd.Start(); err != nil {
+		return err
+	}
+	return cmd.Process.Release()
+}
+
+// shortStatus summarizes the available updates in one line, or returns an
+// empty string when there are none.
+func shortStatus(statuses []appStatus) string {
+	n := 0
+	for _, st := range statuses {
+		if st.UpdateAvailable {
+			n++
+		}
+	}
+	switch n {
+	case 0:
+		return ""
+	case 1:
+		return "gogitup: 1 tool update available"
+	default:
+		return fmt.Sprintf("gogitup: %d tool updates available", n)
+	}
+}
+
+func printStatus(statuses []appStatus) {
+	if len(statuses) == 0 {
+		output.Info("No binaries registered. Use 'gogitup add <name>' to add one.")
+		return
+	}
+
+	var updates []appStatus
+	unchecked := 0
+	var oldest time.Time
+	for _, st := range statuses {
+		if !st.Checked {
+			unchecked++
+			continue
+		}
+		if oldest.IsZero() || st.CheckedAt.Before(oldest) {
+			oldest = st.CheckedAt
+		}
+		if st.UpdateAvailable {
+			updates = append(updates, st)
+		}
+	}
+
+	switch {
+	case len(updates) > 0:
+		output.Warn(fmt.Sprintf("%d of %d tools have updates available:", len(updates), len(statuses)))
+		for _, st := range updates {
+			fmt.Printf("    %s %s%s%s → %s%s%s\n", st.Name, output.Green, st.InstalledVersion, output.Reset, output.Cyan, st.LatestVersion, output.Reset)
+		}
+		output.Info("Run 'gogitup upgrade' to install them.")
+	case unchecked < len(statuses):
+		output.Success(fmt.Sprintf("All %d checked tools are up to date.", len(statuses)-unchecked))
+	}
+	if unchecked > 0 {
+		output.Info(fmt.Sprintf("%d tool(s) have not been checked yet. Run 'gogitup check'.", unchecked))
+	}
+	if !oldest.IsZero() {
+		output.Info("Oldest result checked " + formatAge(time.Since(oldest)))
+	}
+}
+
+// globalArgs returns the global flags gogitup was started with, so that
+// commands it starts use the same config file and profile.
+func globalArgs() []string {
+	var args []string
+	if configPathOverride != "" {
+		path, err := filepath.Abs(configPathOverride)
+		if err != nil {
+			path = configPathOverride
+		}
+		args = append(args, "--config", path)
+	}
+	if profileOverride != "" {
+		args = append(args, "--profile", profileOverride)
+	}
+	return args
+}
+
+// shellInitUsage lists the shells shell-init supports.
+const shellInitUsage = "Usage: gogitup shell-init <bash|zsh|fish>"
+
+func runShellInit(args []string) {
+	if len(args) != 1 {
+		output.Error(shellInitUsage)
+		os.Exit(1)
+	}
+	snippet, err := shellInit(args[0], globalArgs())
+	if err != nil {
+		output.Error(err.Error())
+		output.Error(shellInitUsage)
+		os.Exit(1)
+	}
+	fmt.Print(snippet)
+}
+
+// shellInit returns the snippet that prints the short status when an
+// interactive shell starts, and refreshes stale results in the background.
+func shellInit(shell string, global []string) (string, error) {
+	words := []string{"gogitup"}
+	for _, arg := range global {
+		words = append(words, quoteShellWord(shell, arg))
+	}
+	command := strings.Join(append(words, "status", "--short", "--refresh"), " ")
+
+	switch shell {
+	case "bash", "zsh":
+		return fmt.Sprintf(`# gogitup: report available tool updates when an interactive shell starts.
+if [[ $- == *i* ]] && command -v gogitup >/dev/null 2>&1; then
+  command %s 2>/dev/null
+fi
+`, command), nil
+	case "fish":
+		return fmt.Sprintf(`# gogitup: report available tool updates when an interactive shell starts.
+if status is-interactive; and type -q gogitup
+    command %s 2>/dev/null
+end
+`, command), nil
+	default:
+		return "", fmt.Errorf("unsupported shell %q", shell)
+	}
+}
+
+// quoteShellWord single-quotes s for shell. fish escapes quotes and
+// backslashes inside single quotes; POSIX shells cannot, so a quote ends the
+// quoted string and is escaped outside it.
+func quoteShellWord(shell, s string) string {
+	if shell == "fish" {
+		return "'" + strings.NewReplacer(`\`, `\\`, `'`, `\'`).Replace(s) + "'"
+	}
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
@@ -0,0 +1,211 @@
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/UnitVectorY-Labs/gogitup/internal/cache"
+	"github.com/UnitVectorY-Labs/gogitup/internal/config"
+	"github.com/UnitVectorY-Labs/gogitup/internal/goversion"
+	"github.com/UnitVectorY-Labs/gogitup/internal/installer"
+)
+
+// countingRunner records which binaries are read.
+type countingRunner struct {
+	goversion.Runner
+	calls []string
+}
+
+func (r *countingRunner) GetInfo(name string) (*goversion.Info, error) {
+	r.calls = append(r.calls, name)
+	return r.Runner.GetInfo(name)
+}
+
+// fakeBinaries creates executable files on a temporary PATH.
+func fakeBinaries(t *testing.T, names ...string) string {
+	t.Helper()
+	dir := t.TempDir()
+	for _, name := range names {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte("#!/bin/sh\n"), 0755); err != nil {
+			t.Fatal(err)
+		}
+	}
+	t.Setenv("PATH", dir)
+	return dir
+}
+
+func TestAppStatusesReadOnlyChangedBinaries(t *testing.T) {
+	dir := fakeBinaries(t, "tool", "upgraded", "unchecked")
+	modTime := func(name string) time.Time {
+		info, err := os.Stat(filepath.Join(dir, name))
+		if err != nil {
+			t.Fatal(err)
+		}
+		return info.ModTime()
+	}
+	now := time.Now()
+	cfg := &config.Config{Apps: []config.App{{Name: "tool"}, {Name: "upgraded"}, {Name: "unchecked"}, {Name: "gone"}}}
+	c := &cache.Cache{Entries: map[string]cache.Entry{
+		"tool": {InstalledVersion: "v1.0.0", LatestVersion: "v1.1.0", CheckedAt: now,
+			BinaryPath: filepath.Join(dir, "tool"), BinaryModTime: modTime("tool")},
+		// The binary was replaced after the check.
+		"upgraded": {InstalledVersion: "v2.0.0", LatestVersion: "v2.1.0", CheckedAt: now,
+			BinaryPath: filepath.Join(dir, "upgraded"), BinaryModTime: modTime("upgraded").Add(-time.Hour)},
+		"gone": {InstalledVersion: "v3.0.0", LatestVersion: "v3.1.0", CheckedAt: now},
+	}}
+	runner := &countingRunner{Runner: &stubRunner{infos: map[string]*goversion.Info{
+		"upgraded": {Path: "example.com/upgraded", Version: "v2.1.0"},
+	}}}
+
+	statuses := appStatuses(cfg, c, nil, nil, runnerForAll(runner))
+
+	if strings.Join(runner.calls, ",") != "upgraded,gone" {
+		t.Fatalf("read binaries %q, want only the changed and unknown ones", runner.calls)
+	}
+	if st := statuses[0]; !st.UpdateAvailable || st.Stale || st.InstalledVersion != "v1.0.0" {
+		t.Errorf("tool status = %+v, want cached update", st)
+	}
+	if st := statuses[1]; st.UpdateAvailable || !st.Stale || st.InstalledVersion != "v2.1.0" {
+		t.Errorf("upgraded status = %+v, want up to date and stale", st)
+	}
+	if st := statuses[2]; st.Checked || st.Missing {
+		t.Errorf("unchecked status = %+v", st)
+	}
+	if st := statuses[3]; !st.Missing || st.UpdateAvailable {
+		t.Errorf("gone status = %+v, want missing", st)
+	}
+	if !needsRefresh(statuses) {
+		t.Error("expected stale and unchecked results to need a refresh")
+	}
+	if got := shortStatus(statuses); got != "gogitup: 1 tool update available" {
+		t.Errorf("shortStatus() = %q", got)
+	}
+}
+
+func TestAppStatusesUsesTTL(t *testing.T) {
+	dir := fakeBinaries(t, "tool")
+	info, _ := os.Stat(filepath.Join(dir, "tool"))
+	cfg := &config.Config{Apps: []config.App{{Name: "tool"}}}
+	c := &cache.Cache{Entries: map[string]cache.Entry{
+		"tool": {InstalledVersion: "v1.0.0", LatestVersion: "v1.0.0", CheckedAt: time.Now().Add(-2 * time.Hour),
+			BinaryPath: filepath.Join(dir, "tool"), BinaryModTime: info.ModTime()},
+	}}
+	runner := &countingRunner{Runner: &stubRunner{}}
+
+	statuses := appStatuses(cfg, c, map[string]time.Duration{"tool": 3 * time.Hour}, nil, runnerForAll(runner))
+	if needsRefresh(statuses) || shortStatus(statuses) != "" {
+		t.Fatalf("expected a fresh, up-to-date result, got %+v", statuses)
+	}
+	statuses = appStatuses(cfg, c, map[string]time.Duration{"tool": time.Hour}, nil, runnerForAll(runner))
+	if !needsRefresh(statuses) {
+		t.Fatalf("expected an expired result to need a refresh, got %+v", statuses)
+	}
+	if len(runner.calls) != 0 {
+		t.Fatalf("expected no go version calls, got %q", runner.calls)
+	}
+}
+
+// runnerForAll returns a runner selector that uses runner for every target.
+func runnerForAll(runner goversion.Runner) func(appTarget) goversion.Runner {
+	return func(appTarget) goversion.Runner { return runner }
+}
+
+func TestAppStatusesReportsPlatformKeys(t *testing.T) {
+	binDir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(binDir, "tool"), []byte("binary"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	info, _ := os.Stat(filepath.Join(binDir, "tool"))
+	cfg := &config.Config{Apps: []config.App{{Name: "tool"}}}
+	targets := map[string]appTarget{"tool": {platform: installer.Target{GOOS: "linux", GOARCH: "arm64"}, binDir: binDir}}
+	c := &cache.Cache{Entries: map[string]cache.Entry{
+		"tool@linux/arm64": {InstalledVersion: "v1.0.0", LatestVersion: "v1.1.0", CheckedAt: time.Now(),
+			BinaryPath: filepath.Join(binDir, "tool"), BinaryModTime: info.ModTime()},
+	}}
+	runner := &countingRunner{Runner: &stubRunner{}}
+
+	statuses := appStatuses(cfg, c, nil, targets, runnerForAll(runner))
+	if st := statuses[0]; st.Name != "tool@linux/arm64" || !st.UpdateAvailable || st.Missing {
+		t.Fatalf("status = %+v, want the cached cross-compiled update", st)
+	}
+	if len(runner.calls) != 0 {
+		t.Fatalf("expected no go version calls, got %q", runner.calls)
+	}
+}
+
+func TestOlderVersion(t *testing.T) {
+	tests := []struct {
+		installed, latest string
+		want              bool
+	}{
+		{"v1.0.0", "v1.1.0", true},
+		{"v1.1.0", "v1.1.0", false},
+		{"v1.10.0", "v1.9.0", false},
+		{"v1.2.0-rc.1", "v1.2.0", true},
+		// Built from a commit after the latest release.
+		{"v1.1.1-0.20240102150405-abcdefabcdef", "v1.1.0", false},
+		// Built from a commit before the latest release.
+		{"v1.0.1-0.20240102150405-abcdefabcdef", "v1.1.0", true},
+		{"(devel)", "v1.1.0", false},
+	}
+	for _, tt := range tests {
+		if got := olderVersion(tt.installed, tt.latest); got != tt.want {
+			t.Errorf("olderVersion(%q, %q) = %v, want %v", tt.installed, tt.latest, got, tt.want)
+		}
+	}
+}
+
+func TestReadCacheLeavesCorruptCache(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "cache.yaml")
+	if err := os.WriteFile(path, []byte("entries: [\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	c, err := readCache(path)
+	if err != nil || len(c.Entries) != 0 {
+		t.Fatalf("readCache() = %+v, %v; want an empty cache", c, err)
+	}
+	if _, err := os.Stat(path); err != nil {
+		t.Fatalf("expected the corrupt cache to stay in place: %v", err)
+	}
+}
+
+func TestStartRefreshWaitsBetweenRefreshes(t *testing.T) {
+	stamp := filepath.Join(t.TempDir(), "refresh")
+	started := time.Now().Add(-time.Minute)
+	if err := os.WriteFile(stamp, nil, 0600); err != nil {
+		t.Fatal(err)
+	}
+	os.Chtimes(stamp, started, started)
+
+	if err := startRefresh(stamp, time.Now()); err != nil {
+		t.Fatalf("startRefresh() error: %v", err)
+	}
+	info, _ := os.Stat(stamp)
+	if !info.ModTime().Equal(started) {
+		t.Fatal("expected no refresh within the refresh interval")
+	}
+}
+
+func TestShellInit(t *testing.T) {
+	for _, shell := range []string{"bash", "zsh"} {
+		got, err := shellInit(shell, []string{"--profile", "it's"})
+		if err != nil {
+			t.Fatalf("shellInit(%s) error: %v", shell, err)
+		}
+		if !strings.Contains(got, `command gogitup '--profile' 'it'\''s' status --short --refresh 2>/dev/null`) {
+			t.Errorf("shellInit(%s) =\n%s", shell, got)
+		}
+	}
+
+	got, err := shellInit("fish", nil)
+	if err != nil || !strings.Contains(got, "if status is-interactive; and type -q gogitup\n    command gogitup status --short --refresh") {
+		t.Errorf("shellInit(fish) = %q, %v", got, err)
+	}
+
+	if _, err := shellInit("tcsh", nil); err == nil {
+		t.Error("expected an error for an unsupported shell")
+	}
+}
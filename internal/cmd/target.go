@@ -0,0 +1,111 @@
+package cmd
+
+import (
+	"flag"
+
+	"github.com/UnitVectorY-Labs/gogitup/internal/cache"
+	"github.com/UnitVectorY-Labs/gogitup/internal/config"
+	"github.com/UnitVectorY-Labs/gogitup/internal/goversion"
+	"github.com/UnitVectorY-Labs/gogitup/internal/installer"
+)
+
+// targetOptions holds the cross-compilation flags shared by install and upgrade.
+type targetOptions struct {
+	GOOS   string
+	GOARCH string
+	BinDir string
+}
+
+func addTargetFlags(fs *flag.FlagSet, opts *targetOptions) {
+	fs.StringVar(&opts.GOOS, "os", "", "Target GOOS to cross-compile for")
+	fs.StringVar(&opts.GOARCH, "arch", "", "Target GOARCH to cross-compile for")
+	fs.StringVar(&opts.BinDir, "bin-dir", "", "Output directory for cross-compiled binaries")
+}
+
+// appTarget is the platform an app's binary is built for and the directory it
+// is installed into. The zero appTarget is a native install into GOBIN.
+type appTarget struct {
+	platform installer.Target
+	binDir   string
+}
+
+// key returns the cache key of the app's binary: cross-compiled binaries are
+// cached and reported per platform.
+func (t appTarget) key(name string) string {
+	return cache.PlatformKey(name, t.platform.String())
+}
+
+// resolveTarget merges the target flags with the platform app was registered
+// for and the config defaults, in that order, and returns the target platform
+// and the directory its binaries are installed into.
+func resolveTarget(opts targetOptions, cfg *config.Config, app config.App) (appTarget, error) {
+	goos, goarch := opts.GOOS, opts.GOARCH
+	if goos == "" {
+		goos = app.GOOS
+	}
+	if goos == "" {
+		goos = cfg.GOOS
+	}
+	if goarch == "" {
+		goarch = app.GOARCH
+	}
+	if goarch == "" {
+		goarch = cfg.GOARCH
+	}
+	target := installer.ResolveTarget(goos, goarch)
+	if target.IsZero() {
+		return appTarget{}, nil
+	}
+
+	binDir := opts.BinDir
+	if binDir == "" {
+		binDir = app.CrossBinDir
+	}
+	if binDir == "" {
+		binDir = cfg.CrossBinDir
+	}
+	if binDir == "" {
+		var err error
+		binDir, err = installer.DefaultCrossBinDir(target)
+		if err != nil {
+			return appTarget{}, err
+		}
+	}
+	return appTarget{platform: target, binDir: binDir}, nil
+}
+
+// resolveAppTargets resolves the target of every registered app. Apps built
+// natively have no entry.
+func resolveAppTargets(opts targetOptions, cfg *config.Config) (map[string]appTarget, error) {
+	targets := make(map[string]appTarget)
+	for _, app := range cfg.Apps {
+		target, err := resolveTarget(opts, cfg, app)
+		if err != nil {
+			return nil, err
+		}
+		if !target.platform.IsZero() {
+			targets[app.Name] = target
+		}
+	}
+	return targets, nil
+}
+
+// newTargetInstaller returns the installer for the resolved target.
+func newTargetInstaller(cfg *config.Config, target appTarget) installer.Installer {
+	var d *installer.DefaultInstaller
+	if target.platform.IsZero() {
+		d = installer.NewDefaultInstallerWithOptions(cfg.GOPROXY, cfg.CGOEnabled)
+	} else {
+		d = installer.NewCrossInstaller(cfg.GOPROXY, cfg.CGOEnabled, target.platform, target.binDir)
+	}
+	d.SetExtraEnv(goEnv(cfg))
+	return d
+}
+
+// newTargetRunner returns the runner that inspects binaries for the resolved target.
+func newTargetRunner(target appTarget) goversion.Runner {
+	if target.platform.IsZero() {
+		return &goversion.DefaultRunner{}
+	}
+	return &goversion.DirRunner{Dir: target.binDir, GOOS: target.platform.GOOS}
+}
@@ -6,6 +6,7 @@ import (
 	"fmt"
 	"io"
 	"os"
+	"slices"
 
 	"github.com/UnitVectorY-Labs/gogitup/internal/cache"
 	"github.com/UnitVectorY-Labs/gogitup/internal/config"
@@ -14,36 +15,75 @@ import (
 	"github.com/UnitVectorY-Labs/gogitup/internal/goversion"
 	"github.com/UnitVectorY-Labs/gogitup/internal/installer"
 	"github.com/UnitVectorY-Labs/gogitup/internal/output"
+	"github.com/UnitVectorY-Labs/gogitup/internal/release"
+	"github.com/UnitVectorY-Labs/gogitup/internal/vanity"
+	"golang.org/x/mod/semver"
 )
 
 type upgradeOptions struct {
 	Verbose bool
+	targetOptions
+	// targets holds the resolved target of each cross-compiled app; apps
+	// without an entry are upgraded natively.
+	targets map[string]appTarget
 }
 
 type upgradeDependencies struct {
 	runner    goversion.Runner
 	ghClient  github.Client
+	releases  release.Hosts
+	vanity    vanity.Resolver
 	resolver  gomodule.Resolver
+	verifier  gomodule.Verifier
 	installer installer.Installer
-	out       *output.Writer
-	errOut    *output.Writer
+	// crossRunner and crossInstaller serve cross-compiled apps; runner and
+	// installer serve native ones.
+	crossRunner    func(appTarget) goversion.Runner
+	crossInstaller func(appTarget) installer.Installer
+	out            *output.Writer
+	errOut         *output.Writer
+}
+
+// runnerFor returns the runner that inspects binaries built for target.
+func (d upgradeDependencies) runnerFor(target appTarget) goversion.Runner {
+	if target.platform.IsZero() || d.crossRunner == nil {
+		return d.runner
+	}
+	return d.crossRunner(target)
+}
+
+// installerFor returns the installer that builds binaries for target.
+func (d upgradeDependencies) installerFor(target appTarget) installer.Installer {
+	if target.platform.IsZero() || d.crossInstaller == nil {
+		return d.installer
+	}
+	return d.crossInstaller(target)
 }
 
 type updateResult struct {
 	latestVersion   string
 	updateAvailable bool
+	// status reports whether the installed version is retracted and whether
+	// the module is deprecated.
+	status gomodule.Status
+	// retractedRelease is the latest release when its author retracted it,
+	// so that it was not offered, and retractedRationale the reason given.
+	retractedRelease   string
+	retractedRationale string
 }
 
 func parseUpgradeOptions(args []string, stderr io.Writer) (upgradeOptions, error) {
 	fs := flag.NewFlagSet("upgrade", flag.ContinueOnError)
 	fs.SetOutput(stderr)
 
-	verboseFlag := fs.Bool("verbose", false, "Show binaries that are already up to date")
+	var opts upgradeOptions
+	fs.BoolVar(&opts.Verbose, "verbose", false, "Show binaries that are already up to date")
+	addTargetFlags(fs, &opts.targetOptions)
 	if err := fs.Parse(args); err != nil {
 		return upgradeOptions{}, err
 	}
 
-	return upgradeOptions{Verbose: *verboseFlag}, nil
+	return opts, nil
 }
 
 func runUpgrade(args []string) {
@@ -55,8 +95,10 @@ func runUpgrade(args []string) {
 		os.Exit(2)
 	}
 
-	cfgPath := config.DefaultPath()
-	cfg, err := config.Load(cfgPath)
+	defer lockState()()
+
+	cfgPath := configPath()
+	cfg, err := config.LoadProfile(cfgPath, profileName())
 	if err != nil {
 		output.Error(fmt.Sprintf("Failed to load config: %v", err))
 		os.Exit(1)
@@ -67,23 +109,33 @@ func runUpgrade(args []string) {
 		return
 	}
 
-	cachePath := cache.DefaultPath()
-	c, err := cache.Load(cachePath)
+	cachePath := cacheFilePath()
+	c, err := loadCache(cachePath)
 	if err != nil {
 		output.Error(fmt.Sprintf("Failed to load cache: %v", err))
 		os.Exit(1)
 	}
 
-	runner := &goversion.DefaultRunner{}
-	ghClient := github.NewDefaultClient(github.ResolveToken(cfg.GitHubAuth))
-	inst := installer.NewDefaultInstallerWithOptions(cfg.GOPROXY, cfg.CGOEnabled)
+	opts.targets, err = resolveAppTargets(opts.targetOptions, cfg)
+	if err != nil {
+		output.Error(fmt.Sprintf("Failed to resolve target platform: %v", err))
+		os.Exit(1)
+	}
+
 	deps := upgradeDependencies{
-		runner:    runner,
-		ghClient:  ghClient,
-		resolver:  gomodule.NewDefaultResolverWithGOPROXY(cfg.GOPROXY),
-		installer: inst,
-		out:       output.DefaultWriter,
-		errOut:    output.ErrorWriter,
+		runner:      &goversion.DefaultRunner{},
+		ghClient:    newGitHubReleaseClient(cfg, c),
+		releases:    newReleaseHosts(cfg, c),
+		vanity:      newVanityResolver(cfg, c),
+		resolver:    newModuleResolver(cfg),
+		verifier:    newModuleVerifier(cfg),
+		installer:   newTargetInstaller(cfg, appTarget{}),
+		crossRunner: newTargetRunner,
+		crossInstaller: func(target appTarget) installer.Installer {
+			return newTargetInstaller(cfg, target)
+		},
+		out:    output.DefaultWriter,
+		errOut: output.ErrorWriter,
 	}
 	updated := runUpgradeApps(cfg, c, opts, deps)
 
@@ -100,66 +152,142 @@ func runUpgrade(args []string) {
 
 func runUpgradeApps(cfg *config.Config, c *cache.Cache, opts upgradeOptions, deps upgradeDependencies) int {
 	updated := 0
+	rateLimited := false
 
-	for _, app := range cfg.Apps {
-		info, err := deps.runner.GetInfo(app.Name)
+	// Read every binary first so that a batching GitHub client can look up
+	// all of their latest releases at once.
+	infos := make([]*goversion.Info, len(cfg.Apps))
+	var modulePaths []string
+	for i, app := range cfg.Apps {
+		target := opts.targets[app.Name]
+		info, err := deps.runnerFor(target).GetInfo(app.Name)
 		if err != nil {
-			deps.out.Warn(fmt.Sprintf("Could not get info for '%s': %v", app.Name, err))
+			key := target.key(app.Name)
+			deps.out.Warn(fmt.Sprintf("Could not get info for '%s': %v", key, err))
 			continue
 		}
+		infos[i] = info
+		modulePaths = append(modulePaths, info.Path)
+	}
+	prefetchReleases(deps.ghClient, modulePaths)
+
+	// Find every update before installing any, so that binaries whose
+	// installed version was retracted are moved off it first.
+	type pendingUpgrade struct {
+		app    config.App
+		target appTarget
+		info   *goversion.Info
+		key    string
+		result updateResult
+	}
+	var pending []pendingUpgrade
+	for i, app := range cfg.Apps {
+		info := infos[i]
+		if info == nil {
+			continue
+		}
+		target := opts.targets[app.Name]
+		key := target.key(app.Name)
 
 		// Always perform a fresh update check (ignore cache).
-		result, err := checkForUpdate(info.Path, info.Version, deps.ghClient, deps.resolver)
+		result, err := checkForUpdate(info.Path, info.Version, releaseSources{deps.ghClient, deps.releases, deps.vanity}, deps.resolver)
+		var limitErr *github.RateLimitError
+		if errors.As(err, &limitErr) {
+			// Later GitHub lookups fail the same way, so warn only once.
+			if !rateLimited {
+				deps.out.Warn(fmt.Sprintf("Skipping GitHub modules: %s", rateLimitWarning(cfg, limitErr)))
+				rateLimited = true
+			}
+			continue
+		}
 		if err != nil {
-			deps.out.Warn(fmt.Sprintf("Could not fetch latest version for '%s': %v", app.Name, err))
+			deps.out.Warn(fmt.Sprintf("Could not fetch latest version for '%s': %v", key, err))
 			continue
 		}
 
-		cache.SetForInstalledVersion(c, app.Name, info.Version, result.latestVersion)
+		cache.SetForInstalledVersion(c, key, info.Version, result.latestVersion)
+		recordModuleStatus(c, key, info.Path, result.status)
+		recordBinary(c, key, info)
+		if result.status.Deprecated != "" {
+			deps.out.Warn(fmt.Sprintf("'%s' is deprecated: %s", key, result.status.Deprecated))
+		}
+		if result.retractedRelease != "" {
+			deps.out.Warn(retractedReleaseWarning(key, result.retractedRelease, result.retractedRationale))
+		}
 
 		if !result.updateAvailable {
-			if opts.Verbose {
-				deps.out.Info(upgradeUpToDateMessage(app.Name, info.Version))
+			if result.status.Retracted {
+				deps.out.Warn(retractionWarning(key, info.Version, result.status.Rationale) + "; no other version is available")
+			} else if opts.Verbose {
+				deps.out.Info(upgradeUpToDateMessage(key, info.Version))
 			}
 			continue
 		}
+		pending = append(pending, pendingUpgrade{app: app, target: target, info: info, key: key, result: result})
+	}
+	slices.SortStableFunc(pending, func(a, b pendingUpgrade) int {
+		switch {
+		case a.result.status.Retracted == b.result.status.Retracted:
+			return 0
+		case a.result.status.Retracted:
+			return -1
+		}
+		return 1
+	})
+
+	for _, p := range pending {
+		key, info, result := p.key, p.info, p.result
+		if result.status.Retracted {
+			deps.out.Warn(retractionWarning(key, info.Version, result.status.Rationale))
+		}
+
+		if deps.verifier != nil {
+			v := deps.verifier.Verify(info.Path, result.latestVersion)
+			switch v.Status {
+			case gomodule.VerifyMismatch:
+				deps.errOut.Error(fmt.Sprintf("Refusing to upgrade '%s' to %s: checksum mismatch: %s", key, result.latestVersion, v.Detail))
+				continue
+			case gomodule.VerifyFailed:
+				deps.out.Warn(fmt.Sprintf("Could not verify '%s' %s against the checksum database: %s", key, result.latestVersion, v.Detail))
+			}
+		}
 
-		deps.out.StartProgress(upgradeProgressMessage(app.Name, info.Version, result.latestVersion))
+		deps.out.StartProgress(upgradeProgressMessage(key, info.Version, result.latestVersion))
 
-		installPath := app.InstallPath
+		installPath := p.app.InstallPath
 		if installPath == "" {
 			installPath = info.PackagePath
 		}
 		if installPath == "" {
 			installPath = info.Path
 		}
-		_, err = deps.installer.Install(installPath, result.latestVersion)
+		_, err := deps.installerFor(p.target).Install(installPath, result.latestVersion)
 		if err != nil {
-			deps.errOut.Error(fmt.Sprintf("Failed to upgrade '%s': %v", app.Name, err))
+			deps.errOut.Error(fmt.Sprintf("Failed to upgrade '%s': %v", key, err))
 			continue
 		}
 
-		deps.out.Success(upgradeSuccessMessage(app.Name, result.latestVersion))
+		deps.out.Success(upgradeSuccessMessage(key, result.latestVersion))
 		updated++
 	}
 
 	return updated
 }
 
-func checkForUpdate(modulePath, installedVersion string, ghClient github.Client, resolver gomodule.Resolver) (updateResult, error) {
-	if goversion.IsGitHubRepo(modulePath) {
-		owner, repo, err := goversion.ParseGitHubRepo(modulePath)
+func checkForUpdate(modulePath, installedVersion string, sources releaseSources, resolver gomodule.Resolver) (updateResult, error) {
+	if provider, kind, host := releaseProviderFor(modulePath, sources.ghClient, sources.hosts); provider != nil {
+		owner, repo, err := release.ParseRepoPath(kind, modulePath, host)
 		if err != nil {
 			return updateResult{}, err
 		}
-		latest, err := ghClient.GetLatestRelease(owner, repo)
+		latest, err := provider.GetLatestRelease(owner, repo)
 		if err != nil {
 			return updateResult{}, err
 		}
-		return updateResult{
-			latestVersion:   latest,
-			updateAvailable: installedVersion != latest,
-		}, nil
+		return withModuleStatus(modulePath, installedVersion, latest, resolver), nil
+	}
+	if latest, ok := vanityRelease(modulePath, sources); ok {
+		return withModuleStatus(modulePath, installedVersion, latest, resolver), nil
 	}
 	result, err := resolver.Check(modulePath, installedVersion)
 	if err != nil {
@@ -168,9 +296,57 @@ func checkForUpdate(modulePath, installedVersion string, ghClient github.Client,
 	return updateResult{
 		latestVersion:   result.LatestVersion,
 		updateAvailable: result.UpdateAvailable,
+		status:          result.Status,
 	}, nil
 }
 
+// withModuleStatus returns the update result for a latest release found
+// outside the module proxy, with the retraction and deprecation status the
+// resolver reports for the installed version. A latest release that its
+// author retracted is not offered: the latest version that is not retracted
+// is, when it is newer than the installed version or the installed version is
+// retracted too. A retracted installed version that is also the latest
+// release is replaced the same way. Status lookups that fail leave the status
+// unknown.
+func withModuleStatus(modulePath, installedVersion, latest string, resolver gomodule.Resolver) updateResult {
+	result := updateResult{latestVersion: latest, updateAvailable: installedVersion != latest}
+	checker, ok := resolver.(gomodule.StatusChecker)
+	if !ok {
+		return result
+	}
+	status, err := checker.Status(modulePath, installedVersion)
+	if err != nil {
+		return result
+	}
+	result.status = status
+	if result.updateAvailable {
+		if target, err := checker.Status(modulePath, latest); err == nil && target.Retracted {
+			result.retractedRelease, result.retractedRationale = latest, target.Rationale
+			replacement := target.Replacement
+			if replacement != "" && replacement != installedVersion && (status.Retracted || semver.Compare(replacement, installedVersion) > 0) {
+				result.latestVersion = replacement
+			} else {
+				result.latestVersion, result.updateAvailable = installedVersion, false
+			}
+		}
+	}
+	if status.Retracted && !result.updateAvailable && status.Replacement != "" && status.Replacement != installedVersion {
+		result.latestVersion = status.Replacement
+		result.updateAvailable = true
+	}
+	return result
+}
+
+// retractedReleaseWarning explains that the latest release of an app was
+// skipped because its author retracted it.
+func retractedReleaseWarning(name, version, rationale string) string {
+	msg := fmt.Sprintf("Skipping %s of '%s': retracted by its author", version, name)
+	if rationale != "" {
+		msg += ": " + rationale
+	}
+	return msg
+}
+
 func upgradeUpToDateMessage(name, version string) string {
 	return fmt.Sprintf("'%s' is already up to date (%s)", name, installedVersion(version))
 }
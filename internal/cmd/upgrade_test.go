@@ -10,6 +10,7 @@ import (
 	"github.com/UnitVectorY-Labs/gogitup/internal/config"
 	"github.com/UnitVectorY-Labs/gogitup/internal/gomodule"
 	"github.com/UnitVectorY-Labs/gogitup/internal/goversion"
+	"github.com/UnitVectorY-Labs/gogitup/internal/installer"
 	"github.com/UnitVectorY-Labs/gogitup/internal/output"
 )
 
@@ -84,6 +85,17 @@ func (s *stubModuleResolver) Check(modulePath, installedVersion string) (gomodul
 	return result, nil
 }
 
+type stubVerifier struct {
+	results map[string]gomodule.Verification
+}
+
+func (s *stubVerifier) Verify(modulePath, version string) gomodule.Verification {
+	if v, ok := s.results[modulePath+"@"+version]; ok {
+		return v
+	}
+	return gomodule.Verification{Status: gomodule.VerifyOK}
+}
+
 func (s *stubInstaller) Install(modulePath, version string) (string, error) {
 	s.calls = append(s.calls, installCall{modulePath: modulePath, version: version})
 	if s.err != nil {
@@ -344,3 +356,248 @@ func TestRunUpgradeAppsDoesNotDowngradeNonGitHubModule(t *testing.T) {
 		t.Fatalf("unexpected resolver calls: %+v", resolver.calls)
 	}
 }
+
+func TestParseUpgradeOptionsTarget(t *testing.T) {
+	var stderr bytes.Buffer
+
+	opts, err := parseUpgradeOptions([]string{"--os", "linux", "--arch", "arm64", "--bin-dir", "/out"}, &stderr)
+	if err != nil {
+		t.Fatalf("parseUpgradeOptions returned error: %v", err)
+	}
+	if opts.GOOS != "linux" || opts.GOARCH != "arm64" || opts.BinDir != "/out" {
+		t.Fatalf("unexpected target options: %+v", opts.targetOptions)
+	}
+}
+
+func TestRunUpgradeAppsCachesPerPlatform(t *testing.T) {
+	cfg := &config.Config{Apps: []config.App{{Name: "tool"}, {Name: "native"}}}
+	c := &cache.Cache{Entries: map[string]cache.Entry{}}
+	crossRunner := &stubRunner{
+		infos: map[string]*goversion.Info{
+			"tool": {Path: "github.com/acme/tool", Version: "v1.0.0"},
+		},
+	}
+	nativeRunner := &stubRunner{
+		infos: map[string]*goversion.Info{
+			"native": {Path: "github.com/acme/native", Version: "v2.0.0"},
+		},
+	}
+	ghClient := &stubGitHubClient{releases: map[string]string{"acme/tool": "v1.1.0", "acme/native": "v2.1.0"}}
+	crossInst := &stubInstaller{}
+	nativeInst := &stubInstaller{}
+	arm64 := appTarget{platform: installer.Target{GOOS: "linux", GOARCH: "arm64"}, binDir: "/out"}
+
+	var targets []appTarget
+	updated := runUpgradeApps(cfg, c, upgradeOptions{targets: map[string]appTarget{"tool": arm64}}, upgradeDependencies{
+		runner:    nativeRunner,
+		ghClient:  ghClient,
+		installer: nativeInst,
+		crossRunner: func(target appTarget) goversion.Runner {
+			targets = append(targets, target)
+			return crossRunner
+		},
+		crossInstaller: func(target appTarget) installer.Installer {
+			targets = append(targets, target)
+			return crossInst
+		},
+		out:    &output.Writer{Out: &bytes.Buffer{}},
+		errOut: &output.Writer{Out: &bytes.Buffer{}},
+	})
+
+	if updated != 2 {
+		t.Fatalf("expected 2 updated binaries, got %d", updated)
+	}
+	if len(crossInst.calls) != 1 || crossInst.calls[0].modulePath != "github.com/acme/tool" {
+		t.Fatalf("unexpected cross-compiled installs: %+v", crossInst.calls)
+	}
+	if len(nativeInst.calls) != 1 || nativeInst.calls[0].modulePath != "github.com/acme/native" {
+		t.Fatalf("unexpected native installs: %+v", nativeInst.calls)
+	}
+	for _, target := range targets {
+		if target != arm64 {
+			t.Fatalf("cross-compiled deps requested for %+v, want %+v", target, arm64)
+		}
+	}
+	if _, ok := c.Entries["tool"]; ok {
+		t.Fatal("expected no native cache entry for a cross-compiled upgrade")
+	}
+	entry, ok := c.Entries["tool@linux/arm64"]
+	if !ok || entry.LatestVersion != "v1.1.0" || entry.InstalledVersion != "v1.0.0" {
+		t.Fatalf("unexpected platform cache entry: %+v (found=%t)", entry, ok)
+	}
+	if _, ok := c.Entries["native"]; !ok {
+		t.Fatal("expected a native cache entry for the native app")
+	}
+}
+
+func TestRunUpgradeAppsRefusesChecksumMismatch(t *testing.T) {
+	cfg := &config.Config{
+		Apps: []config.App{
+			{Name: "tampered"},
+			{Name: "unverified"},
+		},
+	}
+	c := &cache.Cache{Entries: map[string]cache.Entry{}}
+	runner := &stubRunner{
+		infos: map[string]*goversion.Info{
+			"tampered":   {Path: "github.com/acme/tampered", Version: "v1.0.0"},
+			"unverified": {Path: "github.com/acme/unverified", Version: "v1.0.0"},
+		},
+	}
+	ghClient := &stubGitHubClient{
+		releases: map[string]string{
+			"acme/tampered":   "v1.1.0",
+			"acme/unverified": "v1.1.0",
+		},
+	}
+	verifier := &stubVerifier{results: map[string]gomodule.Verification{
+		"github.com/acme/tampered@v1.1.0":   {Status: gomodule.VerifyMismatch, Detail: "module zip hash h1:a= does not match h1:b="},
+		"github.com/acme/unverified@v1.1.0": {Status: gomodule.VerifyFailed, Detail: "sum.golang.org unreachable"},
+	}}
+	installer := &stubInstaller{}
+	var stdout bytes.Buffer
+	var stderr bytes.Buffer
+
+	updated := runUpgradeApps(cfg, c, upgradeOptions{}, upgradeDependencies{
+		runner:    runner,
+		ghClient:  ghClient,
+		verifier:  verifier,
+		installer: installer,
+		out:       &output.Writer{Out: &stdout},
+		errOut:    &output.Writer{Out: &stderr},
+	})
+
+	if updated != 1 {
+		t.Fatalf("expected 1 updated binary, got %d", updated)
+	}
+	if len(installer.calls) != 1 || installer.calls[0].modulePath != "github.com/acme/unverified" {
+		t.Fatalf("expected only the unverified binary to be installed, got %+v", installer.calls)
+	}
+	if !strings.Contains(stderr.String(), "Refusing to upgrade 'tampered' to v1.1.0: checksum mismatch") {
+		t.Fatalf("expected refusal on stderr, got %q", stderr.String())
+	}
+	if !strings.Contains(stdout.String(), "Could not verify 'unverified' v1.1.0") {
+		t.Fatalf("expected verification warning, got %q", stdout.String())
+	}
+}
+
+// stubStatusResolver is a module resolver that also reports retraction and
+// deprecation, as the proxy resolver does.
+type stubStatusResolver struct {
+	stubModuleResolver
+	statuses map[string]gomodule.Status
+}
+
+func (s *stubStatusResolver) Status(modulePath, version string) (gomodule.Status, error) {
+	status, ok := s.statuses[modulePath+"@"+version]
+	if !ok {
+		return gomodule.Status{}, errors.New("status not found")
+	}
+	return status, nil
+}
+
+func TestRunUpgradeAppsMovesOffRetractedVersionsFirst(t *testing.T) {
+	cfg := &config.Config{
+		Apps: []config.App{
+			{Name: "stale"},
+			{Name: "retracted"},
+			{Name: "released"},
+		},
+	}
+	c := &cache.Cache{Entries: map[string]cache.Entry{}}
+	runner := &stubRunner{
+		infos: map[string]*goversion.Info{
+			"stale":     {Path: "example.com/stale", Version: "v1.0.0"},
+			"retracted": {Path: "example.com/retracted", Version: "v2.0.0"},
+			"released":  {Path: "github.com/acme/released", Version: "v1.5.0"},
+		},
+	}
+	ghClient := &stubGitHubClient{releases: map[string]string{"acme/released": "v1.5.0"}}
+	resolver := &stubStatusResolver{
+		stubModuleResolver: stubModuleResolver{results: map[string]gomodule.Result{
+			"example.com/stale@v1.0.0": {LatestVersion: "v1.1.0", UpdateAvailable: true},
+			// The installed version is ahead of every release, so only its
+			// retraction moves it, back to v1.9.0.
+			"example.com/retracted@v2.0.0": {LatestVersion: "v1.9.0", UpdateAvailable: true, Status: gomodule.Status{
+				Retracted: true, Rationale: "Tagged by mistake.", Replacement: "v1.9.0",
+			}},
+		}},
+		statuses: map[string]gomodule.Status{
+			// The latest GitHub release is itself retracted.
+			"github.com/acme/released@v1.5.0": {Retracted: true, Replacement: "v1.4.2"},
+		},
+	}
+	installer := &stubInstaller{}
+	var stdout bytes.Buffer
+
+	updated := runUpgradeApps(cfg, c, upgradeOptions{}, upgradeDependencies{
+		runner:    runner,
+		ghClient:  ghClient,
+		resolver:  resolver,
+		installer: installer,
+		out:       &output.Writer{Out: &stdout},
+		errOut:    &output.Writer{Out: &bytes.Buffer{}},
+	})
+
+	if updated != 3 {
+		t.Fatalf("expected 3 updated binaries, got %d", updated)
+	}
+	want := []installCall{
+		{modulePath: "example.com/retracted", version: "v1.9.0"},
+		{modulePath: "github.com/acme/released", version: "v1.4.2"},
+		{modulePath: "example.com/stale", version: "v1.1.0"},
+	}
+	if len(installer.calls) != len(want) {
+		t.Fatalf("expected install calls %+v, got %+v", want, installer.calls)
+	}
+	for i := range want {
+		if installer.calls[i] != want[i] {
+			t.Fatalf("expected install calls %+v, got %+v", want, installer.calls)
+		}
+	}
+	if !strings.Contains(stdout.String(), "'retracted' v2.0.0 has been retracted by its author: Tagged by mistake.") {
+		t.Fatalf("expected retraction warning, got %q", stdout.String())
+	}
+	if entry := c.Entries["retracted"]; !entry.Retracted || entry.RetractionRationale != "Tagged by mistake." {
+		t.Fatalf("expected retraction to be cached, got %+v", entry)
+	}
+}
+
+func TestWithModuleStatusSkipsRetractedRelease(t *testing.T) {
+	resolver := &stubStatusResolver{statuses: map[string]gomodule.Status{
+		"example.com/tool@v1.5.0": {Retracted: true, Rationale: "Broken build.", Replacement: "v1.4.2"},
+		"example.com/tool@v1.1.0": {Retracted: true, Replacement: "v1.4.2"},
+		"example.com/tool@v1.0.0": {},
+		"example.com/tool@v1.4.2": {},
+		"example.com/tool@v1.4.3": {},
+		"example.com/old@v2.0.0":  {Retracted: true, Replacement: "v1.9.0"},
+		"example.com/old@v1.0.0":  {Retracted: true, Replacement: "v1.9.0"},
+	}}
+	tests := []struct {
+		modulePath, installed, latest string
+		wantLatest                    string
+		wantUpdate                    bool
+	}{
+		{"example.com/tool", "v1.0.0", "v1.5.0", "v1.4.2", true},
+		{"example.com/tool", "v1.4.2", "v1.5.0", "v1.4.2", false},
+		{"example.com/tool", "v1.4.3", "v1.5.0", "v1.4.3", false},
+		// A retracted installed version moves to the replacement even when it is lower.
+		{"example.com/old", "v1.0.0", "v2.0.0", "v1.9.0", true},
+		{"example.com/tool", "v1.1.0", "v1.5.0", "v1.4.2", true},
+		{"example.com/tool", "v1.0.0", "v1.6.0", "v1.6.0", true},
+	}
+	for _, tt := range tests {
+		result := withModuleStatus(tt.modulePath, tt.installed, tt.latest, resolver)
+		if result.latestVersion != tt.wantLatest || result.updateAvailable != tt.wantUpdate {
+			t.Errorf("withModuleStatus(%s, %s, %s) = %s, %t; want %s, %t", tt.modulePath, tt.installed, tt.latest,
+				result.latestVersion, result.updateAvailable, tt.wantLatest, tt.wantUpdate)
+		}
+		wantSkipped := ""
+		if tt.latest != "v1.6.0" {
+			wantSkipped = tt.latest
+		}
+		if result.retractedRelease != wantSkipped {
+			t.Errorf("withModuleStatus(%s, %s, %s) skipped %q, want %q", tt.modulePath, tt.installed, tt.latest, result.retractedRelease, wantSkipped)
+		}
+	}
+}
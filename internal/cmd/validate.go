@@ -0,0 +1,101 @@
+package cmd
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/UnitVectorY-Labs/gogitup/internal/config"
+	"github.com/UnitVectorY-Labs/gogitup/internal/github"
+	"github.com/UnitVectorY-Labs/gogitup/internal/notify"
+	"github.com/UnitVectorY-Labs/gogitup/internal/release"
+)
+
+func init() {
+	config.RegisterCheck(providerProblems)
+}
+
+// providerProblems checks the settings whose allowed values are defined by
+// the github, release, and notify packages: the token sources, the release
+// providers, and the notifiers.
+func providerProblems(cfg *config.Config) []string {
+	var problems []string
+	problems = append(problems, validateTokenSources("github_token", cfg.GitHubToken)...)
+	for _, name := range config.ProfileNames(cfg) {
+		problems = append(problems, validateTokenSources("profiles."+name+".github_token", cfg.Profiles[name].GitHubToken)...)
+	}
+	for i, h := range cfg.GitHubHosts {
+		problems = append(problems, validateTokenSources(fmt.Sprintf("github_hosts[%d].token", i), h.Token)...)
+	}
+	problems = append(problems, validateReleaseProviders(cfg.ReleaseHosts)...)
+	problems = append(problems, validateNotifiers(cfg.Notifiers)...)
+	return problems
+}
+
+// validateTokenSources checks the token settings at key, such as
+// "github_token".
+func validateTokenSources(key string, t *config.TokenSettings) []string {
+	if t == nil {
+		return nil
+	}
+	var problems []string
+	seen := make(map[string]bool, len(t.Sources))
+	for i, source := range t.Sources {
+		field := fmt.Sprintf("%s.sources[%d]", key, i)
+		switch {
+		case !slices.Contains(github.TokenSources, source):
+			problems = append(problems, fmt.Sprintf("%s: expected one of %s, got %q", field, strings.Join(github.TokenSources, ", "), source))
+		case seen[source]:
+			problems = append(problems, fmt.Sprintf("%s: duplicate source %q", field, source))
+		case source == github.SourceFile && t.File == "":
+			problems = append(problems, fmt.Sprintf("%s: %s.file is required for the file source", field, key))
+		case source == github.SourceCommand && t.Command == "":
+			problems = append(problems, fmt.Sprintf("%s: %s.command is required for the command source", field, key))
+		}
+		seen[source] = true
+	}
+	return problems
+}
+
+func validateReleaseProviders(hosts []config.ReleaseHost) []string {
+	var problems []string
+	for i, h := range hosts {
+		if !slices.Contains(release.Kinds, h.Provider) {
+			problems = append(problems, fmt.Sprintf("release_hosts[%d].provider: expected one of %s, got %q", i, strings.Join(release.Kinds, ", "), h.Provider))
+		}
+	}
+	return problems
+}
+
+func validateNotifiers(notifiers []config.Notifier) []string {
+	var problems []string
+	for i, n := range notifiers {
+		field := fmt.Sprintf("notifiers[%d]", i)
+		if !slices.Contains(notify.Kinds, n.Type) {
+			problems = append(problems, fmt.Sprintf("%s.type: expected one of %s, got %q", field, strings.Join(notify.Kinds, ", "), n.Type))
+			continue
+		}
+		if n.Type == notify.KindDesktop {
+			if n.URL != "" || n.URLEnv != "" || n.Body != "" || len(n.Headers) > 0 {
+				problems = append(problems, field+": desktop notifiers take no url, url_env, body, or headers")
+			}
+			continue
+		}
+		switch {
+		case n.URL == "" && n.URLEnv == "":
+			problems = append(problems, field+": url or url_env is required")
+		case n.URL != "" && n.URLEnv != "":
+			problems = append(problems, field+": url and url_env cannot both be set")
+		case n.URL != "" && !config.IsHTTPURL(n.URL):
+			problems = append(problems, fmt.Sprintf("%s.url: invalid URL %q", field, n.URL))
+		}
+		if n.Body != "" {
+			if n.Type != notify.KindWebhook {
+				problems = append(problems, fmt.Sprintf("%s.body: only webhook notifiers take a body, not %s", field, n.Type))
+			} else if _, err := notify.ParseBody(n.Body); err != nil {
+				problems = append(problems, fmt.Sprintf("%s.body: %v", field, err))
+			}
+		}
+	}
+	return problems
+}
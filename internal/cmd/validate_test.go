@@ -0,0 +1,62 @@
+package cmd
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/UnitVectorY-Labs/gogitup/internal/config"
+)
+
+func TestProviderProblems(t *testing.T) {
+	cfg := &config.Config{
+		GitHubToken: &config.TokenSettings{Sources: []string{"env", "vault", "file"}},
+		GitHubHosts: []config.GitHubHost{
+			{Host: "github.corp.example", Token: &config.TokenSettings{Sources: []string{"command"}}},
+		},
+		Profiles: map[string]*config.Profile{
+			"work": {GitHubToken: &config.TokenSettings{Sources: []string{"env", "env"}}},
+		},
+		ReleaseHosts: []config.ReleaseHost{
+			{Host: "gitlab.corp.example", Provider: "gitlab"},
+			{Host: "git.example.org", Provider: "svn"},
+		},
+		Notifiers: []config.Notifier{
+			{Type: "desktop"},
+			{Type: "email"},
+			{Type: "slack"},
+			{Type: "teams", URL: "https://example.com", URLEnv: "TEAMS_URL"},
+			{Type: "slack", URLEnv: "SLACK_URL", Body: "{}"},
+			{Type: "webhook", URL: "https://example.com/hook", Body: "{{.Title"},
+			{Type: "desktop", URL: "https://example.com"},
+		},
+	}
+
+	problems := providerProblems(cfg)
+
+	want := []string{
+		`github_token.sources[1]: expected one of env, file, command, gh, netrc, keyring, got "vault"`,
+		"github_token.sources[2]: github_token.file is required for the file source",
+		`profiles.work.github_token.sources[1]: duplicate source "env"`,
+		"github_hosts[0].token.sources[0]: github_hosts[0].token.command is required for the command source",
+		`release_hosts[1].provider: expected one of gitlab, gitea, forgejo, bitbucket, got "svn"`,
+		`notifiers[1].type: expected one of desktop, webhook, slack, teams, got "email"`,
+		"notifiers[2]: url or url_env is required",
+		"notifiers[3]: url and url_env cannot both be set",
+		"notifiers[4].body: only webhook notifiers take a body, not slack",
+		"notifiers[5].body: invalid body template",
+		"notifiers[6]: desktop notifiers take no url",
+	}
+	if len(problems) != len(want) {
+		t.Fatalf("expected %d problems, got %d: %q", len(want), len(problems), problems)
+	}
+	for i, prefix := range want {
+		if !strings.HasPrefix(problems[i], prefix) {
+			t.Errorf("problem %d = %q, want prefix %q", i, problems[i], prefix)
+		}
+	}
+
+	// The checks run whenever the config package validates a config.
+	if got := config.Validate(cfg); len(got) != len(want) {
+		t.Fatalf("config.Validate() = %q, want the provider problems", got)
+	}
+}
@@ -1,10 +1,19 @@
 package config
 
 import (
+	"bytes"
 	"errors"
+	"fmt"
+	"io"
 	"os"
 	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
 
+	"github.com/UnitVectorY-Labs/gogitup/internal/fileutil"
+	"github.com/UnitVectorY-Labs/gogitup/internal/xdg"
 	"gopkg.in/yaml.v3"
 )
 
@@ -12,6 +21,12 @@ import (
 type App struct {
 	Name        string `yaml:"name"`
 	InstallPath string `yaml:"install_path,omitempty"`
+	CacheTTL    string `yaml:"cache_ttl,omitempty"`
+	// GOOS, GOARCH, and CrossBinDir record the platform a cross-compiled app
+	// was installed for and where. They override the profile's settings.
+	GOOS        string `yaml:"goos,omitempty"`
+	GOARCH      string `yaml:"goarch,omitempty"`
+	CrossBinDir string `yaml:"cross_bin_dir,omitempty"`
 }
 
 // Config represents the gogitup configuration file.
@@ -20,15 +35,147 @@ type Config struct {
 	GitHubAuth bool   `yaml:"github_auth"`
 	GOPROXY    string `yaml:"goproxy,omitempty"`
 	CGOEnabled *bool  `yaml:"cgo_enabled,omitempty"`
+	CacheTTL   string `yaml:"cache_ttl,omitempty"`
+	// GOOS and GOARCH select a default target platform for install and
+	// upgrade. CrossBinDir is where cross-compiled binaries are placed.
+	GOOS        string `yaml:"goos,omitempty"`
+	GOARCH      string `yaml:"goarch,omitempty"`
+	CrossBinDir string `yaml:"cross_bin_dir,omitempty"`
+	// HTTPTimeout bounds each network request and Retries is how many times
+	// transient failures are retried. Both apply to every profile.
+	HTTPTimeout string `yaml:"http_timeout,omitempty"`
+	Retries     *int   `yaml:"retries,omitempty"`
+	// HTTPSProxy, CABundle, ClientCert, and ClientKey configure how network
+	// requests leave a corporate network. They apply to every profile.
+	HTTPSProxy   string         `yaml:"https_proxy,omitempty"`
+	CABundle     string         `yaml:"ca_bundle,omitempty"`
+	ClientCert   string         `yaml:"client_cert,omitempty"`
+	ClientKey    string         `yaml:"client_key,omitempty"`
+	GitHubToken  *TokenSettings `yaml:"github_token,omitempty"`
+	GitHubHosts  []GitHubHost   `yaml:"github_hosts,omitempty"`
+	ReleaseHosts []ReleaseHost  `yaml:"release_hosts,omitempty"`
+	// Notifiers are told about new updates found by check --notify. They
+	// apply to every profile.
+	Notifiers []Notifier          `yaml:"notifiers,omitempty"`
+	Profiles  map[string]*Profile `yaml:"profiles,omitempty"`
+
+	// file and profile are set on the effective config returned by
+	// SelectProfile so that Save writes changes back into the profile.
+	file    *Config
+	profile string
+}
+
+// TokenSettings configure where a GitHub token is looked up: the github.com
+// token when github_auth is enabled, or the token of a GitHub Enterprise host.
+type TokenSettings struct {
+	// Sources lists the token sources to try, in order. When empty, every
+	// source is tried in the default order.
+	Sources []string `yaml:"sources,omitempty"`
+	// File is a file holding the token.
+	File string `yaml:"file,omitempty"`
+	// Command is a credential helper command that prints the token.
+	Command string `yaml:"command,omitempty"`
+	// KeyringService is the OS keyring service the token is stored under.
+	KeyringService string `yaml:"keyring_service,omitempty"`
+}
+
+// GitHubHost maps the host of module paths, such as "github.corp.example", to
+// a GitHub Enterprise API used for release lookups.
+type GitHubHost struct {
+	Host string `yaml:"host"`
+	// APIURL defaults to https://<host>/api/v3.
+	APIURL string `yaml:"api_url,omitempty"`
+	// TokenEnv names the environment variable holding the host's token. It is
+	// read before the other token sources.
+	TokenEnv string `yaml:"token_env,omitempty"`
+	// Token configures the other sources the host's token is looked up in.
+	Token *TokenSettings `yaml:"token,omitempty"`
+}
+
+// ReleaseHost maps the host of module paths to a GitLab, Gitea, Forgejo, or
+// Bitbucket server used for release lookups.
+type ReleaseHost struct {
+	Host     string `yaml:"host"`
+	Provider string `yaml:"provider"`
+	// APIURL defaults to https://<host>.
+	APIURL string `yaml:"api_url,omitempty"`
+	// TokenEnv names the environment variable holding the host's token and
+	// defaults to GITLAB_TOKEN, GITEA_TOKEN, or BITBUCKET_TOKEN.
+	TokenEnv string `yaml:"token_env,omitempty"`
+}
+
+// Notifier is a desktop notification or a webhook that check --notify
+// reports new updates to.
+type Notifier struct {
+	// Type is desktop, webhook, slack, or teams.
+	Type string `yaml:"type"`
+	// URL is the webhook URL. URLEnv names an environment variable holding
+	// it instead, which keeps secret URLs out of the config file.
+	URL    string `yaml:"url,omitempty"`
+	URLEnv string `yaml:"url_env,omitempty"`
+	// Body is a text/template for the JSON body of a generic webhook.
+	Body string `yaml:"body,omitempty"`
+	// Headers are added to webhook requests.
+	Headers map[string]string `yaml:"headers,omitempty"`
+}
+
+// Profile is a named set of apps with its own settings. Settings that are not
+// set in the profile inherit the top-level values.
+type Profile struct {
+	Apps        []App  `yaml:"apps"`
+	GitHubAuth  *bool  `yaml:"github_auth,omitempty"`
+	GOPROXY     string `yaml:"goproxy,omitempty"`
+	CGOEnabled  *bool  `yaml:"cgo_enabled,omitempty"`
+	CacheTTL    string `yaml:"cache_ttl,omitempty"`
+	GOOS        string `yaml:"goos,omitempty"`
+	GOARCH      string `yaml:"goarch,omitempty"`
+	CrossBinDir string `yaml:"cross_bin_dir,omitempty"`
+	// GitHubToken replaces the top-level github_token settings.
+	GitHubToken *TokenSettings `yaml:"github_token,omitempty"`
 }
 
-// DefaultPath returns the default config file path (~/.gogitup).
+// EnvPath is the environment variable that overrides the config file path.
+const EnvPath = "GOGITUP_CONFIG"
+
+// DefaultPath returns the config file path: $GOGITUP_CONFIG when set, otherwise
+// $XDG_CONFIG_HOME/gogitup/config.yaml. The legacy ~/.gogitup is returned while
+// it exists and has not been migrated.
 func DefaultPath() string {
-	home, err := os.UserHomeDir()
-	if err != nil {
-		return filepath.Join(".", ".gogitup")
+	return xdg.Resolve(EnvPath, xdgPath(), LegacyPath())
+}
+
+// LockPath returns the path of the lock that serializes changes to the config
+// and cache: next to $GOGITUP_CONFIG when set, otherwise next to the XDG config
+// path. Unlike DefaultPath it does not depend on whether the legacy file has
+// been migrated, so processes on either side of the migration share one lock.
+func LockPath() string {
+	if path := os.Getenv(EnvPath); path != "" {
+		return path + ".lock"
+	}
+	return xdgPath() + ".lock"
+}
+
+// LegacyPath returns the config file path used before XDG support (~/.gogitup).
+func LegacyPath() string {
+	return xdg.HomePath(".gogitup")
+}
+
+func xdgPath() string {
+	return filepath.Join(xdg.ConfigHome(), "gogitup", "config.yaml")
+}
+
+// MigrateLegacy moves ~/.gogitup to the XDG config location unless the path is
+// overridden by $GOGITUP_CONFIG or the XDG file already exists. It returns the
+// new path when a file was moved.
+func MigrateLegacy() (string, error) {
+	if os.Getenv(EnvPath) != "" {
+		return "", nil
 	}
-	return filepath.Join(home, ".gogitup")
+	moved, err := xdg.Migrate(xdgPath(), LegacyPath())
+	if err != nil || !moved {
+		return "", err
+	}
+	return xdgPath(), nil
 }
 
 // Load reads and parses the config file at the given path.
@@ -42,20 +189,293 @@ func Load(path string) (*Config, error) {
 		return nil, err
 	}
 
+	cfg, err := parse(data)
+	var typeErr *yaml.TypeError
+	if errors.As(err, &typeErr) {
+		// The YAML is well formed but has unknown keys or values of the wrong type.
+		return nil, &ValidationError{Path: path, Problems: describeTypeErrors(typeErr)}
+	}
+	if err == nil && len(bytes.TrimSpace(data)) == 0 && hasBackup(path) {
+		// Only gogitup writes backups, so an empty file next to one is the
+		// remains of an interrupted write rather than a new, empty config.
+		err = errors.New("file is empty")
+	}
+	if err != nil {
+		corrupt := &CorruptError{Path: path, Err: err}
+		if hasBackup(path) {
+			corrupt.Backup = BackupPath(path)
+		}
+		return nil, corrupt
+	}
+	if problems := Validate(cfg); len(problems) > 0 {
+		return nil, &ValidationError{Path: path, Problems: problems}
+	}
+	return cfg, nil
+}
+
+// parse strictly decodes a config, rejecting unknown keys.
+func parse(data []byte) (*Config, error) {
 	var cfg Config
-	if err := yaml.Unmarshal(data, &cfg); err != nil {
+	dec := yaml.NewDecoder(bytes.NewReader(data))
+	dec.KnownFields(true)
+	if err := dec.Decode(&cfg); err != nil && !errors.Is(err, io.EOF) {
 		return nil, err
 	}
 	return &cfg, nil
 }
 
-// Save writes the config to the given file path.
+// parseLenient decodes a config, ignoring unknown keys and leaving values of
+// the wrong type unset.
+func parseLenient(data []byte) (*Config, error) {
+	var cfg Config
+	var typeErr *yaml.TypeError
+	if err := yaml.Unmarshal(data, &cfg); err != nil && !errors.As(err, &typeErr) {
+		return nil, err
+	}
+	return &cfg, nil
+}
+
+// parses reports whether data is well-formed YAML for a config, ignoring
+// unknown keys.
+func parses(data []byte) bool {
+	var cfg Config
+	return yaml.Unmarshal(data, &cfg) == nil
+}
+
+// CorruptError reports a config file that exists but cannot be parsed,
+// typically because an earlier write was interrupted.
+type CorruptError struct {
+	Path string
+	// Backup is the path of a readable copy of the previous version, if any.
+	Backup string
+	Err    error
+}
+
+func (e *CorruptError) Error() string {
+	msg := fmt.Sprintf("config file %s is truncated or corrupt: %v", e.Path, e.Err)
+	if e.Backup != "" {
+		msg += fmt.Sprintf("; the previous version is saved at %s and can be restored by copying it over %s", e.Backup, e.Path)
+	}
+	return msg
+}
+
+func (e *CorruptError) Unwrap() error {
+	return e.Err
+}
+
+// BackupPath returns the path of the backup Save keeps of the previous version
+// of a config file.
+func BackupPath(path string) string {
+	return path + ".bak"
+}
+
+// hasBackup reports whether a non-empty, parseable backup exists for path.
+func hasBackup(path string) bool {
+	data, err := os.ReadFile(BackupPath(path))
+	if err != nil || len(bytes.TrimSpace(data)) == 0 {
+		return false
+	}
+	return parses(data)
+}
+
+// ErrUnknownProfile is returned when a profile is selected that the config
+// does not define.
+var ErrUnknownProfile = errors.New("unknown profile")
+
+// LoadProfile reads the config file at the given path and returns the
+// effective config for the named profile. An empty name selects the top-level
+// apps and settings.
+func LoadProfile(path, name string) (*Config, error) {
+	cfg, err := Load(path)
+	if err != nil || name == "" {
+		return cfg, err
+	}
+	return SelectProfile(cfg, name)
+}
+
+// LoadProfileLenient is LoadProfile for commands that repair a config, such
+// as remove. Unknown keys, values of the wrong type, and semantic problems are
+// returned alongside the config instead of failing the load; values of the
+// wrong type are left unset. A file that cannot be parsed at all still fails.
+func LoadProfileLenient(path, name string) (*Config, []string, error) {
+	cfg, err := Load(path)
+	var problems []string
+	var invalid *ValidationError
+	if errors.As(err, &invalid) {
+		data, readErr := os.ReadFile(path)
+		if readErr != nil {
+			return nil, nil, readErr
+		}
+		if cfg, err = parseLenient(data); err != nil {
+			return nil, nil, err
+		}
+		if problems, err = problemsOf(data); err != nil {
+			return nil, nil, err
+		}
+	} else if err != nil {
+		return nil, nil, err
+	}
+
+	if name == "" {
+		return cfg, problems, nil
+	}
+	cfg, err = SelectProfile(cfg, name)
+	return cfg, problems, err
+}
+
+// SelectProfile returns the effective config for the named profile: the
+// profile's apps with its settings layered over the top-level settings. It
+// returns an error wrapping ErrUnknownProfile when the profile does not exist,
+// so that a misspelled name is not mistaken for an empty profile.
+func SelectProfile(cfg *Config, name string) (*Config, error) {
+	p := cfg.Profiles[name]
+	if p == nil {
+		if names := ProfileNames(cfg); len(names) > 0 {
+			return nil, fmt.Errorf("%w %q (defined profiles: %s)", ErrUnknownProfile, name, strings.Join(names, ", "))
+		}
+		return nil, fmt.Errorf("%w %q (no profiles are defined)", ErrUnknownProfile, name)
+	}
+	return profileView(cfg, name, p), nil
+}
+
+// CreateProfile adds an empty profile with the given name to cfg unless it
+// exists, and returns its effective config. The profile is written when the
+// returned config is saved.
+func CreateProfile(cfg *Config, name string) *Config {
+	if cfg.Profiles == nil {
+		cfg.Profiles = make(map[string]*Profile)
+	}
+	p := cfg.Profiles[name]
+	if p == nil {
+		p = &Profile{}
+		cfg.Profiles[name] = p
+	}
+	return profileView(cfg, name, p)
+}
+
+func profileView(cfg *Config, name string, p *Profile) *Config {
+	view := &Config{
+		Apps:         p.Apps,
+		GitHubAuth:   cfg.GitHubAuth,
+		GOPROXY:      cfg.GOPROXY,
+		CGOEnabled:   cfg.CGOEnabled,
+		CacheTTL:     cfg.CacheTTL,
+		GOOS:         cfg.GOOS,
+		GOARCH:       cfg.GOARCH,
+		CrossBinDir:  cfg.CrossBinDir,
+		HTTPTimeout:  cfg.HTTPTimeout,
+		Retries:      cfg.Retries,
+		HTTPSProxy:   cfg.HTTPSProxy,
+		CABundle:     cfg.CABundle,
+		ClientCert:   cfg.ClientCert,
+		ClientKey:    cfg.ClientKey,
+		GitHubToken:  cfg.GitHubToken,
+		GitHubHosts:  cfg.GitHubHosts,
+		ReleaseHosts: cfg.ReleaseHosts,
+		Notifiers:    cfg.Notifiers,
+		file:         cfg,
+		profile:      name,
+	}
+	if p.GitHubAuth != nil {
+		view.GitHubAuth = *p.GitHubAuth
+	}
+	if p.GOPROXY != "" {
+		view.GOPROXY = p.GOPROXY
+	}
+	if p.CGOEnabled != nil {
+		view.CGOEnabled = p.CGOEnabled
+	}
+	if p.CacheTTL != "" {
+		view.CacheTTL = p.CacheTTL
+	}
+	if p.GOOS != "" {
+		view.GOOS = p.GOOS
+	}
+	if p.GOARCH != "" {
+		view.GOARCH = p.GOARCH
+	}
+	if p.CrossBinDir != "" {
+		view.CrossBinDir = p.CrossBinDir
+	}
+	if p.GitHubToken != nil {
+		view.GitHubToken = p.GitHubToken
+	}
+	return view
+}
+
+// ProfileName returns the name of the profile a config was selected for, or
+// an empty string for the top-level config.
+func ProfileName(cfg *Config) string {
+	return cfg.profile
+}
+
+// ProfileNames returns the names of the profiles defined in the config.
+func ProfileNames(cfg *Config) []string {
+	names := make([]string, 0, len(cfg.Profiles))
+	for name := range cfg.Profiles {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+	return names
+}
+
+// AllAppNames returns the names of the apps registered at the top level and
+// in every profile, without duplicates.
+func AllAppNames(cfg *Config) []string {
+	seen := make(map[string]bool)
+	var names []string
+	add := func(apps []App) {
+		for _, app := range apps {
+			if !seen[app.Name] {
+				seen[app.Name] = true
+				names = append(names, app.Name)
+			}
+		}
+	}
+	add(cfg.Apps)
+	for _, name := range ProfileNames(cfg) {
+		add(cfg.Profiles[name].Apps)
+	}
+	return names
+}
+
+// Save atomically writes the config to the given file path, creating its
+// directory if needed. When cfg was returned by SelectProfile or CreateProfile, its apps are stored in the
+// profile and the whole config file is written.
 func Save(path string, cfg *Config) error {
+	if cfg.file != nil {
+		file := cfg.file
+		if file.Profiles == nil {
+			file.Profiles = make(map[string]*Profile)
+		}
+		p := file.Profiles[cfg.profile]
+		if p == nil {
+			p = &Profile{}
+			file.Profiles[cfg.profile] = p
+		}
+		p.Apps = cfg.Apps
+		cfg = file
+	}
+
 	data, err := yaml.Marshal(cfg)
 	if err != nil {
 		return err
 	}
-	return os.WriteFile(path, data, 0600)
+	return writeFile(path, data)
+}
+
+// writeFile atomically replaces the config file at path with data.
+func writeFile(path string, data []byte) error {
+	// Keep the previous version as a backup when it is intact, so a config
+	// damaged outside gogitup can still be restored.
+	if previous, err := os.ReadFile(path); err == nil && len(bytes.TrimSpace(previous)) > 0 {
+		if parses(previous) {
+			if err := fileutil.WriteAtomic(BackupPath(path), previous, 0600); err != nil {
+				return err
+			}
+		}
+	}
+	return fileutil.WriteAtomic(path, data, 0600)
 }
 
 // AddApp adds an app to the config. Returns an error if the app already exists.
@@ -66,10 +486,16 @@ func AddApp(cfg *Config, name string) error {
 // AddAppWithInstallPath adds an app with an optional Go package path used for
 // future upgrades. Returns an error if the app already exists.
 func AddAppWithInstallPath(cfg *Config, name, installPath string) error {
-	if HasApp(cfg, name) {
-		return errors.New("app already exists: " + name)
+	return AddAppEntry(cfg, App{Name: name, InstallPath: installPath})
+}
+
+// AddAppEntry adds app to the config. Returns an error if an app with the
+// same name already exists.
+func AddAppEntry(cfg *Config, app App) error {
+	if HasApp(cfg, app.Name) {
+		return errors.New("app already exists: " + app.Name)
 	}
-	cfg.Apps = append(cfg.Apps, App{Name: name, InstallPath: installPath})
+	cfg.Apps = append(cfg.Apps, app)
 	return nil
 }
 
@@ -93,3 +519,64 @@ func HasApp(cfg *Config, name string) bool {
 	}
 	return false
 }
+
+// CacheTTL returns how long cached version checks for the named app stay
+// valid: the app's cache_ttl, then the global cache_ttl, then fallback.
+func CacheTTL(cfg *Config, name string, fallback time.Duration) (time.Duration, error) {
+	value := cfg.CacheTTL
+	for _, app := range cfg.Apps {
+		if app.Name == name && app.CacheTTL != "" {
+			value = app.CacheTTL
+		}
+	}
+	if value == "" {
+		return fallback, nil
+	}
+	ttl, err := ParseDuration(value)
+	if err != nil {
+		return 0, fmt.Errorf("invalid cache_ttl for %s: %w", name, err)
+	}
+	return ttl, nil
+}
+
+// HTTPTimeout returns how long each network request may take: the configured
+// http_timeout, or fallback when it is not set.
+func HTTPTimeout(cfg *Config, fallback time.Duration) (time.Duration, error) {
+	if cfg.HTTPTimeout == "" {
+		return fallback, nil
+	}
+	timeout, err := ParseDuration(cfg.HTTPTimeout)
+	if err != nil {
+		return 0, fmt.Errorf("invalid http_timeout: %w", err)
+	}
+	return timeout, nil
+}
+
+// Retries returns how many times transient network failures are retried: the
+// configured retries, or fallback when it is not set.
+func Retries(cfg *Config, fallback int) int {
+	if cfg.Retries == nil {
+		return fallback
+	}
+	return *cfg.Retries
+}
+
+// ParseDuration parses a Go duration string such as "12h" or "90m", and
+// additionally accepts a whole number of days such as "7d".
+func ParseDuration(value string) (time.Duration, error) {
+	if days, ok := strings.CutSuffix(value, "d"); ok {
+		n, err := strconv.Atoi(days)
+		if err != nil || n < 0 {
+			return 0, fmt.Errorf("invalid duration %q", value)
+		}
+		return time.Duration(n) * 24 * time.Hour, nil
+	}
+	d, err := time.ParseDuration(value)
+	if err != nil {
+		return 0, err
+	}
+	if d < 0 {
+		return 0, fmt.Errorf("invalid duration %q", value)
+	}
+	return d, nil
+}
@@ -1,9 +1,12 @@
 package config
 
 import (
+	"errors"
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
+	"time"
 )
 
 func TestLoadNonExistentFile(t *testing.T) {
@@ -234,3 +237,185 @@ func TestHasApp(t *testing.T) {
 		t.Fatal("expected HasApp to return false for app2")
 	}
 }
+
+func TestCacheTTL(t *testing.T) {
+	cfg := &Config{
+		CacheTTL: "12h",
+		Apps: []App{
+			{Name: "fast", CacheTTL: "30m"},
+			{Name: "slow", CacheTTL: "7d"},
+			{Name: "plain"},
+		},
+	}
+	tests := []struct {
+		name string
+		want time.Duration
+	}{
+		{"fast", 30 * time.Minute},
+		{"slow", 7 * 24 * time.Hour},
+		{"plain", 12 * time.Hour},
+	}
+	for _, tc := range tests {
+		got, err := CacheTTL(cfg, tc.name, time.Hour)
+		if err != nil {
+			t.Fatalf("CacheTTL(%q) error = %v", tc.name, err)
+		}
+		if got != tc.want {
+			t.Fatalf("CacheTTL(%q) = %v, want %v", tc.name, got, tc.want)
+		}
+	}
+
+	got, err := CacheTTL(&Config{}, "plain", time.Hour)
+	if err != nil || got != time.Hour {
+		t.Fatalf("expected fallback TTL, got %v (err=%v)", got, err)
+	}
+
+	if _, err := CacheTTL(&Config{CacheTTL: "soon"}, "plain", time.Hour); err == nil {
+		t.Fatal("expected an error for an invalid cache_ttl")
+	}
+}
+
+func TestParseDuration(t *testing.T) {
+	for _, value := range []string{"-1h", "xd", "-2d", ""} {
+		if _, err := ParseDuration(value); err == nil {
+			t.Errorf("ParseDuration(%q) expected error", value)
+		}
+	}
+	if d, err := ParseDuration("90m"); err != nil || d != 90*time.Minute {
+		t.Fatalf("ParseDuration(90m) = %v, %v", d, err)
+	}
+}
+
+func TestSelectProfileInheritsSettings(t *testing.T) {
+	cgoDisabled := false
+	auth := true
+	cfg := &Config{
+		Apps:        []App{{Name: "top"}},
+		GOPROXY:     "https://proxy.example.com",
+		CGOEnabled:  &cgoDisabled,
+		GitHubToken: &TokenSettings{File: "/tokens/personal"},
+		Profiles: map[string]*Profile{
+			"work": {
+				Apps:        []App{{Name: "worktool"}},
+				GitHubAuth:  &auth,
+				GOPROXY:     "https://work-proxy.example.com",
+				GitHubToken: &TokenSettings{Sources: []string{"command"}, Command: "work-token"},
+			},
+		},
+	}
+
+	view, err := SelectProfile(cfg, "work")
+	if err != nil {
+		t.Fatalf("SelectProfile: %v", err)
+	}
+
+	if len(view.Apps) != 1 || view.Apps[0].Name != "worktool" {
+		t.Fatalf("expected profile apps, got %+v", view.Apps)
+	}
+	if !view.GitHubAuth || view.GOPROXY != "https://work-proxy.example.com" {
+		t.Fatalf("expected profile settings, got auth=%t goproxy=%q", view.GitHubAuth, view.GOPROXY)
+	}
+	if view.CGOEnabled == nil || *view.CGOEnabled {
+		t.Fatal("expected cgo_enabled to be inherited from the top level")
+	}
+	if view.GitHubToken == nil || view.GitHubToken.Command != "work-token" {
+		t.Fatalf("expected the profile's github_token, got %+v", view.GitHubToken)
+	}
+	if ProfileName(view) != "work" {
+		t.Fatalf("expected profile name work, got %q", ProfileName(view))
+	}
+
+	if _, err := SelectProfile(cfg, "wrok"); !errors.Is(err, ErrUnknownProfile) || !strings.Contains(err.Error(), "work") {
+		t.Fatalf("expected an unknown profile error listing work, got %v", err)
+	}
+}
+
+func TestSaveProfileWritesBackApps(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	if err := Save(path, &Config{Apps: []App{{Name: "top"}}}); err != nil {
+		t.Fatalf("save: %v", err)
+	}
+
+	if _, err := LoadProfile(path, "ci"); !errors.Is(err, ErrUnknownProfile) {
+		t.Fatalf("expected an unknown profile error, got %v", err)
+	}
+	cfg, err := Load(path)
+	if err != nil {
+		t.Fatalf("load: %v", err)
+	}
+	view := CreateProfile(cfg, "ci")
+	if len(view.Apps) != 0 {
+		t.Fatalf("expected new profile to be empty, got %+v", view.Apps)
+	}
+	if err := AddApp(view, "citool"); err != nil {
+		t.Fatalf("AddApp: %v", err)
+	}
+	if err := Save(path, view); err != nil {
+		t.Fatalf("save profile: %v", err)
+	}
+
+	loaded, err := Load(path)
+	if err != nil {
+		t.Fatalf("load: %v", err)
+	}
+	if len(loaded.Apps) != 1 || loaded.Apps[0].Name != "top" {
+		t.Fatalf("expected top-level apps to be unchanged, got %+v", loaded.Apps)
+	}
+	p := loaded.Profiles["ci"]
+	if p == nil || len(p.Apps) != 1 || p.Apps[0].Name != "citool" {
+		t.Fatalf("expected ci profile with citool, got %+v", p)
+	}
+	if names := AllAppNames(loaded); len(names) != 2 {
+		t.Fatalf("expected apps from all profiles, got %v", names)
+	}
+}
+
+func TestLoadCorruptConfigReportsBackup(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	if err := Save(path, &Config{Apps: []App{{Name: "first"}}}); err != nil {
+		t.Fatalf("save: %v", err)
+	}
+	if err := Save(path, &Config{Apps: []App{{Name: "first"}, {Name: "second"}}}); err != nil {
+		t.Fatalf("save: %v", err)
+	}
+
+	// Simulate a write that was cut off midway.
+	if err := os.WriteFile(path, []byte("apps:\n  - name: first\n  - name: [sec"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	_, err := Load(path)
+	var corrupt *CorruptError
+	if !errors.As(err, &corrupt) {
+		t.Fatalf("expected CorruptError, got %v", err)
+	}
+	if corrupt.Backup != BackupPath(path) {
+		t.Fatalf("expected backup path %s, got %q", BackupPath(path), corrupt.Backup)
+	}
+	if !strings.Contains(err.Error(), "truncated or corrupt") {
+		t.Fatalf("expected a clear error message, got %q", err.Error())
+	}
+
+	backup, err := Load(BackupPath(path))
+	if err != nil || len(backup.Apps) != 1 || backup.Apps[0].Name != "first" {
+		t.Fatalf("expected backup to hold the previous version, got %+v (err=%v)", backup, err)
+	}
+}
+
+func TestLoadEmptyConfigWithBackupIsTruncated(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	if err := os.WriteFile(path, nil, 0600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Load(path); err != nil {
+		t.Fatalf("expected empty config without backup to load, got %v", err)
+	}
+
+	if err := os.WriteFile(BackupPath(path), []byte("apps:\n  - name: app1\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	var corrupt *CorruptError
+	if _, err := Load(path); !errors.As(err, &corrupt) {
+		t.Fatalf("expected CorruptError for empty config with backup, got %v", err)
+	}
+}
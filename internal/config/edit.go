@@ -0,0 +1,476 @@
+package config
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Document is a config file loaded as a YAML node tree, so that individual
+// values can be changed without losing comments or key order.
+type Document struct {
+	root yaml.Node
+	// problems are the validation problems of the file as loaded, which an
+	// edit may leave in place.
+	problems []string
+}
+
+// KeyValue is a single setting in a Document, as reported by ListValues.
+type KeyValue struct {
+	Key   string
+	Value string
+}
+
+// keySpec describes the type of a settable key and how to check its value.
+type keySpec struct {
+	tag      string
+	validate func(string) error
+}
+
+var (
+	boolKey = keySpec{tag: "!!bool", validate: func(v string) error {
+		if _, err := strconv.ParseBool(v); err != nil {
+			return fmt.Errorf("expected true or false, got %q", v)
+		}
+		return nil
+	}}
+	stringKey   = keySpec{tag: "!!str", validate: func(string) error { return nil }}
+	durationKey = keySpec{tag: "!!str", validate: func(v string) error {
+		_, err := ParseDuration(v)
+		return err
+	}}
+	platformKey = keySpec{tag: "!!str", validate: func(v string) error {
+		if strings.ContainsAny(v, "/ \t") {
+			return fmt.Errorf("invalid platform value %q", v)
+		}
+		return nil
+	}}
+)
+
+// settingKeys are the keys that can be set at the top level and in profiles,
+// in the order ListValues reports them.
+var settingKeys = []string{"github_auth", "goproxy", "cgo_enabled", "cache_ttl", "goos", "goarch", "cross_bin_dir", "http_timeout", "retries", "https_proxy", "ca_bundle", "client_cert", "client_key"}
+
+var settingSpecs = map[string]keySpec{
+	"github_auth":   boolKey,
+	"goproxy":       {tag: "!!str", validate: ValidateGOPROXY},
+	"cgo_enabled":   boolKey,
+	"cache_ttl":     durationKey,
+	"goos":          platformKey,
+	"goarch":        platformKey,
+	"cross_bin_dir": stringKey,
+	"http_timeout":  {tag: "!!str", validate: ValidateHTTPTimeout},
+	"retries":       {tag: "!!int", validate: ValidateRetries},
+	"https_proxy":   {tag: "!!str", validate: ValidateProxy},
+	"ca_bundle":     stringKey,
+	"client_cert":   stringKey,
+	"client_key":    stringKey,
+}
+
+// globalKeys are the setting keys that apply to every profile and so cannot be
+// set inside one.
+var globalKeys = map[string]bool{
+	"http_timeout": true,
+	"retries":      true,
+	"https_proxy":  true,
+	"ca_bundle":    true,
+	"client_cert":  true,
+	"client_key":   true,
+}
+
+// IsGlobalKey reports whether key is a setting that applies to every profile.
+func IsGlobalKey(key string) bool {
+	return globalKeys[key]
+}
+
+// appKeys are the keys that can be set on a registered app.
+var appKeys = []string{"install_path", "cache_ttl", "goos", "goarch", "cross_bin_dir"}
+
+var appSpecs = map[string]keySpec{
+	"install_path":  {tag: "!!str", validate: ValidateInstallPath},
+	"cache_ttl":     durationKey,
+	"goos":          platformKey,
+	"goarch":        platformKey,
+	"cross_bin_dir": stringKey,
+}
+
+// keyPath is a parsed key such as "goproxy", "apps.tool.cache_ttl", or
+// "profiles.work.apps.tool.install_path".
+type keyPath struct {
+	profile string
+	app     string
+	field   string
+	spec    keySpec
+}
+
+func parseKey(key string) (keyPath, error) {
+	var kp keyPath
+	rest := key
+	if after, ok := strings.CutPrefix(rest, "profiles."); ok {
+		name, field, found := strings.Cut(after, ".")
+		if !found || name == "" {
+			return kp, fmt.Errorf("invalid key %q: expected profiles.<name>.<key>", key)
+		}
+		kp.profile = name
+		rest = field
+	}
+
+	if after, ok := strings.CutPrefix(rest, "apps."); ok {
+		i := strings.LastIndex(after, ".")
+		if i <= 0 {
+			return kp, fmt.Errorf("invalid key %q: expected apps.<name>.<key>", key)
+		}
+		kp.app, kp.field = after[:i], after[i+1:]
+		spec, ok := appSpecs[kp.field]
+		if !ok {
+			return kp, fmt.Errorf("unknown key %q: apps support %s", key, strings.Join(appKeys, ", "))
+		}
+		kp.spec = spec
+		return kp, nil
+	}
+
+	spec, ok := settingSpecs[rest]
+	if !ok {
+		return kp, fmt.Errorf("unknown key %q", key)
+	}
+	if kp.profile != "" && globalKeys[rest] {
+		return kp, fmt.Errorf("%s applies to every profile and cannot be set in one", rest)
+	}
+	kp.field = rest
+	kp.spec = spec
+	return kp, nil
+}
+
+// LoadDocument reads the config file at path for editing. A missing or empty
+// file yields an empty Document.
+func LoadDocument(path string) (*Document, error) {
+	doc := &Document{}
+	data, err := os.ReadFile(path)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return nil, err
+	}
+	if len(bytes.TrimSpace(data)) > 0 {
+		if err := yaml.Unmarshal(data, &doc.root); err != nil {
+			return nil, &CorruptError{Path: path, Err: err}
+		}
+	}
+	if doc.root.Kind == 0 {
+		doc.root = yaml.Node{Kind: yaml.DocumentNode, Content: []*yaml.Node{{Kind: yaml.MappingNode, Tag: "!!map"}}}
+	}
+	if len(doc.root.Content) != 1 || doc.root.Content[0].Kind != yaml.MappingNode {
+		return nil, &CorruptError{Path: path, Err: errors.New("top level is not a mapping")}
+	}
+	if len(bytes.TrimSpace(data)) > 0 {
+		doc.problems, _ = problemsOf(data)
+	}
+	return doc, nil
+}
+
+// SaveDocument validates the edited document and atomically writes it to
+// path. Nothing is written if the edit added a problem, but problems the file
+// already had are kept, so that a config with an invalid value can still be
+// repaired one key at a time; Problems reports them afterwards.
+func SaveDocument(path string, doc *Document) error {
+	var buf bytes.Buffer
+	enc := yaml.NewEncoder(&buf)
+	enc.SetIndent(2)
+	if err := enc.Encode(&doc.root); err != nil {
+		return err
+	}
+	if err := enc.Close(); err != nil {
+		return err
+	}
+
+	problems, err := problemsOf(buf.Bytes())
+	if err != nil {
+		return err
+	}
+	if added := addedProblems(doc.problems, problems); len(added) > 0 {
+		return &ValidationError{Path: path, Problems: added}
+	}
+	if err := writeFile(path, buf.Bytes()); err != nil {
+		return err
+	}
+	doc.problems = problems
+	return nil
+}
+
+// Problems returns the validation problems of the document as last loaded or
+// saved.
+func Problems(doc *Document) []string {
+	return doc.problems
+}
+
+// problemsOf returns the unknown keys, values of the wrong type, and semantic
+// problems of a config file's contents.
+func problemsOf(data []byte) ([]string, error) {
+	cfg, err := parse(data)
+	var typeErr *yaml.TypeError
+	switch {
+	case errors.As(err, &typeErr):
+		if cfg, err = parseLenient(data); err != nil {
+			return nil, err
+		}
+		return append(describeTypeErrors(typeErr), Validate(cfg)...), nil
+	case err != nil:
+		return nil, err
+	}
+	return Validate(cfg), nil
+}
+
+// positionRe matches the parts of a problem that move when other entries are
+// added or removed: line numbers and list indices.
+var positionRe = regexp.MustCompile(`^line \d+: |\[\d+\]`)
+
+// addedProblems returns the problems in after that are not in before. Line
+// numbers and list indices are ignored, so that removing a key or an entry
+// does not make the problems after it look new.
+func addedProblems(before, after []string) []string {
+	seen := make(map[string]int, len(before))
+	for _, p := range before {
+		seen[positionRe.ReplaceAllLiteralString(p, "")]++
+	}
+	var added []string
+	for _, p := range after {
+		key := positionRe.ReplaceAllLiteralString(p, "")
+		if seen[key] > 0 {
+			seen[key]--
+			continue
+		}
+		added = append(added, p)
+	}
+	return added
+}
+
+// RemoveAppEntry removes the app called name from the named profile, or from
+// the top level when profile is empty. It reports whether the app was found.
+func RemoveAppEntry(doc *Document, profile, name string) (bool, error) {
+	m, err := scopeNode(doc, keyPath{profile: profile}, false)
+	if err != nil {
+		return false, err
+	}
+	if m == nil {
+		return false, fmt.Errorf("%w %q", ErrUnknownProfile, profile)
+	}
+	_, apps := lookup(m, "apps")
+	if apps == nil || apps.Kind != yaml.SequenceNode {
+		return false, nil
+	}
+	for i, app := range apps.Content {
+		if _, n := lookup(app, "name"); n != nil && n.Value == name {
+			apps.Content = append(apps.Content[:i], apps.Content[i+1:]...)
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// GetValue returns the value of key as written in the config file, and false
+// if it is not set.
+func GetValue(doc *Document, key string) (string, bool, error) {
+	kp, err := parseKey(key)
+	if err != nil {
+		return "", false, err
+	}
+	m, err := scopeNode(doc, kp, false)
+	if err != nil || m == nil {
+		return "", false, err
+	}
+	_, value := lookup(m, kp.field)
+	if value == nil || value.Kind != yaml.ScalarNode {
+		return "", false, nil
+	}
+	return value.Value, true, nil
+}
+
+// SetValue sets key to value after checking that the value has the right type.
+// The app named by an apps.<name>.<key> key must already be registered.
+func SetValue(doc *Document, key, value string) error {
+	kp, err := parseKey(key)
+	if err != nil {
+		return err
+	}
+	if err := kp.spec.validate(value); err != nil {
+		return fmt.Errorf("invalid value for %s: %w", key, err)
+	}
+	if kp.spec.tag == "!!bool" {
+		b, _ := strconv.ParseBool(value)
+		value = strconv.FormatBool(b)
+	}
+
+	m, err := scopeNode(doc, kp, true)
+	if err != nil {
+		return err
+	}
+	_, node := lookup(m, kp.field)
+	if node == nil {
+		m.Content = append(m.Content,
+			&yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: kp.field},
+			&yaml.Node{Kind: yaml.ScalarNode, Tag: kp.spec.tag, Value: value})
+		return nil
+	}
+	// Update the existing node in place so its comments are kept.
+	node.Kind = yaml.ScalarNode
+	node.Tag = kp.spec.tag
+	node.Value = value
+	node.Content = nil
+	if kp.spec.tag == "!!bool" {
+		node.Style = 0
+	}
+	return nil
+}
+
+// UnsetValue removes key from the config file. It reports whether the key was
+// set.
+func UnsetValue(doc *Document, key string) (bool, error) {
+	kp, err := parseKey(key)
+	if err != nil {
+		return false, err
+	}
+	m, err := scopeNode(doc, kp, false)
+	if err != nil || m == nil {
+		return false, err
+	}
+	i, _ := lookup(m, kp.field)
+	if i < 0 {
+		return false, nil
+	}
+	// Carry the key's own comment over to the next key so it is not lost.
+	if i+2 < len(m.Content) && m.Content[i].HeadComment != "" && m.Content[i+2].HeadComment == "" {
+		m.Content[i+2].HeadComment = m.Content[i].HeadComment
+	}
+	m.Content = append(m.Content[:i], m.Content[i+2:]...)
+	return true, nil
+}
+
+// ListValues returns every setting set in the config file: top-level settings,
+// then app settings, then each profile's settings and apps.
+func ListValues(doc *Document) []KeyValue {
+	root := doc.root.Content[0]
+	values := listScope(root, "")
+	if _, profiles := lookup(root, "profiles"); profiles != nil && profiles.Kind == yaml.MappingNode {
+		for i := 0; i+1 < len(profiles.Content); i += 2 {
+			if p := profiles.Content[i+1]; p.Kind == yaml.MappingNode {
+				values = append(values, listScope(p, "profiles."+profiles.Content[i].Value+".")...)
+			}
+		}
+	}
+	return values
+}
+
+// Keys returns the keys that can be set for cfg: the setting keys, then the
+// keys of each registered app.
+func Keys(cfg *Config) []string {
+	keys := slices.Clone(settingKeys)
+	for _, app := range cfg.Apps {
+		for _, key := range appKeys {
+			keys = append(keys, "apps."+app.Name+"."+key)
+		}
+	}
+	return keys
+}
+
+func listScope(m *yaml.Node, prefix string) []KeyValue {
+	var values []KeyValue
+	for _, key := range settingKeys {
+		if _, v := lookup(m, key); v != nil && v.Kind == yaml.ScalarNode {
+			values = append(values, KeyValue{Key: prefix + key, Value: v.Value})
+		}
+	}
+	_, apps := lookup(m, "apps")
+	if apps == nil || apps.Kind != yaml.SequenceNode {
+		return values
+	}
+	for _, app := range apps.Content {
+		_, name := lookup(app, "name")
+		if name == nil {
+			continue
+		}
+		for _, key := range appKeys {
+			if _, v := lookup(app, key); v != nil && v.Kind == yaml.ScalarNode {
+				values = append(values, KeyValue{Key: prefix + "apps." + name.Value + "." + key, Value: v.Value})
+			}
+		}
+	}
+	return values
+}
+
+// HasProfile reports whether the document defines the named profile.
+func HasProfile(doc *Document, name string) bool {
+	_, profiles := lookup(doc.root.Content[0], "profiles")
+	if profiles == nil {
+		return false
+	}
+	_, p := lookup(profiles, name)
+	return p != nil
+}
+
+// scopeNode returns the mapping that holds kp.field: the top-level mapping, a
+// profile, or an app entry. Missing profiles are created when create is set;
+// otherwise nil is returned for them.
+func scopeNode(doc *Document, kp keyPath, create bool) (*yaml.Node, error) {
+	m := doc.root.Content[0]
+	if kp.profile != "" {
+		profiles, err := child(m, "profiles", create)
+		if err != nil || profiles == nil {
+			return nil, err
+		}
+		if m, err = child(profiles, kp.profile, create); err != nil || m == nil {
+			return nil, err
+		}
+	}
+	if kp.app == "" {
+		return m, nil
+	}
+
+	_, apps := lookup(m, "apps")
+	if apps != nil && apps.Kind == yaml.SequenceNode {
+		for _, app := range apps.Content {
+			if _, name := lookup(app, "name"); name != nil && name.Value == kp.app {
+				return app, nil
+			}
+		}
+	}
+	return nil, errors.New("app not found: " + kp.app)
+}
+
+// child returns the mapping stored under key in m, adding an empty one when
+// create is set.
+func child(m *yaml.Node, key string, create bool) (*yaml.Node, error) {
+	_, v := lookup(m, key)
+	switch {
+	case v != nil && v.Kind == yaml.MappingNode:
+		return v, nil
+	case v != nil && !(v.Kind == yaml.ScalarNode && v.Tag == "!!null"):
+		return nil, fmt.Errorf("%s is not a mapping", key)
+	case !create:
+		return nil, nil
+	}
+	if v == nil {
+		v = &yaml.Node{}
+		m.Content = append(m.Content, &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: key}, v)
+	}
+	v.Kind, v.Tag, v.Value = yaml.MappingNode, "!!map", ""
+	return v, nil
+}
+
+// lookup finds key in mapping m, returning the index of the key node and the
+// value node, or -1 and nil when it is absent.
+func lookup(m *yaml.Node, key string) (int, *yaml.Node) {
+	if m.Kind != yaml.MappingNode {
+		return -1, nil
+	}
+	for i := 0; i+1 < len(m.Content); i += 2 {
+		if m.Content[i].Value == key {
+			return i, m.Content[i+1]
+		}
+	}
+	return -1, nil
+}
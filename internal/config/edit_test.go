@@ -0,0 +1,254 @@
+package config
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+)
+
+const editTestConfig = `# Tools I use every day
+apps:
+  - name: tool # the main one
+    install_path: example.com/tool/cmd/tool
+  - name: other
+# Set to true on the work laptop
+github_auth: false
+profiles:
+  work:
+    apps:
+      - name: worktool
+`
+
+func loadEditTestDocument(t *testing.T) (string, *Document) {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	if err := os.WriteFile(path, []byte(editTestConfig), 0600); err != nil {
+		t.Fatalf("failed to write test file: %v", err)
+	}
+	doc, err := LoadDocument(path)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	return path, doc
+}
+
+func TestSetValuePreservesComments(t *testing.T) {
+	path, doc := loadEditTestDocument(t)
+
+	if err := SetValue(doc, "github_auth", "yes"); err == nil {
+		t.Fatal("expected error for non-boolean github_auth")
+	}
+	for key, value := range map[string]string{
+		"github_auth":          "1",
+		"goproxy":              "https://proxy.example.com,direct",
+		"apps.other.cache_ttl": "7d",
+		"profiles.work.goos":   "linux",
+		"profiles.work.apps.worktool.install_path": "example.com/worktool",
+	} {
+		if err := SetValue(doc, key, value); err != nil {
+			t.Fatalf("unexpected error setting %s: %v", key, err)
+		}
+	}
+	if err := SaveDocument(path, doc); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("failed to read config: %v", err)
+	}
+	for _, comment := range []string{"# Tools I use every day", "# the main one", "# Set to true on the work laptop"} {
+		if !strings.Contains(string(data), comment) {
+			t.Fatalf("expected comment %q to be preserved:\n%s", comment, data)
+		}
+	}
+
+	cfg, err := LoadProfile(path, "work")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !cfg.GitHubAuth || cfg.GOPROXY != "https://proxy.example.com,direct" || cfg.GOOS != "linux" {
+		t.Fatalf("unexpected settings: %+v", cfg)
+	}
+	if cfg.Apps[0].InstallPath != "example.com/worktool" {
+		t.Fatalf("expected profile app install path to be set, got %+v", cfg.Apps[0])
+	}
+	top, _ := Load(path)
+	if top.Apps[1].CacheTTL != "7d" {
+		t.Fatalf("expected cache_ttl 7d, got %q", top.Apps[1].CacheTTL)
+	}
+}
+
+func TestSetValueRejectsUnknownKeysAndApps(t *testing.T) {
+	_, doc := loadEditTestDocument(t)
+
+	if err := SetValue(doc, "github_auht", "true"); err == nil || !strings.Contains(err.Error(), "unknown key") {
+		t.Fatalf("expected unknown key error, got %v", err)
+	}
+	if err := SetValue(doc, "apps.tool.name", "x"); err == nil {
+		t.Fatal("expected error for unsupported app key")
+	}
+	if err := SetValue(doc, "apps.missing.cache_ttl", "1h"); err == nil || !strings.Contains(err.Error(), "app not found") {
+		t.Fatalf("expected app not found error, got %v", err)
+	}
+	if err := SetValue(doc, "apps.tool.install_path", "not a path"); err == nil {
+		t.Fatal("expected error for invalid install path")
+	}
+	if err := SetValue(doc, "profiles.work.retries", "3"); err == nil {
+		t.Fatal("expected error for a global key in a profile")
+	}
+	if err := SetValue(doc, "retries", "-1"); err == nil {
+		t.Fatal("expected error for negative retries")
+	}
+}
+
+func TestGetAndUnsetValue(t *testing.T) {
+	path, doc := loadEditTestDocument(t)
+
+	value, ok, err := GetValue(doc, "apps.tool.install_path")
+	if err != nil || !ok || value != "example.com/tool/cmd/tool" {
+		t.Fatalf("unexpected result: %q, %v, %v", value, ok, err)
+	}
+	if _, ok, _ := GetValue(doc, "profiles.home.goproxy"); ok {
+		t.Fatal("expected setting of a missing profile to be unset")
+	}
+
+	removed, err := UnsetValue(doc, "github_auth")
+	if err != nil || !removed {
+		t.Fatalf("expected github_auth to be removed, got %v, %v", removed, err)
+	}
+	if removed, _ := UnsetValue(doc, "goproxy"); removed {
+		t.Fatal("expected unset of a missing key to report false")
+	}
+	if err := SaveDocument(path, doc); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	data, _ := os.ReadFile(path)
+	if strings.Contains(string(data), "github_auth") || !strings.Contains(string(data), "# Set to true on the work laptop") {
+		t.Fatalf("unexpected config after unset:\n%s", data)
+	}
+}
+
+func TestListValues(t *testing.T) {
+	_, doc := loadEditTestDocument(t)
+	if err := SetValue(doc, "profiles.work.cache_ttl", "12h"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	var got []string
+	for _, kv := range ListValues(doc) {
+		got = append(got, kv.Key+"="+kv.Value)
+	}
+	want := []string{"github_auth=false", "apps.tool.install_path=example.com/tool/cmd/tool", "profiles.work.cache_ttl=12h"}
+	if strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Fatalf("expected %v, got %v", want, got)
+	}
+}
+
+func TestKeys(t *testing.T) {
+	keys := Keys(&Config{Apps: []App{{Name: "tool"}}})
+	for _, key := range keys {
+		if _, err := parseKey(key); err != nil {
+			t.Errorf("Keys() returned unsettable key %q: %v", key, err)
+		}
+	}
+	if !slices.Contains(keys, "goproxy") || !slices.Contains(keys, "apps.tool.install_path") {
+		t.Fatalf("unexpected keys %v", keys)
+	}
+}
+
+func TestSaveDocumentRejectsNewProblems(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	if err := os.WriteFile(path, []byte("apps:\n  - name: tool\n  - name: tool\n"), 0600); err != nil {
+		t.Fatalf("failed to write test file: %v", err)
+	}
+	doc, err := LoadDocument(path)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := SetValue(doc, "client_key", "/keys/client.pem"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	var invalid *ValidationError
+	if err := SaveDocument(path, doc); !errors.As(err, &invalid) || len(invalid.Problems) != 1 || !strings.HasPrefix(invalid.Problems[0], "client_key:") {
+		t.Fatalf("expected only the new problem to be reported, got %v", err)
+	}
+	data, _ := os.ReadFile(path)
+	if strings.Contains(string(data), "client_key") {
+		t.Fatal("expected config file to be left unchanged")
+	}
+}
+
+func TestSaveDocumentKeepsExistingProblems(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	content := "apps:\n  - name: tool\n  - name: tool\nhttp_timeout: 0s\ngoproxyy: https://proxy.example.com\n"
+	if err := os.WriteFile(path, []byte(content), 0600); err != nil {
+		t.Fatalf("failed to write test file: %v", err)
+	}
+	doc, err := LoadDocument(path)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if removed, err := UnsetValue(doc, "http_timeout"); err != nil || !removed {
+		t.Fatalf("expected http_timeout to be removed, got %v, %v", removed, err)
+	}
+	if err := SaveDocument(path, doc); err != nil {
+		t.Fatalf("expected the repair to be saved, got %v", err)
+	}
+	if problems := Problems(doc); len(problems) != 2 || !strings.Contains(problems[0], "goproxyy") {
+		t.Fatalf("expected the remaining problems to be reported, got %q", problems)
+	}
+	data, _ := os.ReadFile(path)
+	if strings.Contains(string(data), "http_timeout") || !strings.Contains(string(data), "goproxyy") {
+		t.Fatalf("unexpected config after unset:\n%s", data)
+	}
+}
+
+func TestRemoveAppEntry(t *testing.T) {
+	path, doc := loadEditTestDocument(t)
+
+	if removed, err := RemoveAppEntry(doc, "", "tool"); err != nil || !removed {
+		t.Fatalf("expected tool to be removed, got %v, %v", removed, err)
+	}
+	if removed, err := RemoveAppEntry(doc, "work", "worktool"); err != nil || !removed {
+		t.Fatalf("expected worktool to be removed, got %v, %v", removed, err)
+	}
+	if removed, err := RemoveAppEntry(doc, "", "missing"); err != nil || removed {
+		t.Fatalf("expected a missing app to be reported, got %v, %v", removed, err)
+	}
+	if _, err := RemoveAppEntry(doc, "home", "tool"); !errors.Is(err, ErrUnknownProfile) {
+		t.Fatalf("expected ErrUnknownProfile, got %v", err)
+	}
+	if err := SaveDocument(path, doc); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	cfg, err := Load(path)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if HasApp(cfg, "tool") || !HasApp(cfg, "other") || len(cfg.Profiles["work"].Apps) != 0 {
+		t.Fatalf("unexpected apps after removal: %+v", cfg)
+	}
+}
+
+func TestLoadDocumentMissingFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	doc, err := LoadDocument(path)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := SetValue(doc, "cgo_enabled", "false"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := SaveDocument(path, doc); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	cfg, err := Load(path)
+	if err != nil || cfg.CGOEnabled == nil || *cfg.CGOEnabled {
+		t.Fatalf("expected cgo_enabled false, got %+v, %v", cfg, err)
+	}
+}
@@ -0,0 +1,268 @@
+package config
+
+import (
+	"fmt"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// ValidationError reports a config file that parses but contains unknown keys
+// or invalid values.
+type ValidationError struct {
+	Path     string
+	Problems []string
+}
+
+func (e *ValidationError) Error() string {
+	return fmt.Sprintf("invalid config file %s: %s", e.Path, strings.Join(e.Problems, "; "))
+}
+
+var unknownFieldRe = regexp.MustCompile(`field (\S+) not found in type \S+`)
+
+// describeTypeErrors rewrites yaml decoding errors in config terms.
+func describeTypeErrors(err *yaml.TypeError) []string {
+	problems := make([]string, 0, len(err.Errors))
+	for _, msg := range err.Errors {
+		problems = append(problems, unknownFieldRe.ReplaceAllString(msg, `unknown key "$1"`))
+	}
+	return problems
+}
+
+// A Check reports problems in a parsed config that Validate cannot find on
+// its own, such as settings whose allowed values are defined by the packages
+// that use them.
+type Check func(cfg *Config) []string
+
+// checks are the registered checks, run by Validate in order.
+var checks []Check
+
+// RegisterCheck adds a check for Validate to run after its own. It keeps this
+// package free of the packages that define token sources, release providers,
+// and notifiers, and is meant to be called from an init function.
+func RegisterCheck(check Check) {
+	checks = append(checks, check)
+}
+
+// Validate checks a parsed config for semantic problems such as duplicate app
+// names, malformed install paths, GOPROXY lists, and durations, then runs the
+// registered checks. It returns a description of each problem found.
+func Validate(cfg *Config) []string {
+	var problems []string
+	problems = append(problems, validateScope("", cfg.Apps, cfg.GOPROXY, cfg.CacheTTL)...)
+	problems = append(problems, validateNetwork(cfg)...)
+	problems = append(problems, validateGitHubHosts(cfg.GitHubHosts)...)
+	problems = append(problems, validateReleaseHosts(cfg.ReleaseHosts, cfg.GitHubHosts)...)
+	for _, name := range ProfileNames(cfg) {
+		p := cfg.Profiles[name]
+		if p == nil {
+			continue
+		}
+		problems = append(problems, validateScope("profiles."+name+".", p.Apps, p.GOPROXY, p.CacheTTL)...)
+	}
+	for _, check := range checks {
+		problems = append(problems, check(cfg)...)
+	}
+	return problems
+}
+
+func validateScope(prefix string, apps []App, goproxy, cacheTTL string) []string {
+	var problems []string
+	seen := make(map[string]bool, len(apps))
+	for i, app := range apps {
+		field := fmt.Sprintf("%sapps[%d]", prefix, i)
+		switch {
+		case app.Name == "":
+			problems = append(problems, field+": name is required")
+		case strings.ContainsAny(app.Name, "/@ \t"):
+			problems = append(problems, fmt.Sprintf("%s: invalid name %q", field, app.Name))
+		case seen[app.Name]:
+			problems = append(problems, fmt.Sprintf("%s: duplicate app name %q", field, app.Name))
+		}
+		seen[app.Name] = true
+
+		if app.InstallPath != "" {
+			if err := ValidateInstallPath(app.InstallPath); err != nil {
+				problems = append(problems, fmt.Sprintf("%s.install_path: %v", field, err))
+			}
+		}
+		if app.CacheTTL != "" {
+			if _, err := ParseDuration(app.CacheTTL); err != nil {
+				problems = append(problems, fmt.Sprintf("%s.cache_ttl: %v", field, err))
+			}
+		}
+		if err := platformKey.validate(app.GOOS); err != nil {
+			problems = append(problems, fmt.Sprintf("%s.goos: %v", field, err))
+		}
+		if err := platformKey.validate(app.GOARCH); err != nil {
+			problems = append(problems, fmt.Sprintf("%s.goarch: %v", field, err))
+		}
+	}
+
+	if goproxy != "" {
+		if err := ValidateGOPROXY(goproxy); err != nil {
+			problems = append(problems, fmt.Sprintf("%sgoproxy: %v", prefix, err))
+		}
+	}
+	if cacheTTL != "" {
+		if _, err := ParseDuration(cacheTTL); err != nil {
+			problems = append(problems, fmt.Sprintf("%scache_ttl: %v", prefix, err))
+		}
+	}
+	return problems
+}
+
+// maxRetries keeps a mistyped retries value from stalling every command.
+const maxRetries = 10
+
+func validateNetwork(cfg *Config) []string {
+	var problems []string
+	if cfg.HTTPTimeout != "" {
+		if err := ValidateHTTPTimeout(cfg.HTTPTimeout); err != nil {
+			problems = append(problems, fmt.Sprintf("http_timeout: %v", err))
+		}
+	}
+	if cfg.Retries != nil {
+		if err := ValidateRetries(strconv.Itoa(*cfg.Retries)); err != nil {
+			problems = append(problems, fmt.Sprintf("retries: %v", err))
+		}
+	}
+	if cfg.HTTPSProxy != "" {
+		if err := ValidateProxy(cfg.HTTPSProxy); err != nil {
+			problems = append(problems, fmt.Sprintf("https_proxy: %v", err))
+		}
+	}
+	if cfg.ClientKey != "" && cfg.ClientCert == "" {
+		problems = append(problems, "client_key: client_cert is required")
+	}
+	return problems
+}
+
+// ValidateProxy checks an https_proxy value, which must be an http, https, or
+// socks5 URL.
+func ValidateProxy(value string) error {
+	u, err := url.Parse(value)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https" && u.Scheme != "socks5") || u.Host == "" {
+		return fmt.Errorf("invalid proxy URL %q", value)
+	}
+	return nil
+}
+
+// ValidateHTTPTimeout checks an http_timeout value, which must be a positive
+// duration.
+func ValidateHTTPTimeout(value string) error {
+	d, err := ParseDuration(value)
+	if err != nil {
+		return err
+	}
+	if d == 0 {
+		return fmt.Errorf("timeout must be greater than zero")
+	}
+	return nil
+}
+
+// ValidateRetries checks a retries value, a whole number from 0 to 10.
+func ValidateRetries(value string) error {
+	n, err := strconv.Atoi(value)
+	if err != nil || n < 0 || n > maxRetries {
+		return fmt.Errorf("expected a whole number from 0 to %d, got %q", maxRetries, value)
+	}
+	return nil
+}
+
+func validateGitHubHosts(hosts []GitHubHost) []string {
+	var problems []string
+	seen := make(map[string]bool, len(hosts))
+	for i, h := range hosts {
+		field := fmt.Sprintf("github_hosts[%d]", i)
+		switch {
+		case h.Host == "":
+			problems = append(problems, field+": host is required")
+		case h.Host == "github.com" || strings.ContainsAny(h.Host, "/@ \t") || !strings.Contains(h.Host, "."):
+			problems = append(problems, fmt.Sprintf("%s: invalid host %q", field, h.Host))
+		case seen[h.Host]:
+			problems = append(problems, fmt.Sprintf("%s: duplicate host %q", field, h.Host))
+		}
+		seen[h.Host] = true
+
+		if h.APIURL != "" && !IsHTTPURL(h.APIURL) {
+			problems = append(problems, fmt.Sprintf("%s.api_url: invalid URL %q", field, h.APIURL))
+		}
+	}
+	return problems
+}
+
+func validateReleaseHosts(hosts []ReleaseHost, githubHosts []GitHubHost) []string {
+	var problems []string
+	seen := make(map[string]bool, len(hosts)+len(githubHosts))
+	for _, h := range githubHosts {
+		seen[h.Host] = true
+	}
+	for i, h := range hosts {
+		field := fmt.Sprintf("release_hosts[%d]", i)
+		switch {
+		case h.Host == "":
+			problems = append(problems, field+": host is required")
+		case h.Host == "github.com" || strings.ContainsAny(h.Host, "/@ \t") || !strings.Contains(h.Host, "."):
+			problems = append(problems, fmt.Sprintf("%s: invalid host %q", field, h.Host))
+		case seen[h.Host]:
+			problems = append(problems, fmt.Sprintf("%s: duplicate host %q", field, h.Host))
+		}
+		seen[h.Host] = true
+
+		if h.APIURL != "" && !IsHTTPURL(h.APIURL) {
+			problems = append(problems, fmt.Sprintf("%s.api_url: invalid URL %q", field, h.APIURL))
+		}
+	}
+	return problems
+}
+
+// IsHTTPURL reports whether value is an absolute http or https URL.
+func IsHTTPURL(value string) bool {
+	u, err := url.Parse(value)
+	return err == nil && (u.Scheme == "https" || u.Scheme == "http") && u.Host != ""
+}
+
+// ValidateInstallPath checks that path looks like a Go package path that go
+// install can resolve, such as "golang.org/x/vuln/cmd/govulncheck".
+func ValidateInstallPath(path string) error {
+	if strings.ContainsAny(path, "@ \t\\") || strings.HasPrefix(path, "/") || strings.HasSuffix(path, "/") || strings.Contains(path, "//") {
+		return fmt.Errorf("invalid package path %q", path)
+	}
+	first, _, _ := strings.Cut(path, "/")
+	if !strings.Contains(first, ".") {
+		return fmt.Errorf("invalid package path %q: the first path element must be a domain name", path)
+	}
+	return nil
+}
+
+// ValidateGOPROXY checks a GOPROXY list: proxy URLs and the keywords "direct"
+// and "off", separated by commas or pipes.
+func ValidateGOPROXY(value string) error {
+	for _, entry := range strings.FieldsFunc(value, func(r rune) bool { return r == ',' || r == '|' }) {
+		if entry == "direct" || entry == "off" {
+			continue
+		}
+		u, err := url.Parse(entry)
+		if err != nil {
+			return fmt.Errorf("invalid GOPROXY entry %q: %v", entry, err)
+		}
+		switch u.Scheme {
+		case "https", "http":
+			if u.Host == "" {
+				return fmt.Errorf("invalid GOPROXY entry %q: missing host", entry)
+			}
+		case "file":
+		default:
+			return fmt.Errorf("invalid GOPROXY entry %q: expected an http, https, or file URL, \"direct\", or \"off\"", entry)
+		}
+	}
+	if strings.HasPrefix(value, ",") || strings.HasPrefix(value, "|") || strings.HasSuffix(value, ",") || strings.HasSuffix(value, "|") ||
+		strings.Contains(value, ",,") || strings.Contains(value, "||") {
+		return fmt.Errorf("invalid GOPROXY %q: empty entry", value)
+	}
+	return nil
+}
@@ -0,0 +1,144 @@
+package config
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestLoadRejectsUnknownKeys(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	content := "apps:\n  - name: app1\ngithub_auth: false\ngoproxyy: https://proxy.golang.org\n"
+	if err := os.WriteFile(path, []byte(content), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	_, err := Load(path)
+	var invalid *ValidationError
+	if !errors.As(err, &invalid) {
+		t.Fatalf("expected ValidationError, got %v", err)
+	}
+	if len(invalid.Problems) != 1 || !strings.Contains(invalid.Problems[0], `unknown key "goproxyy"`) {
+		t.Fatalf("unexpected problems: %q", invalid.Problems)
+	}
+}
+
+func TestLoadProfileLenientReportsProblems(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	content := "apps:\n  - name: app1\n  - name: app1\ngoproxyy: https://proxy.golang.org\nretries: many\nprofiles:\n  work:\n    apps:\n      - name: worktool\n"
+	if err := os.WriteFile(path, []byte(content), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	cfg, problems, err := LoadProfileLenient(path, "")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(cfg.Apps) != 2 || len(problems) != 3 || !strings.Contains(problems[2], "duplicate") {
+		t.Fatalf("unexpected lenient load: %+v, %q", cfg, problems)
+	}
+
+	cfg, _, err = LoadProfileLenient(path, "work")
+	if err != nil || !HasApp(cfg, "worktool") {
+		t.Fatalf("expected the work profile, got %+v, %v", cfg, err)
+	}
+
+	if err := os.WriteFile(path, []byte("apps: [\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	var corrupt *CorruptError
+	if _, _, err := LoadProfileLenient(path, ""); !errors.As(err, &corrupt) {
+		t.Fatalf("expected CorruptError for unparseable YAML, got %v", err)
+	}
+}
+
+func TestValidate(t *testing.T) {
+	tooManyRetries := 50
+	cfg := &Config{
+		Apps: []App{
+			{Name: "tool"},
+			{Name: "tool"},
+			{Name: "other", InstallPath: "cmd/other"},
+			{Name: ""},
+			{Name: "slow", CacheTTL: "forever"},
+		},
+		GOPROXY:     "htps://proxy.golang.org,direct",
+		HTTPTimeout: "0s",
+		Retries:     &tooManyRetries,
+		GitHubHosts: []GitHubHost{
+			{Host: "github.corp.example", APIURL: "https://github.corp.example/api/v3"},
+			{Host: "github.corp.example"},
+			{Host: "ghe.example", APIURL: "ghe.example/api"},
+		},
+		ReleaseHosts: []ReleaseHost{
+			{Host: "gitlab.corp.example", Provider: "gitlab"},
+			{Host: "ghe.example", Provider: "gitea"},
+		},
+		Profiles: map[string]*Profile{
+			"work": {Apps: []App{{Name: "tool"}}, GOPROXY: "https://proxy.example.com|off"},
+			"ci":   {Apps: []App{{Name: "a"}, {Name: "a"}}},
+		},
+	}
+
+	RegisterCheck(func(*Config) []string { return []string{"checked by a registered check"} })
+	t.Cleanup(func() { checks = nil })
+
+	problems := Validate(cfg)
+
+	want := []string{
+		`apps[1]: duplicate app name "tool"`,
+		`apps[2].install_path: invalid package path "cmd/other"`,
+		"apps[3]: name is required",
+		"apps[4].cache_ttl:",
+		`goproxy: invalid GOPROXY entry "htps://proxy.golang.org"`,
+		"http_timeout: timeout must be greater than zero",
+		"retries: expected a whole number from 0 to 10",
+		`github_hosts[1]: duplicate host "github.corp.example"`,
+		`github_hosts[2].api_url: invalid URL "ghe.example/api"`,
+		`release_hosts[1]: duplicate host "ghe.example"`,
+		`profiles.ci.apps[1]: duplicate app name "a"`,
+		"checked by a registered check",
+	}
+	if len(problems) != len(want) {
+		t.Fatalf("expected %d problems, got %d: %q", len(want), len(problems), problems)
+	}
+	for i, prefix := range want {
+		if !strings.HasPrefix(problems[i], prefix) {
+			t.Errorf("problem %d = %q, want prefix %q", i, problems[i], prefix)
+		}
+	}
+}
+
+func TestValidateGOPROXY(t *testing.T) {
+	valid := []string{
+		"https://proxy.golang.org,direct",
+		"https://a.example.com|https://b.example.com|off",
+		"file:///srv/goproxy",
+		"direct",
+	}
+	for _, value := range valid {
+		if err := ValidateGOPROXY(value); err != nil {
+			t.Errorf("ValidateGOPROXY(%q) unexpected error: %v", value, err)
+		}
+	}
+
+	invalid := []string{"htps://proxy.golang.org", "proxy.golang.org", "https://", "https://a.example.com,,direct", "direct,"}
+	for _, value := range invalid {
+		if err := ValidateGOPROXY(value); err == nil {
+			t.Errorf("ValidateGOPROXY(%q) expected error", value)
+		}
+	}
+}
+
+func TestValidateInstallPath(t *testing.T) {
+	if err := ValidateInstallPath("golang.org/x/vuln/cmd/govulncheck"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	for _, path := range []string{"cmd/tool", "/abs/path", "example.com/tool@latest", "example.com/tool/", "example.com//tool"} {
+		if err := ValidateInstallPath(path); err == nil {
+			t.Errorf("ValidateInstallPath(%q) expected error", path)
+		}
+	}
+}
@@ -0,0 +1,88 @@
+package fileutil
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+)
+
+// WriteAtomic writes data to path by writing a temporary file in the same
+// directory and renaming it into place, so readers never observe a partially
+// written file. The directory is created if needed. When path is a symlink,
+// the file it points to is replaced and the link is kept, so a config file
+// linked from a dotfiles repository stays linked.
+func WriteAtomic(path string, data []byte, perm os.FileMode) error {
+	if resolved, err := filepath.EvalSymlinks(path); err == nil {
+		path = resolved
+	}
+	dir := filepath.Dir(path)
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return err
+	}
+
+	tmp, err := os.CreateTemp(dir, "."+filepath.Base(path)+".tmp-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	if err := os.Chmod(tmp.Name(), perm); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
+
+// Lock is an advisory lock held on a lock file.
+type Lock struct {
+	f *os.File
+}
+
+// ErrLocked is returned by TryLock when another process holds the lock.
+var ErrLocked = errors.New("lock is held by another process")
+
+// TryLock takes an exclusive advisory lock on path without waiting, creating
+// the lock file if needed. It returns ErrLocked if the lock is already held.
+func TryLock(path string) (*Lock, error) {
+	return lock(path, false)
+}
+
+// WaitLock takes an exclusive advisory lock on path, waiting until it is
+// available.
+func WaitLock(path string) (*Lock, error) {
+	return lock(path, true)
+}
+
+func lock(path string, wait bool) (*Lock, error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return nil, err
+	}
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_RDWR, 0600)
+	if err != nil {
+		return nil, err
+	}
+	if err := lockFile(f, wait); err != nil {
+		f.Close()
+		return nil, err
+	}
+	return &Lock{f: f}, nil
+}
+
+// Unlock releases the lock.
+func (l *Lock) Unlock() error {
+	if err := unlockFile(l.f); err != nil {
+		l.f.Close()
+		return err
+	}
+	return l.f.Close()
+}
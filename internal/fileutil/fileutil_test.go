@@ -0,0 +1,89 @@
+package fileutil
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+)
+
+func TestWriteAtomic(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "nested", "file.yaml")
+
+	if err := WriteAtomic(path, []byte("first"), 0600); err != nil {
+		t.Fatalf("WriteAtomic() error = %v", err)
+	}
+	if err := WriteAtomic(path, []byte("second"), 0600); err != nil {
+		t.Fatalf("WriteAtomic() error = %v", err)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil || string(data) != "second" {
+		t.Fatalf("unexpected content %q (err=%v)", data, err)
+	}
+	info, err := os.Stat(path)
+	if err != nil || info.Mode().Perm() != 0600 {
+		t.Fatalf("unexpected mode %v (err=%v)", info.Mode(), err)
+	}
+	entries, _ := os.ReadDir(filepath.Dir(path))
+	if len(entries) != 1 {
+		t.Fatalf("expected temporary files to be cleaned up, got %d entries", len(entries))
+	}
+}
+
+func TestWriteAtomicKeepsSymlink(t *testing.T) {
+	dir := t.TempDir()
+	target := filepath.Join(dir, "dotfiles", "config.yaml")
+	if err := WriteAtomic(target, []byte("first"), 0600); err != nil {
+		t.Fatalf("WriteAtomic() error = %v", err)
+	}
+	link := filepath.Join(dir, "config", "config.yaml")
+	if err := os.MkdirAll(filepath.Dir(link), 0700); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Symlink(target, link); err != nil {
+		t.Skipf("symlinks are not available: %v", err)
+	}
+
+	if err := WriteAtomic(link, []byte("second"), 0600); err != nil {
+		t.Fatalf("WriteAtomic() error = %v", err)
+	}
+
+	info, err := os.Lstat(link)
+	if err != nil || info.Mode()&os.ModeSymlink == 0 {
+		t.Fatalf("expected %s to remain a symlink (err=%v)", link, err)
+	}
+	data, err := os.ReadFile(target)
+	if err != nil || string(data) != "second" {
+		t.Fatalf("unexpected target content %q (err=%v)", data, err)
+	}
+	if entries, _ := os.ReadDir(filepath.Dir(link)); len(entries) != 1 {
+		t.Fatalf("expected only the symlink next to it, got %d entries", len(entries))
+	}
+}
+
+func TestTryLockHeld(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("advisory locking is not implemented on Windows")
+	}
+	path := filepath.Join(t.TempDir(), "gogitup.lock")
+
+	l, err := TryLock(path)
+	if err != nil {
+		t.Fatalf("TryLock() error = %v", err)
+	}
+	if _, err := TryLock(path); !errors.Is(err, ErrLocked) {
+		t.Fatalf("expected ErrLocked while held, got %v", err)
+	}
+	if err := l.Unlock(); err != nil {
+		t.Fatalf("Unlock() error = %v", err)
+	}
+
+	l, err = TryLock(path)
+	if err != nil {
+		t.Fatalf("expected lock after release, got %v", err)
+	}
+	_ = l.Unlock()
+}
@@ -0,0 +1,15 @@
+//go:build !unix
+
+package fileutil
+
+import "os"
+
+// Advisory locking is only implemented on Unix. Elsewhere locks always succeed
+// and concurrent writers rely on WriteAtomic alone.
+func lockFile(f *os.File, wait bool) error {
+	return nil
+}
+
+func unlockFile(f *os.File) error {
+	return nil
+}
@@ -4,11 +4,14 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"net"
 	"net/http"
-	"os"
-	"os/exec"
+	"net/url"
+	"strconv"
 	"strings"
 	"time"
+
+	"github.com/UnitVectorY-Labs/gogitup/internal/retry"
 )
 
 // Client is an interface for retrieving the latest release version from GitHub.
@@ -16,10 +19,48 @@ type Client interface {
 	GetLatestRelease(owner, repo string) (string, error)
 }
 
+// DefaultBaseURL is the base URL of the public GitHub REST API.
+const DefaultBaseURL = "https://api.github.com"
+
 // DefaultClient implements Client using the GitHub REST API.
 type DefaultClient struct {
 	token      string
+	baseURL    string
 	httpClient *http.Client
+	responses  ResponseCache
+	// exhaustedUntil is set when GitHub reports that no requests remain;
+	// requests fail fast with a RateLimitError until then. The GraphQL API
+	// has a separate limit, tracked in graphQLExhaustedUntil.
+	exhaustedUntil        time.Time
+	graphQLExhaustedUntil time.Time
+}
+
+// CachedResponse holds the validators of an earlier response and the value
+// parsed from it, so a conditional request answered with 304 Not Modified can
+// be served without downloading or parsing the body again.
+type CachedResponse struct {
+	ETag         string
+	LastModified string
+	Value        string
+}
+
+// ResponseCache stores cached responses keyed by request URL.
+type ResponseCache interface {
+	GetResponse(url string) (CachedResponse, bool)
+	SetResponse(url string, response CachedResponse)
+}
+
+// RateLimitError is returned when the GitHub API rate limit is exhausted.
+type RateLimitError struct {
+	Reset time.Time
+}
+
+func (e *RateLimitError) Error() string {
+	wait := time.Until(e.Reset).Round(time.Minute)
+	if wait < time.Minute {
+		wait = time.Minute
+	}
+	return fmt.Sprintf("GitHub API rate limit exceeded; it resets at %s (in about %s)", e.Reset.Local().Format("15:04"), strings.TrimSuffix(wait.String(), "0s"))
 }
 
 // releaseResponse represents the relevant fields from the GitHub releases API.
@@ -29,26 +70,59 @@ type releaseResponse struct {
 
 // NewDefaultClient creates a new DefaultClient with an optional auth token.
 func NewDefaultClient(token string) *DefaultClient {
+	return NewClientWithBaseURL(DefaultBaseURL, token)
+}
+
+// NewClientWithBaseURL creates a DefaultClient for the GitHub API at baseURL,
+// such as a GitHub Enterprise server, with an optional auth token.
+func NewClientWithBaseURL(baseURL, token string) *DefaultClient {
 	return &DefaultClient{
-		token: token,
-		httpClient: &http.Client{
-			Timeout: 30 * time.Second,
-		},
+		token:      token,
+		baseURL:    strings.TrimSuffix(baseURL, "/"),
+		httpClient: retry.NewHTTPClient(retry.DefaultTimeout, retry.DefaultPolicy, nil),
 	}
 }
 
+// SetHTTPClient replaces the HTTP client used for API requests, such as one
+// with a configured timeout and retry policy.
+func (c *DefaultClient) SetHTTPClient(httpClient *http.Client) {
+	c.httpClient = httpClient
+}
+
+// EnterpriseBaseURL returns the default REST API base URL of a GitHub
+// Enterprise Server at host.
+func EnterpriseBaseURL(host string) string {
+	return "https://" + host + "/api/v3"
+}
+
+// SetResponseCache makes the client send conditional requests using the
+// ETag and Last-Modified validators stored in responses. Responses of 304 Not
+// Modified do not count against the GitHub rate limit.
+func (c *DefaultClient) SetResponseCache(responses ResponseCache) {
+	c.responses = responses
+}
+
 // GetLatestRelease fetches the latest release tag name for the given owner/repo.
 func (c *DefaultClient) GetLatestRelease(owner, repo string) (string, error) {
-	url := fmt.Sprintf("https://api.github.com/repos/%s/%s/releases/latest", owner, repo)
+	if time.Now().Before(c.exhaustedUntil) {
+		return "", &RateLimitError{Reset: c.exhaustedUntil}
+	}
 
-	req, err := http.NewRequest("GET", url, nil)
+	req, err := c.newRequest(fmt.Sprintf("/repos/%s/%s/releases/latest", owner, repo))
 	if err != nil {
-		return "", fmt.Errorf("failed to create request: %w", err)
+		return "", err
 	}
-
-	req.Header.Set("Accept", "application/vnd.github.v3+json")
-	if c.token != "" {
-		req.Header.Set("Authorization", "Bearer "+c.token)
+	key := req.URL.String()
+	cached, haveCached := CachedResponse{}, false
+	if c.responses != nil {
+		if cached, haveCached = c.responses.GetResponse(key); haveCached {
+			if cached.ETag != "" {
+				req.Header.Set("If-None-Match", cached.ETag)
+			}
+			if cached.LastModified != "" {
+				req.Header.Set("If-Modified-Since", cached.LastModified)
+			}
+		}
 	}
 
 	resp, err := c.httpClient.Do(req)
@@ -57,6 +131,12 @@ func (c *DefaultClient) GetLatestRelease(owner, repo string) (string, error) {
 	}
 	defer resp.Body.Close()
 
+	if limitErr := recordRateLimit(resp, &c.exhaustedUntil); limitErr != nil {
+		return "", limitErr
+	}
+	if resp.StatusCode == http.StatusNotModified && haveCached {
+		return cached.Value, nil
+	}
 	if resp.StatusCode != http.StatusOK {
 		return "", fmt.Errorf("GitHub API returned status %d for %s/%s", resp.StatusCode, owner, repo)
 	}
@@ -70,25 +150,141 @@ func (c *DefaultClient) GetLatestRelease(owner, repo string) (string, error) {
 		return "", errors.New("no release tag found for " + owner + "/" + repo)
 	}
 
+	if c.responses != nil {
+		c.responses.SetResponse(key, CachedResponse{
+			ETag:         resp.Header.Get("ETag"),
+			LastModified: resp.Header.Get("Last-Modified"),
+			Value:        release.TagName,
+		})
+	}
 	return release.TagName, nil
 }
 
-// ResolveToken determines the GitHub token to use for API requests.
-// If useAuth is false, returns an empty string. Otherwise it checks the
-// GITHUB_TOKEN environment variable and falls back to the gh CLI.
-func ResolveToken(useAuth bool) string {
-	if !useAuth {
-		return ""
+// recordRateLimit reads the rate limit headers of resp. Once no requests
+// remain, it sets exhaustedUntil so that later requests fail fast until the
+// limit resets. It returns a RateLimitError when resp itself was rejected
+// because of the rate limit.
+func recordRateLimit(resp *http.Response, exhaustedUntil *time.Time) error {
+	reset := time.Time{}
+	if secs, err := strconv.ParseInt(resp.Header.Get("X-RateLimit-Reset"), 10, 64); err == nil {
+		reset = time.Unix(secs, 0)
+	}
+	exhausted := resp.Header.Get("X-RateLimit-Remaining") == "0"
+	if exhausted && !reset.IsZero() {
+		*exhaustedUntil = reset
 	}
 
-	if token := os.Getenv("GITHUB_TOKEN"); token != "" {
-		return token
+	if resp.StatusCode != http.StatusForbidden && resp.StatusCode != http.StatusTooManyRequests {
+		return nil
+	}
+	// Secondary rate limits are reported with Retry-After instead.
+	if secs, err := strconv.Atoi(resp.Header.Get("Retry-After")); err == nil {
+		*exhaustedUntil = time.Now().Add(time.Duration(secs) * time.Second)
+		return &RateLimitError{Reset: *exhaustedUntil}
 	}
+	if exhausted {
+		if reset.IsZero() {
+			reset = time.Now().Add(time.Hour)
+		}
+		*exhaustedUntil = reset
+		return &RateLimitError{Reset: reset}
+	}
+	return nil
+}
+
+// RateLimit describes the core GitHub API rate limit for the client's credentials.
+type RateLimit struct {
+	Limit     int
+	Remaining int
+	Reset     time.Time
+}
 
-	out, err := exec.Command("gh", "auth", "token").Output()
+type rateLimitResponse struct {
+	Resources struct {
+		Core struct {
+			Limit     int   `json:"limit"`
+			Remaining int   `json:"remaining"`
+			Reset     int64 `json:"reset"`
+		} `json:"core"`
+	} `json:"resources"`
+}
+
+// ErrUnauthorized is returned when GitHub rejects the client's token.
+var ErrUnauthorized = errors.New("GitHub rejected the token (status 401)")
+
+// GetRateLimit fetches the current core rate limit. Requests to this endpoint
+// do not count against the limit, so it doubles as a cheap token check.
+func (c *DefaultClient) GetRateLimit() (RateLimit, error) {
+	req, err := c.newRequest("/rate_limit")
+	if err != nil {
+		return RateLimit{}, err
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return RateLimit{}, fmt.Errorf("failed to fetch rate limit: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusUnauthorized {
+		return RateLimit{}, ErrUnauthorized
+	}
+	if resp.StatusCode != http.StatusOK {
+		return RateLimit{}, fmt.Errorf("GitHub API returned status %d for rate limit", resp.StatusCode)
+	}
+
+	var body rateLimitResponse
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		return RateLimit{}, fmt.Errorf("failed to parse rate limit response: %w", err)
+	}
+	core := body.Resources.Core
+	return RateLimit{Limit: core.Limit, Remaining: core.Remaining, Reset: time.Unix(core.Reset, 0)}, nil
+}
+
+func (c *DefaultClient) newRequest(path string) (*http.Request, error) {
+	req, err := http.NewRequest("GET", c.baseURL+path, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+
+	req.Header.Set("Accept", "application/vnd.github.v3+json")
+	if c.token != "" {
+		req.Header.Set("Authorization", "Bearer "+c.token)
+	}
+	return req, nil
+}
+
+// Reachable reports whether the GitHub API at baseURL, or the HTTPS proxy
+// configured for it, accepts TCP connections within the timeout. It is a cheap
+// probe used to detect being offline before issuing slower API requests. A
+// nil proxy uses the proxy environment variables.
+func Reachable(baseURL string, proxy *url.URL, timeout time.Duration) bool {
+	req, err := http.NewRequest("GET", baseURL, nil)
 	if err != nil {
-		return ""
+		return false
+	}
+	addr := req.URL.Host
+	if req.URL.Port() == "" {
+		addr = net.JoinHostPort(req.URL.Hostname(), "443")
+		if req.URL.Scheme == "http" {
+			addr = net.JoinHostPort(req.URL.Hostname(), "80")
+		}
+	}
+	proxyURL, err := http.ProxyFromEnvironment(req)
+	if proxy != nil {
+		proxyURL, err = proxy, nil
+	}
+	if err == nil && proxyURL != nil {
+		addr = proxyURL.Host
+		if proxyURL.Port() == "" {
+			addr = net.JoinHostPort(proxyURL.Hostname(), "80")
+		}
 	}
 
-	return strings.TrimSpace(string(out))
+	conn, err := net.DialTimeout("tcp", addr, timeout)
+	if err != nil {
+		return false
+	}
+	conn.Close()
+	return true
 }
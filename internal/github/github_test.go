@@ -2,9 +2,13 @@ package github
 
 import (
 	"encoding/json"
+	"errors"
 	"net/http"
 	"net/http/httptest"
+	"strconv"
+	"strings"
 	"testing"
+	"time"
 )
 
 func TestGetLatestRelease_Success(t *testing.T) {
@@ -144,3 +148,169 @@ func mustNewRequest(t *testing.T, url string) *http.Request {
 	}
 	return req
 }
+
+func TestGetRateLimit(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/rate_limit" {
+			t.Errorf("unexpected path: %s", r.URL.Path)
+		}
+		if r.Header.Get("Authorization") != "Bearer test-token" {
+			t.Errorf("unexpected Authorization header: %s", r.Header.Get("Authorization"))
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"resources":{"core":{"limit":5000,"remaining":4990,"reset":1700000000}}}`))
+	}))
+	defer server.Close()
+
+	client := &DefaultClient{token: "test-token", baseURL: server.URL, httpClient: server.Client()}
+	limit, err := client.GetRateLimit()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if limit.Limit != 5000 || limit.Remaining != 4990 || limit.Reset.Unix() != 1700000000 {
+		t.Fatalf("unexpected rate limit: %+v", limit)
+	}
+}
+
+func TestGetRateLimit_Unauthorized(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusUnauthorized)
+	}))
+	defer server.Close()
+
+	client := &DefaultClient{token: "bad-token", baseURL: server.URL, httpClient: server.Client()}
+	if _, err := client.GetRateLimit(); !errors.Is(err, ErrUnauthorized) {
+		t.Fatalf("expected ErrUnauthorized, got %v", err)
+	}
+}
+
+func TestNewClientWithBaseURL_EnterpriseRelease(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/v3/repos/platform/tool/releases/latest" {
+			t.Errorf("unexpected path: %s", r.URL.Path)
+		}
+		if r.Header.Get("Authorization") != "Bearer ghe-token" {
+			t.Errorf("unexpected Authorization header: %s", r.Header.Get("Authorization"))
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(releaseResponse{TagName: "v2.0.0"})
+	}))
+	defer server.Close()
+
+	client := NewClientWithBaseURL(server.URL+"/api/v3/", "ghe-token")
+	tag, err := client.GetLatestRelease("platform", "tool")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if tag != "v2.0.0" {
+		t.Fatalf("expected tag v2.0.0, got %s", tag)
+	}
+}
+
+func TestEnterpriseBaseURL(t *testing.T) {
+	if got := EnterpriseBaseURL("github.corp.example"); got != "https://github.corp.example/api/v3" {
+		t.Fatalf("unexpected base URL: %s", got)
+	}
+}
+
+type memoryResponseCache map[string]CachedResponse
+
+func (m memoryResponseCache) GetResponse(url string) (CachedResponse, bool) {
+	r, ok := m[url]
+	return r, ok
+}
+
+func (m memoryResponseCache) SetResponse(url string, response CachedResponse) {
+	m[url] = response
+}
+
+func TestGetLatestRelease_ConditionalRequest(t *testing.T) {
+	requests := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		if r.Header.Get("If-None-Match") == `"abc"` {
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		w.Header().Set("ETag", `"abc"`)
+		json.NewEncoder(w).Encode(releaseResponse{TagName: "v1.2.3"})
+	}))
+	defer server.Close()
+
+	responses := memoryResponseCache{}
+	client := NewClientWithBaseURL(server.URL, "")
+	client.SetResponseCache(responses)
+
+	for range 2 {
+		tag, err := client.GetLatestRelease("owner", "repo")
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if tag != "v1.2.3" {
+			t.Fatalf("expected tag v1.2.3, got %s", tag)
+		}
+	}
+	if requests != 2 {
+		t.Fatalf("expected 2 requests, got %d", requests)
+	}
+	if responses[server.URL+"/repos/owner/repo/releases/latest"].ETag != `"abc"` {
+		t.Fatalf("expected ETag to be stored, got %+v", responses)
+	}
+}
+
+func TestGetLatestRelease_RateLimitExhausted(t *testing.T) {
+	reset := time.Now().Add(30 * time.Minute).Truncate(time.Second)
+	requests := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		w.Header().Set("X-RateLimit-Remaining", "0")
+		w.Header().Set("X-RateLimit-Reset", strconv.FormatInt(reset.Unix(), 10))
+		w.WriteHeader(http.StatusForbidden)
+	}))
+	defer server.Close()
+
+	client := NewClientWithBaseURL(server.URL, "")
+	for range 3 {
+		_, err := client.GetLatestRelease("owner", "repo")
+		var limitErr *RateLimitError
+		if !errors.As(err, &limitErr) {
+			t.Fatalf("expected RateLimitError, got %v", err)
+		}
+		if !limitErr.Reset.Equal(reset) {
+			t.Fatalf("expected reset %v, got %v", reset, limitErr.Reset)
+		}
+	}
+	if requests != 1 {
+		t.Fatalf("expected requests to stop after the limit was exhausted, got %d", requests)
+	}
+}
+
+func TestGetLatestRelease_LastRemainingRequest(t *testing.T) {
+	requests := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		w.Header().Set("X-RateLimit-Remaining", "0")
+		w.Header().Set("X-RateLimit-Reset", strconv.FormatInt(time.Now().Add(time.Hour).Unix(), 10))
+		json.NewEncoder(w).Encode(releaseResponse{TagName: "v1.0.0"})
+	}))
+	defer server.Close()
+
+	client := NewClientWithBaseURL(server.URL, "")
+	if tag, err := client.GetLatestRelease("owner", "repo"); err != nil || tag != "v1.0.0" {
+		t.Fatalf("expected v1.0.0, got %q (err=%v)", tag, err)
+	}
+	var limitErr *RateLimitError
+	if _, err := client.GetLatestRelease("owner", "other"); !errors.As(err, &limitErr) {
+		t.Fatalf("expected RateLimitError, got %v", err)
+	}
+	if requests != 1 {
+		t.Fatalf("expected 1 request, got %d", requests)
+	}
+}
+
+func TestRateLimitErrorMessage(t *testing.T) {
+	err := &RateLimitError{Reset: time.Now().Add(25 * time.Minute)}
+	if !strings.Contains(err.Error(), "resets at") || !strings.Contains(err.Error(), "in about 25m") {
+		t.Fatalf("unexpected message: %s", err.Error())
+	}
+}
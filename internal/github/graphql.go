@@ -0,0 +1,186 @@
+package github
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// Repo identifies a GitHub repository.
+type Repo struct {
+	Owner string
+	Name  string
+}
+
+// Prefetcher is implemented by clients that can look up the latest releases
+// of many repositories at once, ahead of individual GetLatestRelease calls.
+type Prefetcher interface {
+	Prefetch(repos []Repo) error
+}
+
+// graphQLBatchSize is the number of repositories looked up per GraphQL query,
+// well below GitHub's node limits.
+const graphQLBatchSize = 50
+
+// HasToken reports whether the client sends authenticated requests. The
+// GraphQL API is only available to authenticated clients.
+func (c *DefaultClient) HasToken() bool {
+	return c.token != ""
+}
+
+// graphQLURL returns the GraphQL endpoint that belongs to the REST base URL:
+// https://api.github.com/graphql, or /api/graphql on GitHub Enterprise.
+func (c *DefaultClient) graphQLURL() string {
+	if base, ok := strings.CutSuffix(c.baseURL, "/api/v3"); ok {
+		return base + "/api/graphql"
+	}
+	return c.baseURL + "/graphql"
+}
+
+type graphQLRequest struct {
+	Query     string            `json:"query"`
+	Variables map[string]string `json:"variables"`
+}
+
+type graphQLResponse struct {
+	Data map[string]*struct {
+		LatestRelease *struct {
+			TagName string `json:"tagName"`
+		} `json:"latestRelease"`
+	} `json:"data"`
+	Errors []struct {
+		Type    string `json:"type"`
+		Message string `json:"message"`
+	} `json:"errors"`
+}
+
+// GetLatestReleases looks up the latest release tags of repos with one
+// GraphQL query per batch of repositories. Repositories that do not exist or
+// have no release are missing from the result.
+func (c *DefaultClient) GetLatestReleases(repos []Repo) (map[Repo]string, error) {
+	tags := make(map[Repo]string, len(repos))
+	for start := 0; start < len(repos); start += graphQLBatchSize {
+		batch := repos[start:min(start+graphQLBatchSize, len(repos))]
+		if err := c.getLatestReleaseBatch(batch, tags); err != nil {
+			return tags, err
+		}
+	}
+	return tags, nil
+}
+
+func (c *DefaultClient) getLatestReleaseBatch(repos []Repo, tags map[Repo]string) error {
+	if time.Now().Before(c.graphQLExhaustedUntil) {
+		return &RateLimitError{Reset: c.graphQLExhaustedUntil}
+	}
+
+	var query, params strings.Builder
+	variables := make(map[string]string, 2*len(repos))
+	for i, r := range repos {
+		fmt.Fprintf(&params, "$o%d: String!, $n%d: String!, ", i, i)
+		fmt.Fprintf(&query, "r%d: repository(owner: $o%d, name: $n%d) { latestRelease { tagName } } ", i, i, i)
+		variables[fmt.Sprintf("o%d", i)] = r.Owner
+		variables[fmt.Sprintf("n%d", i)] = r.Name
+	}
+	body, err := json.Marshal(graphQLRequest{
+		Query:     fmt.Sprintf("query(%s) { %s}", strings.TrimSuffix(params.String(), ", "), query.String()),
+		Variables: variables,
+	})
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequest("POST", c.graphQLURL(), bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if c.token != "" {
+		req.Header.Set("Authorization", "Bearer "+c.token)
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to query latest releases: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if limitErr := recordRateLimit(resp, &c.graphQLExhaustedUntil); limitErr != nil {
+		return limitErr
+	}
+	if resp.StatusCode == http.StatusUnauthorized {
+		return ErrUnauthorized
+	}
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("GitHub GraphQL API returned status %d", resp.StatusCode)
+	}
+
+	var result graphQLResponse
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return fmt.Errorf("failed to parse GraphQL response: %w", err)
+	}
+	// A query over the GraphQL rate limit is rejected with 200 OK and a
+	// RATE_LIMITED error rather than with a 403.
+	for _, e := range result.Errors {
+		if e.Type == "RATE_LIMITED" {
+			if !time.Now().Before(c.graphQLExhaustedUntil) {
+				c.graphQLExhaustedUntil = time.Now().Add(time.Hour)
+			}
+			return &RateLimitError{Reset: c.graphQLExhaustedUntil}
+		}
+	}
+	// Missing repositories are reported as errors alongside partial data, so
+	// errors only fail the batch when no data came back at all.
+	if result.Data == nil && len(result.Errors) > 0 {
+		return fmt.Errorf("GitHub GraphQL API error: %s", result.Errors[0].Message)
+	}
+	for i, r := range repos {
+		if node := result.Data[fmt.Sprintf("r%d", i)]; node != nil && node.LatestRelease != nil && node.LatestRelease.TagName != "" {
+			tags[r] = node.LatestRelease.TagName
+		}
+	}
+	return nil
+}
+
+// BatchClient serves latest release lookups from tags prefetched through the
+// GraphQL API, falling back to one REST request per repository for anything
+// that was not prefetched.
+type BatchClient struct {
+	rest *DefaultClient
+	tags map[Repo]string
+}
+
+// NewBatchClient wraps an authenticated REST client.
+func NewBatchClient(rest *DefaultClient) *BatchClient {
+	return &BatchClient{rest: rest, tags: make(map[Repo]string)}
+}
+
+// Prefetch looks up the latest releases of repos in batches. On error, the
+// tags fetched so far are kept and the rest are looked up individually.
+func (c *BatchClient) Prefetch(repos []Repo) error {
+	var pending []Repo
+	for _, r := range repos {
+		if _, ok := c.tags[r]; !ok {
+			pending = append(pending, r)
+		}
+	}
+	if len(pending) == 0 {
+		return nil
+	}
+	tags, err := c.rest.GetLatestReleases(pending)
+	for r, tag := range tags {
+		c.tags[r] = tag
+	}
+	return err
+}
+
+// GetLatestRelease returns the prefetched tag for owner/repo, or fetches it
+// through the REST API.
+func (c *BatchClient) GetLatestRelease(owner, repo string) (string, error) {
+	if tag, ok := c.tags[Repo{Owner: owner, Name: repo}]; ok {
+		return tag, nil
+	}
+	return c.rest.GetLatestRelease(owner, repo)
+}
@@ -0,0 +1,150 @@
+package github
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+)
+
+func TestGetLatestReleases_Batches(t *testing.T) {
+	var queries int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != "POST" || r.URL.Path != "/graphql" {
+			t.Errorf("unexpected request %s %s", r.Method, r.URL.Path)
+		}
+		if got := r.Header.Get("Authorization"); got != "Bearer test-token" {
+			t.Errorf("expected bearer token, got %q", got)
+		}
+		var req graphQLRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			t.Fatalf("failed to decode request: %v", err)
+		}
+		queries++
+		data := map[string]any{}
+		for i := 0; i < len(req.Variables)/2; i++ {
+			name := req.Variables[fmt.Sprintf("n%d", i)]
+			switch name {
+			case "missing":
+				data[fmt.Sprintf("r%d", i)] = nil
+			case "norelease":
+				data[fmt.Sprintf("r%d", i)] = map[string]any{"latestRelease": nil}
+			default:
+				data[fmt.Sprintf("r%d", i)] = map[string]any{"latestRelease": map[string]string{"tagName": "v1.0.0-" + name}}
+			}
+		}
+		json.NewEncoder(w).Encode(map[string]any{
+			"data":   data,
+			"errors": []map[string]string{{"message": "Could not resolve to a Repository"}},
+		})
+	}))
+	defer server.Close()
+
+	repos := []Repo{{Owner: "o", Name: "missing"}, {Owner: "o", Name: "norelease"}}
+	for i := 0; i < graphQLBatchSize+5; i++ {
+		repos = append(repos, Repo{Owner: "o", Name: fmt.Sprintf("repo%d", i)})
+	}
+
+	client := NewClientWithBaseURL(server.URL, "test-token")
+	tags, err := client.GetLatestReleases(repos)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if queries != 2 {
+		t.Fatalf("expected 2 queries, got %d", queries)
+	}
+	if len(tags) != graphQLBatchSize+5 {
+		t.Fatalf("expected %d tags, got %d", graphQLBatchSize+5, len(tags))
+	}
+	if tags[Repo{Owner: "o", Name: "repo52"}] != "v1.0.0-repo52" {
+		t.Fatalf("unexpected tag: %q", tags[Repo{Owner: "o", Name: "repo52"}])
+	}
+	if _, ok := tags[Repo{Owner: "o", Name: "missing"}]; ok {
+		t.Fatal("expected missing repository to be absent")
+	}
+}
+
+func TestGraphQLURL(t *testing.T) {
+	if got := NewDefaultClient("").graphQLURL(); got != "https://api.github.com/graphql" {
+		t.Fatalf("unexpected URL: %s", got)
+	}
+	if got := NewClientWithBaseURL(EnterpriseBaseURL("github.example.com"), "").graphQLURL(); got != "https://github.example.com/api/graphql" {
+		t.Fatalf("unexpected URL: %s", got)
+	}
+}
+
+func TestBatchClient_FallsBackToREST(t *testing.T) {
+	var restCalls int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/graphql" {
+			w.WriteHeader(http.StatusBadGateway)
+			return
+		}
+		restCalls++
+		w.Write([]byte(`{"tag_name": "v2.0.0"}`))
+	}))
+	defer server.Close()
+
+	client := NewBatchClient(NewClientWithBaseURL(server.URL, "test-token"))
+	if err := client.Prefetch([]Repo{{Owner: "o", Name: "r"}}); err == nil {
+		t.Fatal("expected prefetch error")
+	}
+	tag, err := client.GetLatestRelease("o", "r")
+	if err != nil || tag != "v2.0.0" {
+		t.Fatalf("expected REST fallback result, got %q, %v", tag, err)
+	}
+	if restCalls != 1 {
+		t.Fatalf("expected 1 REST call, got %d", restCalls)
+	}
+}
+
+func TestBatchClient_ServesPrefetchedTags(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/graphql" {
+			t.Errorf("unexpected REST request %s", r.URL.Path)
+		}
+		w.Write([]byte(`{"data": {"r0": {"latestRelease": {"tagName": "v3.1.0"}}}}`))
+	}))
+	defer server.Close()
+
+	client := NewBatchClient(NewClientWithBaseURL(server.URL, "test-token"))
+	if err := client.Prefetch([]Repo{{Owner: "o", Name: "r"}}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	tag, err := client.GetLatestRelease("o", "r")
+	if err != nil || tag != "v3.1.0" {
+		t.Fatalf("expected prefetched tag, got %q, %v", tag, err)
+	}
+}
+
+func TestGetLatestReleases_RecordsRateLimit(t *testing.T) {
+	var queries int
+	reset := time.Now().Add(30 * time.Minute).Unix()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		queries++
+		w.Header().Set("X-RateLimit-Remaining", "0")
+		w.Header().Set("X-RateLimit-Reset", strconv.FormatInt(reset, 10))
+		w.Write([]byte(`{"data": null, "errors": [{"type": "RATE_LIMITED", "message": "API rate limit exceeded"}]}`))
+	}))
+	defer server.Close()
+
+	client := NewClientWithBaseURL(server.URL, "test-token")
+	repos := []Repo{{Owner: "o", Name: "r"}}
+	var limitErr *RateLimitError
+	if _, err := client.GetLatestReleases(repos); !errors.As(err, &limitErr) || limitErr.Reset.Unix() != reset {
+		t.Fatalf("expected RateLimitError resetting at %d, got %v", reset, err)
+	}
+	if _, err := client.GetLatestReleases(repos); !errors.As(err, &limitErr) {
+		t.Fatalf("expected RateLimitError while exhausted, got %v", err)
+	}
+	if queries != 1 {
+		t.Fatalf("expected later queries to fail fast, got %d queries", queries)
+	}
+	if !client.exhaustedUntil.IsZero() {
+		t.Fatal("expected the REST rate limit to be tracked separately")
+	}
+}
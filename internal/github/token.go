@@ -0,0 +1,289 @@
+package github
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+)
+
+// Token sources, tried in the order of TokenSources unless configured
+// otherwise.
+const (
+	// SourceEnv reads the GITHUB_TOKEN and GH_TOKEN environment variables, or
+	// GH_ENTERPRISE_TOKEN and GITHUB_ENTERPRISE_TOKEN for an Enterprise host.
+	SourceEnv = "env"
+	// SourceFile reads the token from a file.
+	SourceFile = "file"
+	// SourceCommand runs a credential helper command that prints the token.
+	SourceCommand = "command"
+	// SourceGH asks the gh CLI with gh auth token.
+	SourceGH = "gh"
+	// SourceNetrc reads the password of api.github.com or github.com, or of
+	// the Enterprise host, from ~/.netrc.
+	SourceNetrc = "netrc"
+	// SourceKeyring reads the token from the OS keyring: the Secret Service
+	// through secret-tool on Linux, or the login keychain on macOS.
+	SourceKeyring = "keyring"
+)
+
+// TokenSources lists every token source in the default order.
+var TokenSources = []string{SourceEnv, SourceFile, SourceCommand, SourceGH, SourceNetrc, SourceKeyring}
+
+// DefaultKeyringService is the keyring service name tokens are stored under.
+const DefaultKeyringService = "gogitup"
+
+// defaultHost is the host tokens are looked up for when TokenConfig.Host is
+// empty.
+const defaultHost = "github.com"
+
+// TokenConfig configures where LookupToken looks for a token.
+type TokenConfig struct {
+	// Host is the GitHub Enterprise host the token is for; empty means
+	// github.com. It selects the environment variables, the gh host, the
+	// netrc machine, and the keyring account that are read.
+	Host string
+	// TokenEnv names an environment variable the env source reads before
+	// the default ones.
+	TokenEnv string
+	// Sources are tried in order; nil means TokenSources. The file and
+	// command sources are skipped when File or Command is empty.
+	Sources        []string
+	File           string
+	Command        string
+	KeyringService string
+}
+
+// host returns the host the token is for.
+func (tc TokenConfig) host() string {
+	if tc.Host == "" {
+		return defaultHost
+	}
+	return tc.Host
+}
+
+// envNames returns the environment variables the env source reads, in order.
+func (tc TokenConfig) envNames() []string {
+	names := []string{"GITHUB_TOKEN", "GH_TOKEN"}
+	if tc.host() != defaultHost {
+		names = []string{"GH_ENTERPRISE_TOKEN", "GITHUB_ENTERPRISE_TOKEN"}
+	}
+	if tc.TokenEnv != "" {
+		names = append([]string{tc.TokenEnv}, names...)
+	}
+	return names
+}
+
+// netrcMachines returns the netrc machines the netrc source reads, in order.
+func (tc TokenConfig) netrcMachines() []string {
+	if tc.host() != defaultHost {
+		return []string{tc.host()}
+	}
+	return []string{"api.github.com", "github.com"}
+}
+
+// Token is a resolved token and where it was found, such as "GH_TOKEN" or
+// "gh auth token". Source never contains the token itself.
+type Token struct {
+	Value  string
+	Source string
+}
+
+// LookupToken tries each configured source in turn and returns the first
+// token found, or an empty Token. Sources that are configured but fail, such
+// as a credential helper that exits with an error, are reported in errs.
+func LookupToken(tc TokenConfig) (token Token, errs []error) {
+	sources := tc.Sources
+	if sources == nil {
+		sources = TokenSources
+	}
+	for _, source := range sources {
+		token, err := lookupSource(tc, source)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("token source %s: %w", source, err))
+			continue
+		}
+		if token.Value != "" {
+			return token, errs
+		}
+	}
+	return Token{}, errs
+}
+
+func lookupSource(tc TokenConfig, source string) (Token, error) {
+	switch source {
+	case SourceEnv:
+		for _, name := range tc.envNames() {
+			if value := os.Getenv(name); value != "" {
+				return Token{Value: value, Source: name}, nil
+			}
+		}
+	case SourceFile:
+		if tc.File == "" {
+			return Token{}, nil
+		}
+		data, err := os.ReadFile(tc.File)
+		if err != nil {
+			return Token{}, err
+		}
+		return Token{Value: firstLine(data), Source: "file " + tc.File}, nil
+	case SourceCommand:
+		if tc.Command == "" {
+			return Token{}, nil
+		}
+		out, err := shellCommand(tc.Command).Output()
+		if err != nil {
+			return Token{}, fmt.Errorf("%q failed: %w", tc.Command, err)
+		}
+		return Token{Value: firstLine(out), Source: "command " + tc.Command}, nil
+	case SourceGH:
+		// A missing or logged-out gh is not an error; gh is only a fallback.
+		args := []string{"auth", "token"}
+		if tc.host() != defaultHost {
+			args = append(args, "--hostname", tc.host())
+		}
+		if out, err := exec.Command("gh", args...).Output(); err == nil {
+			return Token{Value: firstLine(out), Source: "gh " + strings.Join(args, " ")}, nil
+		}
+	case SourceNetrc:
+		path, err := netrcPath()
+		if err != nil {
+			return Token{}, nil
+		}
+		data, err := os.ReadFile(path)
+		if errors.Is(err, os.ErrNotExist) {
+			return Token{}, nil
+		} else if err != nil {
+			return Token{}, err
+		}
+		for _, machine := range tc.netrcMachines() {
+			if password := NetrcPassword(string(data), machine); password != "" {
+				return Token{Value: password, Source: fmt.Sprintf("%s (machine %s)", path, machine)}, nil
+			}
+		}
+	case SourceKeyring:
+		service := tc.KeyringService
+		if service == "" {
+			service = DefaultKeyringService
+		}
+		cmd := keyringCommand(service, tc.host())
+		if cmd == nil {
+			return Token{}, nil
+		}
+		if _, err := exec.LookPath(cmd.Path); err != nil {
+			return Token{}, nil
+		}
+		// Both tools exit with an error when no item matches.
+		if out, err := cmd.Output(); err == nil {
+			return Token{Value: firstLine(out), Source: fmt.Sprintf("keyring (service %s)", service)}, nil
+		}
+	default:
+		return Token{}, errors.New("unknown source")
+	}
+	return Token{}, nil
+}
+
+// keyringCommand returns the command that prints the token stored in the OS
+// keyring under service for the account host, or nil when the OS keyring is
+// not supported.
+func keyringCommand(service, host string) *exec.Cmd {
+	switch runtime.GOOS {
+	case "linux", "freebsd", "openbsd", "netbsd":
+		return exec.Command("secret-tool", "lookup", "service", service, "account", host)
+	case "darwin":
+		return exec.Command("security", "find-generic-password", "-s", service, "-a", host, "-w")
+	}
+	return nil
+}
+
+func shellCommand(command string) *exec.Cmd {
+	if runtime.GOOS == "windows" {
+		return exec.Command("cmd", "/C", command)
+	}
+	return exec.Command("sh", "-c", command)
+}
+
+func firstLine(data []byte) string {
+	line, _, _ := strings.Cut(string(data), "\n")
+	return strings.TrimSpace(line)
+}
+
+// netrcPath returns $NETRC, or ~/.netrc (~/_netrc on Windows).
+func netrcPath() (string, error) {
+	if path := os.Getenv("NETRC"); path != "" {
+		return path, nil
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", err
+	}
+	name := ".netrc"
+	if runtime.GOOS == "windows" {
+		name = "_netrc"
+	}
+	return filepath.Join(home, name), nil
+}
+
+// NetrcPassword returns the password of the entry for machine in the netrc
+// file contents data, or an empty string when there is none. A default entry
+// is not used, so that its password is never sent to GitHub by accident.
+func NetrcPassword(data, machine string) string {
+	scanner := bufio.NewScanner(strings.NewReader(data))
+	var fields []string
+	inMacro := false
+	for scanner.Scan() {
+		line := scanner.Text()
+		if inMacro {
+			// Macro definitions run until the next blank line.
+			inMacro = strings.TrimSpace(line) != ""
+			continue
+		}
+		lineFields := strings.Fields(line)
+		for i, f := range lineFields {
+			if f == "macdef" {
+				lineFields = lineFields[:i]
+				inMacro = true
+				break
+			}
+		}
+		fields = append(fields, lineFields...)
+	}
+
+	current := ""
+	for i := 0; i < len(fields); i++ {
+		switch fields[i] {
+		case "machine":
+			if i+1 < len(fields) {
+				i++
+				current = fields[i]
+			}
+		case "default":
+			current = ""
+		case "login", "account":
+			i++
+		case "password":
+			if i+1 < len(fields) {
+				i++
+				if current == machine {
+					return fields[i]
+				}
+			}
+		}
+	}
+	return ""
+}
+
+// ResolveToken determines the GitHub token to use for API requests.
+// If useAuth is false, returns an empty string. Otherwise it tries the default
+// token sources in order.
+func ResolveToken(useAuth bool) string {
+	if !useAuth {
+		return ""
+	}
+	token, _ := LookupToken(TokenConfig{})
+	return token.Value
+}
@@ -0,0 +1,107 @@
+package github
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestLookupToken_Order(t *testing.T) {
+	t.Setenv("GITHUB_TOKEN", "")
+	t.Setenv("GH_TOKEN", "gh-env-token")
+	file := filepath.Join(t.TempDir(), "token")
+	if err := os.WriteFile(file, []byte("file-token\n"), 0600); err != nil {
+		t.Fatalf("failed to write token file: %v", err)
+	}
+
+	token, errs := LookupToken(TokenConfig{Sources: []string{SourceEnv, SourceFile}, File: file})
+	if len(errs) != 0 || token.Value != "gh-env-token" || token.Source != "GH_TOKEN" {
+		t.Fatalf("expected GH_TOKEN, got %+v, %v", token, errs)
+	}
+
+	token, _ = LookupToken(TokenConfig{Sources: []string{SourceFile, SourceEnv}, File: file})
+	if token.Value != "file-token" || token.Source != "file "+file {
+		t.Fatalf("expected the token file, got %+v", token)
+	}
+}
+
+func TestLookupToken_Command(t *testing.T) {
+	token, errs := LookupToken(TokenConfig{Sources: []string{SourceCommand}, Command: "echo helper-token"})
+	if len(errs) != 0 || token.Value != "helper-token" {
+		t.Fatalf("expected the helper's token, got %+v, %v", token, errs)
+	}
+
+	token, errs = LookupToken(TokenConfig{Sources: []string{SourceCommand}, Command: "exit 3"})
+	if token.Value != "" || len(errs) != 1 || !strings.Contains(errs[0].Error(), "token source command") {
+		t.Fatalf("expected a failed command to be reported, got %+v, %v", token, errs)
+	}
+}
+
+func TestLookupToken_Netrc(t *testing.T) {
+	netrc := filepath.Join(t.TempDir(), "netrc")
+	data := "machine example.com login me password other\nmachine api.github.com login me password netrc-token\n"
+	if err := os.WriteFile(netrc, []byte(data), 0600); err != nil {
+		t.Fatalf("failed to write netrc: %v", err)
+	}
+	t.Setenv("NETRC", netrc)
+
+	token, errs := LookupToken(TokenConfig{Sources: []string{SourceNetrc}})
+	if len(errs) != 0 || token.Value != "netrc-token" || strings.Contains(token.Source, "netrc-token") {
+		t.Fatalf("expected the netrc token, got %+v, %v", token, errs)
+	}
+}
+
+func TestLookupToken_EnterpriseHost(t *testing.T) {
+	t.Setenv("GITHUB_TOKEN", "github-com-token")
+	t.Setenv("GH_ENTERPRISE_TOKEN", "")
+	t.Setenv("GITHUB_ENTERPRISE_TOKEN", "enterprise-token")
+	t.Setenv("GHE_TOKEN", "")
+	tc := TokenConfig{Host: "github.corp.example", TokenEnv: "GHE_TOKEN", Sources: []string{SourceEnv}}
+
+	token, errs := LookupToken(tc)
+	if len(errs) != 0 || token.Value != "enterprise-token" || token.Source != "GITHUB_ENTERPRISE_TOKEN" {
+		t.Fatalf("expected the enterprise token, got %+v, %v", token, errs)
+	}
+
+	t.Setenv("GHE_TOKEN", "host-token")
+	if token, _ := LookupToken(tc); token.Value != "host-token" || token.Source != "GHE_TOKEN" {
+		t.Fatalf("expected token_env to come first, got %+v", token)
+	}
+
+	netrc := filepath.Join(t.TempDir(), "netrc")
+	data := "machine api.github.com login me password github-com-token\nmachine github.corp.example login me password netrc-token\n"
+	if err := os.WriteFile(netrc, []byte(data), 0600); err != nil {
+		t.Fatalf("failed to write netrc: %v", err)
+	}
+	t.Setenv("NETRC", netrc)
+	tc.Sources = []string{SourceNetrc}
+	if token, errs := LookupToken(tc); len(errs) != 0 || token.Value != "netrc-token" {
+		t.Fatalf("expected the host's netrc token, got %+v, %v", token, errs)
+	}
+}
+
+func TestLookupToken_MissingFileIsReported(t *testing.T) {
+	t.Setenv("GITHUB_TOKEN", "env-token")
+	token, errs := LookupToken(TokenConfig{Sources: []string{SourceFile, SourceEnv}, File: filepath.Join(t.TempDir(), "missing")})
+	if token.Value != "env-token" || len(errs) != 1 {
+		t.Fatalf("expected fallback to env with one problem, got %+v, %v", token, errs)
+	}
+}
+
+func TestNetrcPassword(t *testing.T) {
+	data := `default login anyone password default-secret
+macdef init
+machine github.com password inside-macro
+
+machine github.com
+  login me
+  password gh-secret
+`
+	if got := NetrcPassword(data, "github.com"); got != "gh-secret" {
+		t.Fatalf("expected gh-secret, got %q", got)
+	}
+	if got := NetrcPassword(data, "api.github.com"); got != "" {
+		t.Fatalf("expected the default entry not to be used, got %q", got)
+	}
+}
@@ -1,18 +1,40 @@
 package gomodule
 
 import (
+	"bytes"
+	"context"
 	"encoding/json"
 	"errors"
 	"fmt"
 	"os"
 	"os/exec"
 	"strings"
+	"time"
+
+	"github.com/UnitVectorY-Labs/gogitup/internal/retry"
 )
 
 // Result describes the Go toolchain's update decision for an installed module.
 type Result struct {
 	LatestVersion   string
 	UpdateAvailable bool
+	// Status describes the installed version. When it is retracted, an
+	// update to the latest version that is not retracted is offered even if
+	// that version is lower.
+	Status Status
+}
+
+// Status reports whether a module version was retracted by its author and
+// whether the module is deprecated, as go list -m -u does.
+type Status struct {
+	Retracted bool
+	// Rationale is the author's reason for the retraction, if given.
+	Rationale string
+	// Deprecated is the module's deprecation message.
+	Deprecated string
+	// Replacement is the latest version that is not retracted, set when the
+	// version is retracted and another version is available.
+	Replacement string
 }
 
 // Resolver checks module updates through the Go toolchain.
@@ -20,49 +42,173 @@ type Resolver interface {
 	Check(modulePath, installedVersion string) (Result, error)
 }
 
+// StatusChecker is implemented by resolvers that can report retraction and
+// deprecation for modules whose latest version comes from elsewhere, such
+// as a GitHub release.
+type StatusChecker interface {
+	Status(modulePath, version string) (Status, error)
+}
+
 // DefaultResolver implements Resolver using go list.
 type DefaultResolver struct {
 	goproxy string
+	// timeout bounds each go list invocation; zero means no limit.
+	timeout time.Duration
+	policy  retry.Policy
+	// extraEnv holds KEY=value entries, such as proxy settings, added to
+	// the environment of go list.
+	extraEnv []string
 }
 
 // NewDefaultResolver creates a resolver that inherits GOPROXY from the environment.
 func NewDefaultResolver() *DefaultResolver {
-	return &DefaultResolver{}
+	return &DefaultResolver{timeout: retry.DefaultTimeout, policy: retry.DefaultPolicy}
 }
 
 // NewDefaultResolverWithGOPROXY creates a resolver that overrides GOPROXY when
 // the provided value is non-empty.
 func NewDefaultResolverWithGOPROXY(goproxy string) *DefaultResolver {
-	return &DefaultResolver{goproxy: goproxy}
+	return &DefaultResolver{goproxy: goproxy, timeout: retry.DefaultTimeout, policy: retry.DefaultPolicy}
+}
+
+// SetRetryPolicy sets how long each go list invocation may take and how
+// invocations that fail with a transient network error are retried.
+func (r *DefaultResolver) SetRetryPolicy(timeout time.Duration, policy retry.Policy) {
+	r.timeout = timeout
+	r.policy = policy
 }
 
 type moduleInfo struct {
-	Version string      `json:"Version"`
-	Update  *moduleInfo `json:"Update"`
+	Version    string      `json:"Version"`
+	Update     *moduleInfo `json:"Update"`
+	Retracted  []string    `json:"Retracted"`
+	Deprecated string      `json:"Deprecated"`
+}
+
+// SetExtraEnv adds KEY=value entries to the environment of go list,
+// overriding inherited values.
+func (r *DefaultResolver) SetExtraEnv(env []string) {
+	r.extraEnv = env
 }
 
 // Check asks the Go toolchain whether a newer module version is available.
+// When the installed version is retracted and there is no newer version, the
+// latest version is looked up so the retracted one can be replaced.
+// Invocations that fail because of a network problem are retried.
 func (r *DefaultResolver) Check(modulePath, installedVersion string) (Result, error) {
-	cmd := r.buildListCmd(modulePath, installedVersion)
-	out, err := cmd.CombinedOutput()
+	out, err := r.run("go list -m -u "+modulePath+"@"+installedVersion, func(ctx context.Context) *exec.Cmd {
+		return r.buildListCmd(ctx, modulePath, installedVersion)
+	})
 	if err != nil {
-		return Result{}, fmt.Errorf("go list -m -u %s@%s failed: %w\n%s", modulePath, installedVersion, err, string(out))
+		return Result{}, err
+	}
+	result, err := ParseUpdate(out)
+	if err != nil || !result.Status.Retracted || result.UpdateAvailable {
+		return result, err
 	}
 
-	return ParseUpdate(out)
+	out, err = r.run("go list -m "+modulePath+"@latest", func(ctx context.Context) *exec.Cmd {
+		return r.goCmd(ctx, "list", "-m", "-json", modulePath+"@latest")
+	})
+	if err != nil {
+		return Result{}, err
+	}
+	var latest moduleInfo
+	if err := json.Unmarshal(out, &latest); err != nil {
+		return Result{}, fmt.Errorf("failed to parse go list output: %w", err)
+	}
+	if latest.Version != "" && latest.Version != installedVersion {
+		result.LatestVersion = latest.Version
+		result.UpdateAvailable = true
+		result.Status.Replacement = latest.Version
+	}
+	return result, nil
 }
 
-func (r *DefaultResolver) buildListCmd(modulePath, installedVersion string) *exec.Cmd {
-	cmd := exec.Command("go", "list", "-m", "-u", "-json", modulePath+"@"+installedVersion)
-	env := os.Environ()
+// Status reports whether version is retracted and whether the module is
+// deprecated.
+func (r *DefaultResolver) Status(modulePath, version string) (Status, error) {
+	result, err := r.Check(modulePath, version)
+	return result.Status, err
+}
+
+// run runs the go command built by build, retrying network failures. name
+// describes the command in errors.
+func (r *DefaultResolver) run(name string, build func(ctx context.Context) *exec.Cmd) ([]byte, error) {
+	var out []byte
+	err := retry.Do(r.policy, func() error {
+		ctx, cancel := r.context()
+		defer cancel()
+		var err error
+		out, err = build(ctx).CombinedOutput()
+		if err == nil {
+			return nil
+		}
+		if ctx.Err() != nil {
+			err = fmt.Errorf("timed out after %s", r.timeout)
+		}
+		err = fmt.Errorf("%s failed: %w\n%s", name, err, string(out))
+		if ctx.Err() != nil || isTransient(out) {
+			return retry.Transient(err)
+		}
+		return err
+	})
+	return out, err
+}
+
+func (r *DefaultResolver) context() (context.Context, context.CancelFunc) {
+	if r.timeout <= 0 {
+		return context.WithCancel(context.Background())
+	}
+	return context.WithTimeout(context.Background(), r.timeout)
+}
+
+// transientMarkers appear in go list errors caused by network problems or
+// overloaded proxies, as opposed to unknown modules or versions.
+var transientMarkers = []string{
+	" 429 Too Many Requests",
+	" 500 Internal Server Error",
+	" 502 Bad Gateway",
+	" 503 Service Unavailable",
+	" 504 Gateway Timeout",
+	"connection reset by peer",
+	"connection refused",
+	"i/o timeout",
+	"TLS handshake timeout",
+	"unexpected EOF",
+}
+
+func isTransient(out []byte) bool {
+	for _, marker := range transientMarkers {
+		if bytes.Contains(out, []byte(marker)) {
+			return true
+		}
+	}
+	return false
+}
+
+func (r *DefaultResolver) buildListCmd(ctx context.Context, modulePath, installedVersion string) *exec.Cmd {
+	return r.goCmd(ctx, "list", "-m", "-u", "-json", modulePath+"@"+installedVersion)
+}
+
+// goCmd returns a go command with the configured GOPROXY and extra
+// environment.
+func (r *DefaultResolver) goCmd(ctx context.Context, args ...string) *exec.Cmd {
+	cmd := exec.CommandContext(ctx, "go", args...)
+	overrides := r.extraEnv
 	if r.goproxy != "" {
+		overrides = append(overrides[:len(overrides):len(overrides)], "GOPROXY="+r.goproxy)
+	}
+	env := os.Environ()
+	for _, override := range overrides {
+		key, _, _ := strings.Cut(override, "=")
 		filtered := make([]string, 0, len(env))
 		for _, entry := range env {
-			if !strings.HasPrefix(entry, "GOPROXY=") {
+			if !strings.HasPrefix(entry, key+"=") {
 				filtered = append(filtered, entry)
 			}
 		}
-		env = append(filtered, "GOPROXY="+r.goproxy)
+		env = append(filtered, override)
 	}
 	cmd.Env = env
 	return cmd
@@ -77,14 +223,23 @@ func ParseUpdate(data []byte) (Result, error) {
 	if info.Version == "" {
 		return Result{}, errors.New("go list output did not include a version")
 	}
+	status := Status{
+		Retracted:  len(info.Retracted) > 0,
+		Rationale:  strings.Join(info.Retracted, "; "),
+		Deprecated: info.Deprecated,
+	}
 	if info.Update == nil {
-		return Result{LatestVersion: info.Version}, nil
+		return Result{LatestVersion: info.Version, Status: status}, nil
 	}
 	if info.Update.Version == "" {
 		return Result{}, errors.New("go list update output did not include a version")
 	}
+	if status.Retracted {
+		status.Replacement = info.Update.Version
+	}
 	return Result{
 		LatestVersion:   info.Update.Version,
 		UpdateAvailable: true,
+		Status:          status,
 	}, nil
 }
@@ -1,6 +1,7 @@
 package gomodule
 
 import (
+	"context"
 	"strings"
 	"testing"
 )
@@ -63,7 +64,7 @@ func TestBuildListCmdUsesConfiguredGOPROXY(t *testing.T) {
 	t.Setenv("GOPROXY", "https://environment.example.com")
 
 	resolver := NewDefaultResolverWithGOPROXY("https://proxy.example.com,direct")
-	cmd := resolver.buildListCmd("golang.org/x/vuln", "v1.2.3")
+	cmd := resolver.buildListCmd(context.Background(), "golang.org/x/vuln", "v1.2.3")
 
 	if got := cmd.Args[len(cmd.Args)-1]; got != "golang.org/x/vuln@v1.2.3" {
 		t.Fatalf("unexpected module argument %q", got)
@@ -86,7 +87,7 @@ func TestBuildListCmdUsesConfiguredGOPROXY(t *testing.T) {
 func TestBuildListCmdInheritsGOPROXY(t *testing.T) {
 	t.Setenv("GOPROXY", "https://environment.example.com")
 
-	cmd := NewDefaultResolver().buildListCmd("golang.org/x/vuln", "v1.2.3")
+	cmd := NewDefaultResolver().buildListCmd(context.Background(), "golang.org/x/vuln", "v1.2.3")
 
 	found := false
 	for _, entry := range cmd.Env {
@@ -108,3 +109,29 @@ func containsArg(args []string, want string) bool {
 	}
 	return false
 }
+
+func TestIsTransient(t *testing.T) {
+	if !isTransient([]byte("go: example.com/tool@v1.0.0: reading https://proxy.golang.org/example.com/tool/@v/list: 502 Bad Gateway")) {
+		t.Fatal("expected proxy 502 to be transient")
+	}
+	if isTransient([]byte("go: example.com/tool@v1.0.0: reading https://proxy.golang.org/example.com/tool/@v/v1.0.0.info: 404 Not Found")) {
+		t.Fatal("expected 404 not to be transient")
+	}
+}
+
+func TestParseUpdateRetractedAndDeprecated(t *testing.T) {
+	result, err := ParseUpdate([]byte(`{
+		"Path": "example.com/tool",
+		"Version": "v1.2.0",
+		"Retracted": ["Broken build."],
+		"Deprecated": "use example.com/newtool",
+		"Update": {"Path": "example.com/tool", "Version": "v1.3.0"}
+	}`))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	want := Status{Retracted: true, Rationale: "Broken build.", Deprecated: "use example.com/newtool", Replacement: "v1.3.0"}
+	if result.Status != want {
+		t.Fatalf("Status = %+v, want %+v", result.Status, want)
+	}
+}
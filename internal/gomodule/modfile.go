@@ -0,0 +1,44 @@
+package gomodule
+
+import (
+	"golang.org/x/mod/modfile"
+	"golang.org/x/mod/semver"
+)
+
+// modFile holds what the go command reads from the go.mod file of a
+// module's latest version: the versions its author retracted and the
+// module's deprecation notice.
+type modFile struct {
+	deprecated string
+	retract    []*modfile.Retract
+}
+
+// parseModFile reads the retract directives and the deprecation notice of a
+// go.mod file. It parses the file the way the go command reads the go.mod of
+// a dependency, and a file it cannot parse has neither.
+func parseModFile(data []byte) modFile {
+	f, err := modfile.ParseLax("go.mod", data, nil)
+	if err != nil {
+		return modFile{}
+	}
+	var mf modFile
+	if f.Module != nil {
+		mf.deprecated = f.Module.Deprecated
+	}
+	for _, r := range f.Retract {
+		if isValidSemver(r.Low) && isValidSemver(r.High) {
+			mf.retract = append(mf.retract, r)
+		}
+	}
+	return mf
+}
+
+// retracted reports whether version is retracted, and the author's rationale.
+func (mf modFile) retracted(version string) (bool, string) {
+	for _, r := range mf.retract {
+		if semver.Compare(r.Low, version) <= 0 && semver.Compare(version, r.High) <= 0 {
+			return true, r.Rationale
+		}
+	}
+	return false, ""
+}
@@ -0,0 +1,65 @@
+package gomodule
+
+import "testing"
+
+func TestParseModFile(t *testing.T) {
+	mf := parseModFile([]byte(`// Package tool does things.
+//
+// Deprecated: use example.com/tool/v2 instead.
+module example.com/tool
+
+go 1.22
+
+// Published with a broken build.
+retract v1.0.0
+
+retract [v1.1.0, v1.1.5] // Data loss on upgrade.
+
+retract (
+	v1.2.0 // Accidental release.
+	"v1.3.0"
+)
+
+require golang.org/x/mod v0.20.0 // indirect
+`))
+
+	if mf.deprecated != "use example.com/tool/v2 instead." {
+		t.Errorf("deprecated = %q", mf.deprecated)
+	}
+	tests := []struct {
+		version   string
+		retracted bool
+		rationale string
+	}{
+		{"v1.0.0", true, "Published with a broken build."},
+		{"v1.0.1", false, ""},
+		{"v1.1.0", true, "Data loss on upgrade."},
+		{"v1.1.3", true, "Data loss on upgrade."},
+		{"v1.1.6", false, ""},
+		{"v1.2.0", true, "Accidental release."},
+		{"v1.3.0", true, ""},
+	}
+	for _, tt := range tests {
+		retracted, rationale := mf.retracted(tt.version)
+		if retracted != tt.retracted || rationale != tt.rationale {
+			t.Errorf("retracted(%s) = %v, %q; want %v, %q", tt.version, retracted, rationale, tt.retracted, tt.rationale)
+		}
+	}
+}
+
+func TestParseModFileBlockRationale(t *testing.T) {
+	mf := parseModFile([]byte("module example.com/tool\n\n// Security issue.\nretract (\n\tv0.1.0\n\tv0.2.0\n)\n"))
+	if retracted, rationale := mf.retracted("v0.2.0"); !retracted || rationale != "Security issue." {
+		t.Errorf("retracted(v0.2.0) = %v, %q; want block rationale", retracted, rationale)
+	}
+	if mf.deprecated != "" {
+		t.Errorf("deprecated = %q, want none", mf.deprecated)
+	}
+}
+
+func TestParseModFileInvalid(t *testing.T) {
+	mf := parseModFile([]byte("module example.com/tool\n\nretract (\n\tv1.0.0\n"))
+	if retracted, _ := mf.retracted("v1.0.0"); retracted || mf.deprecated != "" {
+		t.Errorf("parseModFile() of an invalid file = %+v, want nothing", mf)
+	}
+}
@@ -0,0 +1,430 @@
+package gomodule
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"golang.org/x/mod/module"
+	"golang.org/x/mod/semver"
+)
+
+// DefaultGOPROXY is the go command's default GOPROXY.
+const DefaultGOPROXY = "https://proxy.golang.org,direct"
+
+// errDirect is returned when a module has to be fetched directly from its
+// version control system, which only the go command can do.
+var errDirect = errors.New("module must be fetched directly from version control")
+
+// maxProxyResponse bounds how much of a proxy response is read.
+const maxProxyResponse = 10 << 20
+
+// ProxyResolver implements Resolver with the module proxy protocol, without
+// running the go command. Modules that must be fetched directly, because of
+// GONOPROXY, GOPRIVATE, or a "direct" entry in GOPROXY, are reported with an
+// error that FallbackResolver answers with go list.
+type ProxyResolver struct {
+	goproxy    string
+	httpClient *http.Client
+	// getenv looks up go environment variables.
+	getenv func(key string) string
+
+	once     sync.Once
+	proxies  []proxyEntry
+	noProxy  string
+	settings error
+}
+
+// proxyEntry is one element of a GOPROXY list.
+type proxyEntry struct {
+	url string
+	// fallbackOnError is set when the entry is followed by "|", so that any
+	// error moves on to the next entry rather than only "not found".
+	fallbackOnError bool
+}
+
+// NewProxyResolver creates a resolver for the proxies in goproxy, or in the
+// GOPROXY environment variable when goproxy is empty, using httpClient for
+// requests.
+func NewProxyResolver(goproxy string, httpClient *http.Client) *ProxyResolver {
+	return &ProxyResolver{goproxy: goproxy, httpClient: httpClient, getenv: goEnvLookup()}
+}
+
+// load reads the GOPROXY, GONOPROXY, and GOPRIVATE settings once.
+func (r *ProxyResolver) load() error {
+	r.once.Do(func() {
+		goproxy := r.goproxy
+		if goproxy == "" {
+			goproxy = r.getenv("GOPROXY")
+		}
+		if goproxy == "" {
+			goproxy = DefaultGOPROXY
+		}
+		r.proxies = parseGOPROXY(goproxy)
+		if len(r.proxies) == 0 {
+			r.settings = fmt.Errorf("GOPROXY %q lists no proxies", goproxy)
+		}
+		r.noProxy = r.getenv("GONOPROXY")
+		if r.noProxy == "" {
+			r.noProxy = r.getenv("GOPRIVATE")
+		}
+	})
+	return r.settings
+}
+
+// Check finds the latest version of modulePath through the module proxies and
+// reports whether it is newer than installedVersion, as go list -m -u would.
+// Retracted versions are never offered, and a retracted installed version is
+// replaced by the latest version even when that version is lower.
+func (r *ProxyResolver) Check(modulePath, installedVersion string) (Result, error) {
+	if !isValidSemver(installedVersion) {
+		return Result{}, fmt.Errorf("installed version %q of %s is not a module version", installedVersion, modulePath)
+	}
+	q, err := r.query(modulePath)
+	if err != nil {
+		return Result{}, err
+	}
+
+	status := q.status(installedVersion)
+	switch {
+	case semver.Compare(q.latest, installedVersion) > 0:
+		return Result{LatestVersion: q.latest, UpdateAvailable: true, Status: status}, nil
+	case status.Replacement != "":
+		return Result{LatestVersion: status.Replacement, UpdateAvailable: true, Status: status}, nil
+	}
+	return Result{LatestVersion: installedVersion, Status: status}, nil
+}
+
+// Status reports whether version is retracted and whether the module is
+// deprecated, from the go.mod file of the module's latest version.
+func (r *ProxyResolver) Status(modulePath, version string) (Status, error) {
+	q, err := r.query(modulePath)
+	if err != nil {
+		return Status{}, err
+	}
+	return q.status(version), nil
+}
+
+// moduleQuery is what the proxies report about a module.
+type moduleQuery struct {
+	// latest is the latest version that is not retracted.
+	latest string
+	mod    modFile
+}
+
+// status describes version from the module's retractions and deprecation.
+func (q moduleQuery) status(version string) Status {
+	retracted, rationale := q.mod.retracted(version)
+	status := Status{Retracted: retracted, Rationale: rationale, Deprecated: q.mod.deprecated}
+	if retracted && q.latest != version {
+		status.Replacement = q.latest
+	}
+	return status
+}
+
+// query asks each proxy in turn about modulePath.
+func (r *ProxyResolver) query(modulePath string) (moduleQuery, error) {
+	if err := r.load(); err != nil {
+		return moduleQuery{}, err
+	}
+	if module.MatchPrefixPatterns(r.noProxy, modulePath) {
+		return moduleQuery{}, fmt.Errorf("%s matches GONOPROXY or GOPRIVATE: %w", modulePath, errDirect)
+	}
+	var q moduleQuery
+	err := r.eachProxy(func(base string) error {
+		var err error
+		q, err = r.queryProxy(base, modulePath)
+		return err
+	})
+	return q, err
+}
+
+// eachProxy calls fn with each proxy URL until one succeeds. As with the go
+// command, the next proxy is only tried after a "not found" error, or after
+// any error when the proxy is followed by "|".
+func (r *ProxyResolver) eachProxy(fn func(base string) error) error {
+	var lastErr error
+	for _, p := range r.proxies {
+		switch p.url {
+		case "direct":
+			return errDirect
+		case "off":
+			return fmt.Errorf("module lookup disabled by GOPROXY=off")
+		}
+		err := fn(p.url)
+		if err == nil {
+			return nil
+		}
+		lastErr = err
+		if !p.fallbackOnError && !isNotFound(err) {
+			return err
+		}
+	}
+	return lastErr
+}
+
+// queryProxy finds the latest version of modulePath in the proxy at base and
+// reads the retractions and deprecation from that version's go.mod file, as
+// the go command does. The latest version is the highest release, or the
+// version reported by @latest when no version is tagged. Retracted versions
+// are skipped unless every version is retracted.
+func (r *ProxyResolver) queryProxy(base, modulePath string) (moduleQuery, error) {
+	escaped, err := module.EscapePath(modulePath)
+	if err != nil {
+		return moduleQuery{}, err
+	}
+	list, err := r.fetch(base + "/" + escaped + "/@v/list")
+	if err != nil {
+		return moduleQuery{}, err
+	}
+	versions := strings.Fields(string(list))
+	top := latestVersion(versions)
+	if top == "" {
+		if top, err = r.latestInfo(base, escaped); err != nil {
+			return moduleQuery{}, err
+		}
+	}
+
+	escVersion, err := module.EscapeVersion(top)
+	if err != nil {
+		return moduleQuery{}, err
+	}
+	// A proxy that cannot serve the go.mod file still answers the version
+	// query; the module is then treated as having no retractions.
+	data, err := r.fetch(base + "/" + escaped + "/@v/" + escVersion + ".mod")
+	if err != nil && !isNotFound(err) {
+		return moduleQuery{}, err
+	}
+	q := moduleQuery{latest: top, mod: parseModFile(data)}
+	allowed := make([]string, 0, len(versions))
+	for _, v := range versions {
+		if retracted, _ := q.mod.retracted(v); !retracted {
+			allowed = append(allowed, v)
+		}
+	}
+	if latest := latestVersion(allowed); latest != "" {
+		q.latest = latest
+	}
+	return q, nil
+}
+
+// latestInfo returns the version reported by the proxy's @latest endpoint.
+func (r *ProxyResolver) latestInfo(base, escaped string) (string, error) {
+	data, err := r.fetch(base + "/" + escaped + "/@latest")
+	if err != nil {
+		return "", err
+	}
+	var info struct {
+		Version string `json:"Version"`
+	}
+	if err := json.Unmarshal(data, &info); err != nil {
+		return "", fmt.Errorf("failed to parse %s/%s/@latest: %w", base, escaped, err)
+	}
+	if !isValidSemver(info.Version) {
+		return "", fmt.Errorf("%s/%s/@latest returned invalid version %q", base, escaped, info.Version)
+	}
+	return info.Version, nil
+}
+
+// latestVersion picks the version go list -m -u would offer from a proxy's
+// version list: the highest release, or the highest prerelease when there is
+// no release. +incompatible versions are only used when there is nothing else.
+func latestVersion(versions []string) string {
+	best := ""
+	rank := func(v string) int {
+		r := 0
+		if semver.Prerelease(v) == "" {
+			r += 2
+		}
+		if semver.Build(v) != "+incompatible" {
+			r++
+		}
+		return r
+	}
+	for _, v := range versions {
+		if !isValidSemver(v) {
+			continue
+		}
+		if best == "" || rank(v) > rank(best) || (rank(v) == rank(best) && semver.Compare(v, best) > 0) {
+			best = v
+		}
+	}
+	return best
+}
+
+// notFoundError is a 404 or 410 response, which lets the next proxy in a
+// comma-separated GOPROXY list be tried.
+type notFoundError struct {
+	url    string
+	status string
+}
+
+func (e *notFoundError) Error() string {
+	return fmt.Sprintf("%s: %s", e.url, e.status)
+}
+
+func isNotFound(err error) bool {
+	var nf *notFoundError
+	return errors.As(err, &nf)
+}
+
+// unavailableError is a proxy that could not be reached, or that answered
+// with a server error after the retries of the HTTP client.
+type unavailableError struct {
+	err error
+}
+
+func (e *unavailableError) Error() string {
+	return e.err.Error()
+}
+
+func (e *unavailableError) Unwrap() error {
+	return e.err
+}
+
+func isUnavailable(err error) bool {
+	var ue *unavailableError
+	return errors.As(err, &ue)
+}
+
+// fetch returns the body of a proxy URL. file:// proxies are read from disk.
+func (r *ProxyResolver) fetch(rawURL string) ([]byte, error) {
+	body, err := r.open(rawURL)
+	if err != nil {
+		return nil, err
+	}
+	defer body.Close()
+	return io.ReadAll(io.LimitReader(body, maxProxyResponse))
+}
+
+// open returns a reader for the body of a proxy URL.
+func (r *ProxyResolver) open(rawURL string) (io.ReadCloser, error) {
+	if rest, ok := strings.CutPrefix(rawURL, "file://"); ok {
+		u, err := url.Parse("file://" + rest)
+		if err != nil {
+			return nil, err
+		}
+		f, err := os.Open(filepath.FromSlash(u.Path))
+		if errors.Is(err, os.ErrNotExist) {
+			return nil, &notFoundError{url: rawURL, status: "not found"}
+		}
+		return f, err
+	}
+
+	resp, err := r.httpClient.Get(rawURL)
+	if err != nil {
+		return nil, &unavailableError{err: fmt.Errorf("failed to fetch %s: %w", rawURL, err)}
+	}
+	switch {
+	case resp.StatusCode == http.StatusNotFound || resp.StatusCode == http.StatusGone:
+		resp.Body.Close()
+		return nil, &notFoundError{url: rawURL, status: resp.Status}
+	case resp.StatusCode >= http.StatusInternalServerError:
+		resp.Body.Close()
+		return nil, &unavailableError{err: fmt.Errorf("%s: %s", rawURL, resp.Status)}
+	case resp.StatusCode != http.StatusOK:
+		resp.Body.Close()
+		return nil, fmt.Errorf("%s: %s", rawURL, resp.Status)
+	}
+	return resp.Body, nil
+}
+
+// ProxyURLs returns the module proxies of the effective GOPROXY that are
+// reached over the network, in order. "direct", "off", and file:// entries
+// are left out.
+func (r *ProxyResolver) ProxyURLs() []string {
+	if err := r.load(); err != nil {
+		return nil
+	}
+	var urls []string
+	for _, p := range r.proxies {
+		if strings.HasPrefix(p.url, "http://") || strings.HasPrefix(p.url, "https://") {
+			urls = append(urls, p.url)
+		}
+	}
+	return urls
+}
+
+// parseGOPROXY splits a GOPROXY list into its entries.
+func parseGOPROXY(value string) []proxyEntry {
+	var entries []proxyEntry
+	for value != "" {
+		entry, sep := value, byte(0)
+		if i := strings.IndexAny(value, ",|"); i >= 0 {
+			entry, sep, value = value[:i], value[i], value[i+1:]
+		} else {
+			value = ""
+		}
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		entries = append(entries, proxyEntry{url: strings.TrimSuffix(entry, "/"), fallbackOnError: sep == '|'})
+	}
+	return entries
+}
+
+// goEnvLookup returns a function that reads go environment variables from the
+// process environment, falling back to go env for values set with go env -w.
+// go env only runs when a variable is not in the environment.
+func goEnvLookup() func(string) string {
+	var once sync.Once
+	values := map[string]string{}
+	return func(key string) string {
+		if v := os.Getenv(key); v != "" {
+			return v
+		}
+		once.Do(func() {
+			out, err := exec.Command("go", "env", "-json", "GOPROXY", "GONOPROXY", "GOPRIVATE", "GONOSUMDB", "GOSUMDB", "GOINSECURE").Output()
+			if err == nil {
+				_ = json.Unmarshal(out, &values)
+			}
+		})
+		return values[key]
+	}
+}
+
+// FallbackResolver answers with Primary and turns to Fallback for modules
+// Primary cannot look up: those that must be fetched directly, and those
+// whose proxy could not be reached or failed with a server error.
+type FallbackResolver struct {
+	Primary  Resolver
+	Fallback Resolver
+}
+
+// Status implements StatusChecker when both resolvers do.
+func (r *FallbackResolver) Status(modulePath, version string) (Status, error) {
+	primary, ok1 := r.Primary.(StatusChecker)
+	fallback, ok2 := r.Fallback.(StatusChecker)
+	if !ok1 || !ok2 {
+		return Status{}, errors.New("resolver does not report module status")
+	}
+	status, err := primary.Status(modulePath, version)
+	if useFallback(err) {
+		return fallback.Status(modulePath, version)
+	}
+	return status, err
+}
+
+// Check implements Resolver.
+func (r *FallbackResolver) Check(modulePath, installedVersion string) (Result, error) {
+	result, err := r.Primary.Check(modulePath, installedVersion)
+	if useFallback(err) {
+		return r.Fallback.Check(modulePath, installedVersion)
+	}
+	return result, err
+}
+
+// useFallback reports whether err means the module has to be looked up with
+// the fallback resolver.
+func useFallback(err error) bool {
+	return err != nil && (errors.Is(err, errDirect) || isUnavailable(err))
+}
@@ -0,0 +1,237 @@
+package gomodule
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"golang.org/x/mod/semver"
+)
+
+func newTestProxyResolver(goproxy string, env map[string]string) *ProxyResolver {
+	return &ProxyResolver{goproxy: goproxy, httpClient: http.DefaultClient, getenv: func(key string) string { return env[key] }}
+}
+
+// proxyServer serves a module proxy with the given files, keyed by URL path.
+func proxyServer(t *testing.T, files map[string]string) *httptest.Server {
+	t.Helper()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, ok := files[r.URL.Path]
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+		w.Write([]byte(body))
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func TestProxyResolverCheck(t *testing.T) {
+	server := proxyServer(t, map[string]string{
+		"/github.com/!burnt!sushi/toml/@v/list": "v1.2.0\nv1.3.0-rc.1\nv1.2.1\nv2.0.0+incompatible\n",
+		"/example.com/untagged/@v/list":         "",
+		"/example.com/untagged/@latest":         `{"Version":"v0.0.0-20260101000000-abcdefabcdef"}`,
+	})
+	r := newTestProxyResolver(server.URL, nil)
+
+	result, err := r.Check("github.com/BurntSushi/toml", "v1.2.0")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !result.UpdateAvailable || result.LatestVersion != "v1.2.1" {
+		t.Fatalf("expected update to v1.2.1, got %+v", result)
+	}
+
+	result, err = r.Check("github.com/BurntSushi/toml", "v1.3.0-rc.1")
+	if err != nil || result.UpdateAvailable || result.LatestVersion != "v1.3.0-rc.1" {
+		t.Fatalf("expected no downgrade from a newer prerelease, got %+v, %v", result, err)
+	}
+
+	result, err = r.Check("example.com/untagged", "v0.0.0-20250101000000-123456123456")
+	if err != nil || !result.UpdateAvailable || result.LatestVersion != "v0.0.0-20260101000000-abcdefabcdef" {
+		t.Fatalf("expected update to the latest pseudo-version, got %+v, %v", result, err)
+	}
+}
+
+func TestProxyResolverFallsThroughProxyList(t *testing.T) {
+	empty := proxyServer(t, nil)
+	full := proxyServer(t, map[string]string{"/example.com/tool/@v/list": "v1.0.0\nv1.1.0\n"})
+	broken := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusForbidden)
+	}))
+	defer broken.Close()
+
+	// A comma moves on only after "not found".
+	result, err := newTestProxyResolver(empty.URL+","+full.URL, nil).Check("example.com/tool", "v1.0.0")
+	if err != nil || result.LatestVersion != "v1.1.0" {
+		t.Fatalf("expected the second proxy to answer, got %+v, %v", result, err)
+	}
+	if _, err := newTestProxyResolver(broken.URL+","+full.URL, nil).Check("example.com/tool", "v1.0.0"); err == nil {
+		t.Fatal("expected a forbidden response to stop a comma-separated list")
+	}
+	// A pipe moves on after any error.
+	result, err = newTestProxyResolver(broken.URL+"|"+full.URL, nil).Check("example.com/tool", "v1.0.0")
+	if err != nil || result.LatestVersion != "v1.1.0" {
+		t.Fatalf("expected the second proxy to answer, got %+v, %v", result, err)
+	}
+	// direct and off are not served by the proxy protocol.
+	if _, err := newTestProxyResolver(empty.URL+",direct", nil).Check("example.com/tool", "v1.0.0"); !errors.Is(err, errDirect) {
+		t.Fatalf("expected errDirect, got %v", err)
+	}
+	if _, err := newTestProxyResolver("off", nil).Check("example.com/tool", "v1.0.0"); err == nil || errors.Is(err, errDirect) {
+		t.Fatalf("expected GOPROXY=off to fail, got %v", err)
+	}
+}
+
+func TestProxyResolverPrivateModules(t *testing.T) {
+	server := proxyServer(t, map[string]string{"/corp.example/team/tool/@v/list": "v1.0.0\n"})
+	r := newTestProxyResolver(server.URL, map[string]string{"GOPRIVATE": "*.internal,corp.example/team"})
+	if _, err := r.Check("corp.example/team/tool", "v1.0.0"); !errors.Is(err, errDirect) {
+		t.Fatalf("expected GOPRIVATE module to need a direct fetch, got %v", err)
+	}
+
+	r = newTestProxyResolver(server.URL, map[string]string{"GOPRIVATE": "corp.example", "GONOPROXY": "none.example"})
+	if _, err := r.Check("corp.example/team/tool", "v1.0.0"); err != nil {
+		t.Fatalf("expected GONOPROXY to override GOPRIVATE, got %v", err)
+	}
+}
+
+func TestProxyResolverFileProxy(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(dir, "example.com", "tool", "@v"), 0755); err != nil {
+		t.Fatalf("failed to create proxy dir: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "example.com", "tool", "@v", "list"), []byte("v1.0.0\nv1.4.0\n"), 0644); err != nil {
+		t.Fatalf("failed to write list: %v", err)
+	}
+	result, err := newTestProxyResolver("file://"+filepath.ToSlash(dir), nil).Check("example.com/tool", "v1.0.0")
+	if err != nil || result.LatestVersion != "v1.4.0" {
+		t.Fatalf("expected v1.4.0 from the file proxy, got %+v, %v", result, err)
+	}
+}
+
+type stubResolver struct {
+	result Result
+	err    error
+	calls  int
+}
+
+func (s *stubResolver) Check(modulePath, installedVersion string) (Result, error) {
+	s.calls++
+	return s.result, s.err
+}
+
+func TestProxyURLs(t *testing.T) {
+	r := newTestProxyResolver("https://proxy.example.com/,file:///srv/mods|https://goproxy.io,direct", nil)
+	got := strings.Join(r.ProxyURLs(), " ")
+	if got != "https://proxy.example.com https://goproxy.io" {
+		t.Errorf("ProxyURLs() = %q", got)
+	}
+	if got := newTestProxyResolver("off", nil).ProxyURLs(); len(got) != 0 {
+		t.Errorf("ProxyURLs() for off = %q, want none", got)
+	}
+}
+
+func TestFallbackResolver(t *testing.T) {
+	goList := &stubResolver{result: Result{LatestVersion: "v1.0.0"}}
+	r := &FallbackResolver{Primary: &stubResolver{err: errDirect}, Fallback: goList}
+	if result, err := r.Check("example.com/tool", "v1.0.0"); err != nil || result.LatestVersion != "v1.0.0" || goList.calls != 1 {
+		t.Fatalf("expected go list to answer, got %+v, %v", result, err)
+	}
+
+	outage := &unavailableError{err: errors.New("https://proxy.example.com: 503 Service Unavailable")}
+	r = &FallbackResolver{Primary: &stubResolver{err: fmt.Errorf("wrapped: %w", outage)}, Fallback: goList}
+	if result, err := r.Check("example.com/tool", "v1.0.0"); err != nil || result.LatestVersion != "v1.0.0" || goList.calls != 2 {
+		t.Fatalf("expected go list to answer during a proxy outage, got %+v, %v", result, err)
+	}
+
+	r = &FallbackResolver{Primary: &stubResolver{err: errors.New("invalid proxy response")}, Fallback: goList}
+	if _, err := r.Check("example.com/tool", "v1.0.0"); err == nil || goList.calls != 2 {
+		t.Fatalf("expected other proxy errors to be returned without running go list, got %v", err)
+	}
+}
+
+func TestProxyResolverReportsServerErrorsAsUnavailable(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "down", http.StatusBadGateway)
+	}))
+	defer server.Close()
+
+	r := newTestProxyResolver(server.URL, nil)
+	if _, err := r.Check("example.com/tool", "v1.0.0"); !isUnavailable(err) {
+		t.Fatalf("expected a server error to be reported as unavailable, got %v", err)
+	}
+
+	r = newTestProxyResolver("http://127.0.0.1:1", nil)
+	if _, err := r.Check("example.com/tool", "v1.0.0"); !isUnavailable(err) {
+		t.Fatalf("expected a connection error to be reported as unavailable, got %v", err)
+	}
+}
+
+func TestSemverOrderAndValidity(t *testing.T) {
+	ordered := []string{
+		"v0.0.0-20250101000000-123456123456",
+		"v1.0.0-alpha",
+		"v1.0.0-alpha.1",
+		"v1.0.0-alpha.beta",
+		"v1.0.0-beta.2",
+		"v1.0.0-beta.11",
+		"v1.0.0-rc.1",
+		"v1.0.0",
+		"v1.2.0",
+		"v1.10.0",
+		"v2.0.0+incompatible",
+	}
+	for i := 0; i+1 < len(ordered); i++ {
+		if semver.Compare(ordered[i], ordered[i+1]) != -1 || semver.Compare(ordered[i+1], ordered[i]) != 1 {
+			t.Errorf("expected %s < %s", ordered[i], ordered[i+1])
+		}
+	}
+	for _, invalid := range []string{"1.0.0", "v1.0", "v01.0.0", "v1.0.0-01", "(devel)"} {
+		if isValidSemver(invalid) {
+			t.Errorf("expected %q to be invalid", invalid)
+		}
+	}
+	for _, valid := range []string{"v1.0.0", "v1.0.0-rc.1", "v2.0.0+incompatible", "v0.0.0-20250101000000-123456123456"} {
+		if !isValidSemver(valid) {
+			t.Errorf("expected %q to be valid", valid)
+		}
+	}
+}
+
+func TestProxyResolverRetractions(t *testing.T) {
+	server := proxyServer(t, map[string]string{
+		"/example.com/tool/@v/list":       "v1.0.0\nv1.1.0\nv1.2.0\n",
+		"/example.com/tool/@v/v1.2.0.mod": "// Deprecated: use example.com/newtool.\nmodule example.com/tool\n\nretract v1.2.0 // Broken build.\nretract v1.0.0\n",
+	})
+	r := newTestProxyResolver(server.URL, nil)
+
+	// The retracted v1.2.0 is not offered.
+	result, err := r.Check("example.com/tool", "v1.1.0")
+	if err != nil || result.UpdateAvailable || result.LatestVersion != "v1.1.0" {
+		t.Fatalf("expected no update past the retracted version, got %+v (err=%v)", result, err)
+	}
+	if result.Status.Deprecated != "use example.com/newtool." {
+		t.Fatalf("expected deprecation notice, got %+v", result.Status)
+	}
+
+	// A retracted installed version is replaced, even by a lower version.
+	result, err = r.Check("example.com/tool", "v1.2.0")
+	if err != nil || !result.UpdateAvailable || result.LatestVersion != "v1.1.0" {
+		t.Fatalf("expected a move to v1.1.0, got %+v (err=%v)", result, err)
+	}
+	if !result.Status.Retracted || result.Status.Rationale != "Broken build." || result.Status.Replacement != "v1.1.0" {
+		t.Fatalf("expected retraction status, got %+v", result.Status)
+	}
+
+	status, err := r.Status("example.com/tool", "v1.0.0")
+	if err != nil || !status.Retracted || status.Replacement != "v1.1.0" {
+		t.Fatalf("expected v1.0.0 to be retracted, got %+v (err=%v)", status, err)
+	}
+}
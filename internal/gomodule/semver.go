@@ -0,0 +1,10 @@
+package gomodule
+
+import "golang.org/x/mod/semver"
+
+// isValidSemver reports whether v is a canonical module version:
+// vMAJOR.MINOR.PATCH with optional prerelease and build suffixes, such as
+// v2.0.0+incompatible. Shorthands such as v1.2 are rejected.
+func isValidSemver(v string) bool {
+	return semver.IsValid(v) && semver.Canonical(v)+semver.Build(v) == v
+}
@@ -0,0 +1,241 @@
+package gomodule
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+
+	"golang.org/x/mod/module"
+	"golang.org/x/mod/sumdb"
+	"golang.org/x/mod/sumdb/dirhash"
+)
+
+// Verification statuses.
+const (
+	// VerifyOK means the module proxy serves the go.mod recorded in the
+	// checksum database.
+	VerifyOK = "verified"
+	// VerifyMismatch means the proxy serves content that differs from the
+	// checksum database, or the database served a tree inconsistent with
+	// one seen before, and the version must not be installed.
+	VerifyMismatch = "mismatch"
+	// VerifySkipped means the version is not checked against the database,
+	// because of GOSUMDB=off, GONOSUMDB, or GOPRIVATE.
+	VerifySkipped = "skipped"
+	// VerifyFailed means the version could not be checked, such as when the
+	// database is unreachable or does not know the version.
+	VerifyFailed = "failed"
+)
+
+// Verification is the result of checking a module version against the
+// checksum database.
+type Verification struct {
+	Status string
+	// Detail explains any status other than VerifyOK.
+	Detail string
+}
+
+// Verifier checks module versions against the Go checksum database.
+type Verifier interface {
+	Verify(modulePath, version string) Verification
+}
+
+// SumDBVerifier implements Verifier for the database set by GOSUMDB. It
+// looks up the go.sum lines of a version with golang.org/x/mod/sumdb and
+// compares the go.mod hash with the go.mod the proxies of a ProxyResolver
+// serve. The module zip is left to the go command, which checks it against
+// the same database when it installs the version.
+type SumDBVerifier struct {
+	proxy *ProxyResolver
+	// dir holds the latest tree head and the records and tiles read from
+	// each database.
+	dir string
+
+	once     sync.Once
+	off      bool
+	ops      *sumdbOps
+	client   *sumdb.Client
+	settings error
+
+	baseOnce sync.Once
+	base     string
+	baseErr  error
+}
+
+// NewSumDBVerifier creates a verifier that reads modules through proxy,
+// reads the GOSUMDB, GONOSUMDB, and GOPRIVATE settings the same way, and
+// keeps what it learns from the database in dir.
+func NewSumDBVerifier(proxy *ProxyResolver, dir string) *SumDBVerifier {
+	return &SumDBVerifier{proxy: proxy, dir: dir}
+}
+
+// load reads the checksum database settings once.
+func (v *SumDBVerifier) load() error {
+	v.once.Do(func() {
+		if err := v.proxy.load(); err != nil {
+			v.settings = err
+			return
+		}
+		gosumdb := v.proxy.getenv("GOSUMDB")
+		if gosumdb == "" {
+			gosumdb = DefaultGOSUMDB
+		}
+		if gosumdb == "off" {
+			v.off = true
+			return
+		}
+		key, direct, err := parseGOSUMDB(gosumdb)
+		if err != nil {
+			v.settings = err
+			return
+		}
+		name, _, _ := strings.Cut(key, "+")
+		v.ops = &sumdbOps{key: key, dir: v.dir, read: func(path string) ([]byte, error) {
+			base, err := v.dbBase(name, direct)
+			if err != nil {
+				return nil, err
+			}
+			return v.proxy.fetch(base + path)
+		}}
+		v.client = sumdb.NewClient(v.ops)
+		noSumDB := v.proxy.getenv("GONOSUMDB")
+		if noSumDB == "" {
+			noSumDB = v.proxy.getenv("GOPRIVATE")
+		}
+		v.client.SetGONOSUMDB(noSumDB)
+	})
+	return v.settings
+}
+
+// dbBase returns the URL the database is read from. Like the go command, it
+// uses the first proxy that supports proxying the database, and otherwise
+// connects to the database directly.
+func (v *SumDBVerifier) dbBase(name, direct string) (string, error) {
+	v.baseOnce.Do(func() {
+		v.base = direct
+		for _, p := range v.proxy.proxies {
+			if p.url == "direct" || p.url == "off" {
+				return
+			}
+			base := p.url + "/sumdb/" + name
+			_, err := v.proxy.fetch(base + "/supported")
+			if err == nil {
+				v.base = base
+				return
+			}
+			if !isNotFound(err) && !p.fallbackOnError {
+				v.baseErr = err
+				return
+			}
+		}
+	})
+	return v.base, v.baseErr
+}
+
+// Verify checks modulePath at version against the checksum database.
+func (v *SumDBVerifier) Verify(modulePath, version string) Verification {
+	if !isValidSemver(version) {
+		return Verification{Status: VerifySkipped, Detail: fmt.Sprintf("%q is not a module version", version)}
+	}
+	if err := v.load(); err != nil {
+		return Verification{Status: VerifyFailed, Detail: err.Error()}
+	}
+	if v.off {
+		return Verification{Status: VerifySkipped, Detail: "GOSUMDB=off"}
+	}
+	if module.MatchPrefixPatterns(v.proxy.noProxy, modulePath) {
+		return Verification{Status: VerifySkipped, Detail: modulePath + " matches GONOPROXY and is verified by the go command when installed"}
+	}
+
+	lines, err := v.client.Lookup(modulePath, version+"/go.mod")
+	switch {
+	case errors.Is(err, sumdb.ErrGONOSUMDB):
+		return Verification{Status: VerifySkipped, Detail: modulePath + " matches GONOSUMDB or GOPRIVATE"}
+	case v.ops.securityError() != "":
+		// The client reports ErrSecurity without wrapping it, so the message
+		// recorded by SecurityError is what identifies it.
+		return Verification{Status: VerifyMismatch, Detail: fmt.Sprintf("the checksum database served a tree inconsistent with the one recorded in %s", v.dir)}
+	case err != nil:
+		return Verification{Status: VerifyFailed, Detail: err.Error()}
+	case len(lines) == 0:
+		return Verification{Status: VerifyFailed, Detail: fmt.Sprintf("the checksum database has no go.mod hash for %s@%s", modulePath, version)}
+	}
+
+	var infoVersion string
+	var mod []byte
+	err = v.proxy.eachProxy(func(base string) error {
+		var err error
+		infoVersion, mod, err = v.proxy.moduleMetadata(base, modulePath, version)
+		return err
+	})
+	if errors.Is(err, errDirect) {
+		return Verification{Status: VerifySkipped, Detail: modulePath + " is fetched directly and verified by the go command when installed"}
+	}
+	if err != nil {
+		return Verification{Status: VerifyFailed, Detail: err.Error()}
+	}
+	if infoVersion != version {
+		return Verification{
+			Status: VerifyMismatch,
+			Detail: fmt.Sprintf("%s@%s: the module proxy reports version %q", modulePath, version, infoVersion),
+		}
+	}
+
+	modHash, err := hashMod(mod)
+	if err != nil {
+		return Verification{Status: VerifyFailed, Detail: err.Error()}
+	}
+	for _, line := range lines {
+		f := strings.Fields(line)
+		if len(f) == 3 && f[2] != modHash {
+			return Verification{
+				Status: VerifyMismatch,
+				Detail: fmt.Sprintf("%s@%s: go.mod hash %s from the module proxy does not match %s in the checksum database", modulePath, version, modHash, f[2]),
+			}
+		}
+	}
+	return Verification{Status: VerifyOK}
+}
+
+// moduleMetadata reads the .info and .mod files of a module version from the
+// proxy at base, and returns the version the .info file reports and the
+// go.mod contents.
+func (r *ProxyResolver) moduleMetadata(base, modulePath, version string) (string, []byte, error) {
+	escaped, err := module.EscapePath(modulePath)
+	if err != nil {
+		return "", nil, err
+	}
+	escVersion, err := module.EscapeVersion(version)
+	if err != nil {
+		return "", nil, err
+	}
+	prefix := base + "/" + escaped + "/@v/" + escVersion
+
+	data, err := r.fetch(prefix + ".info")
+	if err != nil {
+		return "", nil, err
+	}
+	var info struct {
+		Version string `json:"Version"`
+	}
+	if err := json.Unmarshal(data, &info); err != nil {
+		return "", nil, fmt.Errorf("failed to parse %s.info: %w", prefix, err)
+	}
+
+	mod, err := r.fetch(prefix + ".mod")
+	if err != nil {
+		return "", nil, err
+	}
+	return info.Version, mod, nil
+}
+
+// hashMod returns the go.sum hash of a go.mod file.
+func hashMod(mod []byte) (string, error) {
+	return dirhash.Hash1([]string{"go.mod"}, func(string) (io.ReadCloser, error) {
+		return io.NopCloser(bytes.NewReader(mod)), nil
+	})
+}
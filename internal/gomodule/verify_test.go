@@ -0,0 +1,220 @@
+package gomodule
+
+import (
+	"context"
+	"crypto/rand"
+	"net/http"
+	"net/http/httptest"
+	"net/http/httputil"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"golang.org/x/mod/module"
+	"golang.org/x/mod/sumdb"
+	"golang.org/x/mod/sumdb/note"
+)
+
+// checksumDB serves a checksum database signed with skey that holds the
+// go.sum lines in records, keyed by module@version.
+func checksumDB(t *testing.T, skey string, records map[string]string) (*httptest.Server, *sumdb.TestServer) {
+	t.Helper()
+	ops := sumdb.NewTestServer(skey, func(path, vers string) ([]byte, error) {
+		lines, ok := records[path+"@"+vers]
+		if !ok {
+			return nil, os.ErrNotExist
+		}
+		return []byte(lines), nil
+	})
+	server := httptest.NewServer(sumdb.NewServer(ops))
+	t.Cleanup(server.Close)
+	return server, ops
+}
+
+// verifyFixture serves example.com/Tool from a proxy and records it in a
+// local checksum database. It returns the proxy URL and the GOSUMDB setting
+// of the database.
+func verifyFixture(t *testing.T) (proxyURL, gosumdb string) {
+	t.Helper()
+	mod := "module example.com/Tool\n"
+	modHash, err := hashMod([]byte(mod))
+	if err != nil {
+		t.Fatal(err)
+	}
+	server := proxyServer(t, map[string]string{
+		"/example.com/!tool/@v/v1.2.0.info": `{"Version":"v1.2.0"}`,
+		"/example.com/!tool/@v/v1.2.0.mod":  mod,
+		"/example.com/!tool/@v/v1.3.0.info": `{"Version":"v1.3.0"}`,
+		"/example.com/!tool/@v/v1.3.0.mod":  mod,
+		"/example.com/!tool/@v/v1.5.0.info": `{"Version":"v1.5.1"}`,
+		"/example.com/!tool/@v/v1.5.0.mod":  mod,
+	})
+
+	skey, vkey, err := note.GenerateKey(rand.Reader, "sum.example.com")
+	if err != nil {
+		t.Fatal(err)
+	}
+	db, _ := checksumDB(t, skey, map[string]string{
+		"example.com/Tool@v1.2.0": "example.com/Tool v1.2.0 h1:zip=\nexample.com/Tool v1.2.0/go.mod " + modHash + "\n",
+		// v1.3.0 was recorded with a different go.mod than the proxy now serves.
+		"example.com/Tool@v1.3.0": "example.com/Tool v1.3.0 h1:zip=\nexample.com/Tool v1.3.0/go.mod h1:original=\n",
+		"example.com/Tool@v1.5.0": "example.com/Tool v1.5.0 h1:zip=\nexample.com/Tool v1.5.0/go.mod " + modHash + "\n",
+	})
+	return server.URL, vkey + " " + db.URL
+}
+
+func TestSumDBVerifierVerify(t *testing.T) {
+	proxyURL, gosumdb := verifyFixture(t)
+	v := NewSumDBVerifier(newTestProxyResolver(proxyURL, map[string]string{"GOSUMDB": gosumdb}), t.TempDir())
+
+	if got := v.Verify("example.com/Tool", "v1.2.0"); got.Status != VerifyOK {
+		t.Errorf("Verify(v1.2.0) = %+v, want verified", got)
+	}
+
+	got := v.Verify("example.com/Tool", "v1.3.0")
+	if got.Status != VerifyMismatch || !strings.Contains(got.Detail, "go.mod hash") {
+		t.Errorf("Verify(v1.3.0) = %+v, want go.mod mismatch", got)
+	}
+
+	got = v.Verify("example.com/Tool", "v1.5.0")
+	if got.Status != VerifyMismatch || !strings.Contains(got.Detail, `reports version "v1.5.1"`) {
+		t.Errorf("Verify(v1.5.0) = %+v, want .info mismatch", got)
+	}
+
+	got = v.Verify("example.com/Tool", "v1.4.0")
+	if got.Status != VerifyFailed || !strings.Contains(got.Detail, "example.com/Tool@v1.4.0") {
+		t.Errorf("Verify(v1.4.0) = %+v, want failure for unknown version", got)
+	}
+
+	if got := v.Verify("example.com/Tool", "(devel)"); got.Status != VerifySkipped {
+		t.Errorf("Verify((devel)) = %+v, want skipped", got)
+	}
+}
+
+func TestSumDBVerifierSkips(t *testing.T) {
+	proxyURL, gosumdb := verifyFixture(t)
+	tests := []struct {
+		name string
+		env  map[string]string
+	}{
+		{"GOSUMDB off", map[string]string{"GOSUMDB": "off"}},
+		{"GONOSUMDB", map[string]string{"GOSUMDB": gosumdb, "GONOSUMDB": "example.com"}},
+		{"GOPRIVATE", map[string]string{"GOSUMDB": gosumdb, "GOPRIVATE": "example.com/*"}},
+	}
+	for _, tt := range tests {
+		v := NewSumDBVerifier(newTestProxyResolver(proxyURL, tt.env), t.TempDir())
+		if got := v.Verify("example.com/Tool", "v1.3.0"); got.Status != VerifySkipped {
+			t.Errorf("%s: Verify() = %+v, want skipped", tt.name, got)
+		}
+	}
+
+	v := NewSumDBVerifier(newTestProxyResolver("direct", map[string]string{"GOSUMDB": gosumdb}), t.TempDir())
+	if got := v.Verify("example.com/Tool", "v1.3.0"); got.Status != VerifySkipped {
+		t.Errorf("direct: Verify() = %+v, want skipped", got)
+	}
+}
+
+// TestSumDBVerifierDetectsSplitView checks that a database serving a tree
+// that does not extend the one an earlier run saw is rejected.
+func TestSumDBVerifierDetectsSplitView(t *testing.T) {
+	mod := "module example.com/Tool\n"
+	modHash, err := hashMod([]byte(mod))
+	if err != nil {
+		t.Fatal(err)
+	}
+	proxy := proxyServer(t, map[string]string{
+		"/example.com/!tool/@v/v1.2.0.info": `{"Version":"v1.2.0"}`,
+		"/example.com/!tool/@v/v1.2.0.mod":  mod,
+		"/example.com/!tool/@v/v1.3.0.info": `{"Version":"v1.3.0"}`,
+		"/example.com/!tool/@v/v1.3.0.mod":  mod,
+	})
+	records := map[string]string{
+		"example.com/Tool@v1.2.0":  "example.com/Tool v1.2.0/go.mod " + modHash + "\n",
+		"example.com/Tool@v1.3.0":  "example.com/Tool v1.3.0/go.mod " + modHash + "\n",
+		"example.com/other@v0.1.0": "example.com/other v0.1.0/go.mod h1:other=\n",
+	}
+	skey, vkey, err := note.GenerateKey(rand.Reader, "sum.example.com")
+	if err != nil {
+		t.Fatal(err)
+	}
+	honest, _ := checksumDB(t, skey, records)
+	forked, forkedOps := checksumDB(t, skey, records)
+	if _, err := forkedOps.Lookup(context.Background(), module.Version{Path: "example.com/other", Version: "v0.1.0"}); err != nil {
+		t.Fatal(err)
+	}
+
+	dir := t.TempDir()
+	v := NewSumDBVerifier(newTestProxyResolver(proxy.URL, map[string]string{"GOSUMDB": vkey + " " + honest.URL}), dir)
+	if got := v.Verify("example.com/Tool", "v1.2.0"); got.Status != VerifyOK {
+		t.Fatalf("Verify() from the honest database = %+v, want verified", got)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "sum.example.com", "latest")); err != nil {
+		t.Fatalf("latest tree head not stored: %v", err)
+	}
+
+	// A later run reads from a database that signed a different tree.
+	v = NewSumDBVerifier(newTestProxyResolver(proxy.URL, map[string]string{"GOSUMDB": vkey + " " + forked.URL}), dir)
+	if got := v.Verify("example.com/Tool", "v1.3.0"); got.Status != VerifyMismatch {
+		t.Errorf("Verify() from the forked database = %+v, want mismatch", got)
+	}
+}
+
+func TestSumDBVerifierThroughProxy(t *testing.T) {
+	proxyURL, gosumdb := verifyFixture(t)
+	vkey, dbURL, _ := strings.Cut(gosumdb, " ")
+	target, _ := url.Parse(dbURL)
+	dbProxy := httputil.NewSingleHostReverseProxy(target)
+	var proxied []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if rest, ok := strings.CutPrefix(r.URL.Path, "/sumdb/sum.example.com"); ok {
+			proxied = append(proxied, rest)
+			if rest == "/supported" {
+				return
+			}
+			r.URL.Path = rest
+			dbProxy.ServeHTTP(w, r)
+			return
+		}
+		http.Redirect(w, r, proxyURL+r.URL.Path, http.StatusFound)
+	}))
+	defer server.Close()
+
+	// The database URL is unreachable, so lookups must go through the proxy.
+	gosumdb = vkey + " http://127.0.0.1:1"
+	v := NewSumDBVerifier(newTestProxyResolver(server.URL, map[string]string{"GOSUMDB": gosumdb}), t.TempDir())
+	if got := v.Verify("example.com/Tool", "v1.2.0"); got.Status != VerifyOK {
+		t.Fatalf("Verify() = %+v, want verified", got)
+	}
+	if len(proxied) < 2 || proxied[0] != "/supported" {
+		t.Errorf("proxied requests = %q, want /supported then lookups", proxied)
+	}
+}
+
+func TestParseGOSUMDB(t *testing.T) {
+	const golangKey = "sum.golang.org+033de0ae+Ac4zctda0e5eza+HJyk9SxEdh+s3Ux18htTTAD8OuAn8"
+	tests := []struct {
+		value, key, url string
+		wantErr         bool
+	}{
+		{value: "sum.golang.org", key: golangKey, url: "https://sum.golang.org"},
+		{value: "sum.golang.google.cn", key: golangKey, url: "https://sum.golang.google.cn"},
+		{value: "sum.golang.org https://proxy.example.com/sumdb/sum.golang.org/", key: golangKey, url: "https://proxy.example.com/sumdb/sum.golang.org"},
+		{value: "sum.example.com+01234567+AQID", key: "sum.example.com+01234567+AQID", url: "https://sum.example.com"},
+		{value: "sum.example.com", wantErr: true},
+		{value: "", wantErr: true},
+	}
+	for _, tt := range tests {
+		key, url, err := parseGOSUMDB(tt.value)
+		if tt.wantErr {
+			if err == nil {
+				t.Errorf("parseGOSUMDB(%q) expected error", tt.value)
+			}
+			continue
+		}
+		if err != nil || key != tt.key || url != tt.url {
+			t.Errorf("parseGOSUMDB(%q) = %q, %q, %v; want %q, %q", tt.value, key, url, err, tt.key, tt.url)
+		}
+	}
+}
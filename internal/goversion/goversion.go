@@ -6,7 +6,9 @@ import (
 	"errors"
 	"fmt"
 	"io"
+	"os"
 	"os/exec"
+	"path/filepath"
 	"strings"
 )
 
@@ -16,6 +18,8 @@ type Info struct {
 	PackagePath string
 	Version     string
 	GoVersion   string
+	// File is the path of the binary the information was read from.
+	File string
 }
 
 // Runner is an interface for retrieving version info from Go binaries.
@@ -43,13 +47,42 @@ func (d *DefaultRunner) GetInfo(binaryName string) (*Info, error) {
 		return nil, errors.New("binary not found: " + binaryName)
 	}
 
+	return readInfo(binaryPath)
+}
+
+// DirRunner implements Runner for binaries stored in a fixed directory, such as
+// cross-compiled binaries that cannot be resolved from PATH.
+type DirRunner struct {
+	Dir  string
+	GOOS string
+}
+
+// GetInfo runs go version -m -json against the named binary in Dir.
+func (d *DirRunner) GetInfo(binaryName string) (*Info, error) {
+	binaryPath := filepath.Join(d.Dir, binaryName)
+	if d.GOOS == "windows" && !strings.HasSuffix(binaryName, ".exe") {
+		binaryPath += ".exe"
+	}
+	if info, err := os.Stat(binaryPath); err != nil || info.IsDir() {
+		return nil, errors.New("binary not found: " + binaryPath)
+	}
+
+	return readInfo(binaryPath)
+}
+
+func readInfo(binaryPath string) (*Info, error) {
 	cmd := exec.Command("go", "version", "-m", "-json", binaryPath)
 	output, err := cmd.Output()
 	if err != nil {
 		return nil, errors.New("failed to execute go version")
 	}
 
-	return ParseVersionJSON(output)
+	info, err := ParseVersionJSON(output)
+	if err != nil {
+		return nil, err
+	}
+	info.File = binaryPath
+	return info, nil
 }
 
 // ParseVersionJSON parses the JSON output from go version -m -json into Info.
@@ -120,11 +153,28 @@ func ParseGitHubRepo(modulePath string) (owner string, repo string, err error) {
 	if !IsGitHubRepo(modulePath) {
 		return "", "", errors.New("not a GitHub repository path: " + modulePath)
 	}
+	return ParseRepoPath(modulePath, "github.com")
+}
+
+// ModuleHost returns the host part of a module path, such as "github.com" for
+// "github.com/owner/repo".
+func ModuleHost(modulePath string) string {
+	host, _, _ := strings.Cut(modulePath, "/")
+	return host
+}
+
+// ParseRepoPath extracts the owner and repo from a "host/owner/repo" module
+// path served by the given host, such as a GitHub Enterprise server.
+func ParseRepoPath(modulePath, host string) (owner string, repo string, err error) {
+	rest, ok := strings.CutPrefix(modulePath, host+"/")
+	if !ok {
+		return "", "", fmt.Errorf("not a %s repository path: %s", host, modulePath)
+	}
 
-	parts := strings.SplitN(modulePath, "/", 4)
-	if len(parts) < 3 || parts[1] == "" || parts[2] == "" {
-		return "", "", errors.New("invalid GitHub repository path: " + modulePath)
+	parts := strings.SplitN(rest, "/", 3)
+	if len(parts) < 2 || parts[0] == "" || parts[1] == "" {
+		return "", "", errors.New("invalid repository path: " + modulePath)
 	}
 
-	return parts[1], parts[2], nil
+	return parts[0], parts[1], nil
 }
@@ -2,6 +2,7 @@ package goversion
 
 import (
 	"errors"
+	"strings"
 	"testing"
 )
 
@@ -195,3 +196,31 @@ func TestMockRunnerError(t *testing.T) {
 		t.Fatalf("expected error from mock runner")
 	}
 }
+
+func TestDirRunnerMissingBinary(t *testing.T) {
+	r := &DirRunner{Dir: t.TempDir(), GOOS: "linux"}
+	_, err := r.GetInfo("missing")
+	if err == nil || !strings.Contains(err.Error(), "binary not found") {
+		t.Fatalf("expected binary not found error, got %v", err)
+	}
+}
+
+func TestParseRepoPathEnterpriseHost(t *testing.T) {
+	owner, repo, err := ParseRepoPath("github.corp.example/platform/tool/cmd/tool", "github.corp.example")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if owner != "platform" || repo != "tool" {
+		t.Fatalf("expected platform/tool, got %s/%s", owner, repo)
+	}
+
+	if _, _, err := ParseRepoPath("github.com/owner/repo", "github.corp.example"); err == nil {
+		t.Fatalf("expected error for a path on another host")
+	}
+	if _, _, err := ParseRepoPath("github.corp.example/owner", "github.corp.example"); err == nil {
+		t.Fatalf("expected error for missing repo")
+	}
+	if got := ModuleHost("github.corp.example/owner/repo"); got != "github.corp.example" {
+		t.Fatalf("expected github.corp.example, got %s", got)
+	}
+}
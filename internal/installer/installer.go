@@ -1,9 +1,13 @@
 package installer
 
 import (
+	"errors"
 	"fmt"
+	"io"
 	"os"
 	"os/exec"
+	"path/filepath"
+	"runtime"
 	"strings"
 )
 
@@ -12,10 +16,77 @@ type Installer interface {
 	Install(modulePath string, version string) (string, error)
 }
 
+// Target describes the GOOS/GOARCH platform binaries are built for.
+type Target struct {
+	GOOS   string
+	GOARCH string
+}
+
+// ResolveTarget returns the target for the given GOOS and GOARCH values. When
+// only one is provided the other defaults to the host platform; when neither is
+// provided the zero Target is returned, meaning a native install.
+func ResolveTarget(goos, goarch string) Target {
+	if goos == "" && goarch == "" {
+		return Target{}
+	}
+	if goos == "" {
+		goos = runtime.GOOS
+	}
+	if goarch == "" {
+		goarch = runtime.GOARCH
+	}
+	return Target{GOOS: goos, GOARCH: goarch}
+}
+
+// IsZero reports whether the target is unset, meaning a native install.
+func (t Target) IsZero() bool {
+	return t.GOOS == "" && t.GOARCH == ""
+}
+
+// String returns the target in "goos/goarch" form, or an empty string for a
+// native install.
+func (t Target) String() string {
+	if t.IsZero() {
+		return ""
+	}
+	return t.GOOS + "/" + t.GOARCH
+}
+
+// DefaultCrossBinDir returns the directory used for cross-compiled binaries when
+// none is configured. It mirrors the go command's own layout of
+// $GOPATH/bin/<goos>_<goarch>.
+func DefaultCrossBinDir(target Target) (string, error) {
+	gopath, err := goEnv("GOPATH")
+	if err != nil {
+		return "", err
+	}
+	gopath, _, _ = strings.Cut(gopath, string(os.PathListSeparator))
+	return filepath.Join(gopath, "bin", target.GOOS+"_"+target.GOARCH), nil
+}
+
+// DefaultBinDir returns the directory go install places native binaries in:
+// GOBIN when set, otherwise $GOPATH/bin.
+func DefaultBinDir() (string, error) {
+	if gobin, err := goEnv("GOBIN"); err == nil {
+		return gobin, nil
+	}
+	gopath, err := goEnv("GOPATH")
+	if err != nil {
+		return "", err
+	}
+	gopath, _, _ = strings.Cut(gopath, string(os.PathListSeparator))
+	return filepath.Join(gopath, "bin"), nil
+}
+
 // DefaultInstaller implements Installer using go install.
 type DefaultInstaller struct {
 	goproxy    string
 	cgoenabled *bool
+	target     Target
+	binDir     string
+	// extraEnv holds KEY=value entries, such as proxy settings, added to
+	// the environment of go install.
+	extraEnv []string
 }
 
 // NewDefaultInstaller creates a new DefaultInstaller.
@@ -36,34 +107,46 @@ func NewDefaultInstallerWithOptions(goproxy string, cgoenabled *bool) *DefaultIn
 	return &DefaultInstaller{goproxy: goproxy, cgoenabled: cgoenabled}
 }
 
+// NewCrossInstaller creates a new DefaultInstaller that builds binaries for the
+// given target platform and places them in binDir. The go command refuses to
+// install cross-compiled binaries into GOBIN, so the build runs against a
+// temporary GOPATH and the result is moved into binDir afterwards.
+func NewCrossInstaller(goproxy string, cgoenabled *bool, target Target, binDir string) *DefaultInstaller {
+	return &DefaultInstaller{goproxy: goproxy, cgoenabled: cgoenabled, target: target, binDir: binDir}
+}
+
+// SetExtraEnv adds KEY=value entries to the environment of go install,
+// overriding inherited values.
+func (d *DefaultInstaller) SetExtraEnv(env []string) {
+	d.extraEnv = env
+}
+
 // buildInstallCmd creates the exec.Cmd for "go install {modulePath}@{version}" with the
 // current process environment so that variables such as GOPROXY are forwarded.
 // If the installer was configured with a GOPROXY value it overrides any inherited GOPROXY.
 // If the installer was configured with a CGO_ENABLED value it overrides any inherited CGO_ENABLED.
+// If the installer was configured with a target platform, GOOS and GOARCH are set and GOBIN is cleared.
 func (d *DefaultInstaller) buildInstallCmd(modulePath, version string) *exec.Cmd {
 	cmd := exec.Command("go", "install", modulePath+"@"+version)
 	env := os.Environ()
 	if d.goproxy != "" {
-		filtered := make([]string, 0, len(env))
-		for _, e := range env {
-			if !strings.HasPrefix(e, "GOPROXY=") {
-				filtered = append(filtered, e)
-			}
-		}
-		env = append(filtered, "GOPROXY="+d.goproxy)
+		env = setEnv(env, "GOPROXY", d.goproxy)
 	}
 	if d.cgoenabled != nil {
 		value := "1"
 		if !*d.cgoenabled {
 			value = "0"
 		}
-		filtered := make([]string, 0, len(env))
-		for _, e := range env {
-			if !strings.HasPrefix(e, "CGO_ENABLED=") {
-				filtered = append(filtered, e)
-			}
-		}
-		env = append(filtered, "CGO_ENABLED="+value)
+		env = setEnv(env, "CGO_ENABLED", value)
+	}
+	if !d.target.IsZero() {
+		env = setEnv(env, "GOOS", d.target.GOOS)
+		env = setEnv(env, "GOARCH", d.target.GOARCH)
+		env = setEnv(env, "GOBIN", "")
+	}
+	for _, entry := range d.extraEnv {
+		key, value, _ := strings.Cut(entry, "=")
+		env = setEnv(env, key, value)
 	}
 	cmd.Env = env
 	return cmd
@@ -71,6 +154,9 @@ func (d *DefaultInstaller) buildInstallCmd(modulePath, version string) *exec.Cmd
 
 // Install runs "go install {modulePath}@{version}" and returns the combined output.
 func (d *DefaultInstaller) Install(modulePath string, version string) (string, error) {
+	if !d.target.IsZero() {
+		return d.crossInstall(modulePath, version)
+	}
 	cmd := d.buildInstallCmd(modulePath, version)
 	out, err := cmd.CombinedOutput()
 	if err != nil {
@@ -78,3 +164,110 @@ func (d *DefaultInstaller) Install(modulePath string, version string) (string, e
 	}
 	return string(out), nil
 }
+
+// crossInstall builds modulePath@version for the configured target into a
+// temporary GOPATH, sharing the real module cache, and moves the resulting
+// binary into the installer's bin directory.
+func (d *DefaultInstaller) crossInstall(modulePath, version string) (string, error) {
+	if d.binDir == "" {
+		return "", errors.New("no output directory configured for " + d.target.String())
+	}
+	modCache, err := goEnv("GOMODCACHE")
+	if err != nil {
+		return "", err
+	}
+	workDir, err := os.MkdirTemp("", "gogitup-cross-")
+	if err != nil {
+		return "", err
+	}
+	defer os.RemoveAll(workDir)
+
+	cmd := d.buildInstallCmd(modulePath, version)
+	cmd.Env = setEnv(cmd.Env, "GOPATH", workDir)
+	cmd.Env = setEnv(cmd.Env, "GOMODCACHE", modCache)
+	out, err := cmd.CombinedOutput()
+	if err != nil {
+		return string(out), fmt.Errorf("go install %s@%s for %s failed: %w\n%s", modulePath, version, d.target, err, string(out))
+	}
+
+	if err := os.MkdirAll(d.binDir, 0755); err != nil {
+		return string(out), err
+	}
+	// go install places cross-compiled binaries in bin/<goos>_<goarch> and
+	// binaries matching the host platform directly in bin.
+	moved := 0
+	for _, dir := range []string{
+		filepath.Join(workDir, "bin", d.target.GOOS+"_"+d.target.GOARCH),
+		filepath.Join(workDir, "bin"),
+	} {
+		entries, err := os.ReadDir(dir)
+		if err != nil {
+			continue
+		}
+		for _, entry := range entries {
+			if entry.IsDir() {
+				continue
+			}
+			if err := moveFile(filepath.Join(dir, entry.Name()), filepath.Join(d.binDir, entry.Name())); err != nil {
+				return string(out), err
+			}
+			moved++
+		}
+	}
+	if moved == 0 {
+		return string(out), fmt.Errorf("go install %s@%s for %s produced no binary", modulePath, version, d.target)
+	}
+	return string(out), nil
+}
+
+// moveFile moves src to dst, copying when a rename across filesystems is not possible.
+func moveFile(src, dst string) error {
+	if err := os.Rename(src, dst); err == nil {
+		return nil
+	}
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+	info, err := in.Stat()
+	if err != nil {
+		return err
+	}
+	out, err := os.OpenFile(dst, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, info.Mode().Perm())
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(out, in); err != nil {
+		out.Close()
+		return err
+	}
+	if err := out.Close(); err != nil {
+		return err
+	}
+	return os.Remove(src)
+}
+
+// setEnv returns env with any existing entry for key replaced by key=value.
+func setEnv(env []string, key, value string) []string {
+	filtered := make([]string, 0, len(env)+1)
+	for _, e := range env {
+		if !strings.HasPrefix(e, key+"=") {
+			filtered = append(filtered, e)
+		}
+	}
+	return append(filtered, key+"="+value)
+}
+
+// goEnv returns the value of a go env variable as reported by the go command.
+func goEnv(key string) (string, error) {
+	out, err := exec.Command("go", "env", key).Output()
+	if err != nil {
+		return "", fmt.Errorf("go env %s failed: %w", key, err)
+	}
+	value := strings.TrimSpace(string(out))
+	if value == "" {
+		return "", fmt.Errorf("go env %s is empty", key)
+	}
+	return value, nil
+}
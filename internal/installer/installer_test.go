@@ -2,6 +2,7 @@ package installer
 
 import (
 	"fmt"
+	"runtime"
 	"slices"
 	"strings"
 	"testing"
@@ -203,3 +204,44 @@ func TestNewDefaultInstallerWithOptionsNilCGOEnabled(t *testing.T) {
 	}
 	t.Fatal("expected inherited CGO_ENABLED=0 not found in command environment")
 }
+
+// TestResolveTarget verifies that a partial target is completed from the host platform.
+func TestResolveTarget(t *testing.T) {
+	if got := ResolveTarget("", ""); !got.IsZero() {
+		t.Fatalf("expected zero target, got %+v", got)
+	}
+	if got := ResolveTarget("linux", "arm64"); got.String() != "linux/arm64" {
+		t.Fatalf("expected linux/arm64, got %q", got.String())
+	}
+	if got := ResolveTarget("", "arm64"); got.GOOS != runtime.GOOS || got.GOARCH != "arm64" {
+		t.Fatalf("expected host GOOS with arm64, got %+v", got)
+	}
+}
+
+// TestNewCrossInstallerSetsTargetEnv verifies that cross installs set GOOS/GOARCH and clear GOBIN.
+func TestNewCrossInstallerSetsTargetEnv(t *testing.T) {
+	t.Setenv("GOBIN", "/home/user/go/bin")
+	t.Setenv("GOOS", "darwin")
+	inst := NewCrossInstaller("", nil, Target{GOOS: "linux", GOARCH: "arm64"}, t.TempDir())
+	cmd := inst.buildInstallCmd("github.com/example/tool", "v1.0.0")
+
+	for _, want := range []string{"GOOS=linux", "GOARCH=arm64", "GOBIN="} {
+		if !slices.Contains(cmd.Env, want) {
+			t.Fatalf("expected %s in command environment", want)
+		}
+	}
+	if slices.Contains(cmd.Env, "GOOS=darwin") || slices.Contains(cmd.Env, "GOBIN=/home/user/go/bin") {
+		t.Fatal("expected inherited GOOS and GOBIN to be overridden")
+	}
+}
+
+// TestNewDefaultInstallerLeavesTargetEnv verifies that native installs do not touch GOOS/GOARCH/GOBIN.
+func TestNewDefaultInstallerLeavesTargetEnv(t *testing.T) {
+	t.Setenv("GOBIN", "/home/user/go/bin")
+	inst := NewDefaultInstaller()
+	cmd := inst.buildInstallCmd("github.com/example/tool", "v1.0.0")
+
+	if !slices.Contains(cmd.Env, "GOBIN=/home/user/go/bin") {
+		t.Fatal("expected inherited GOBIN to be preserved")
+	}
+}
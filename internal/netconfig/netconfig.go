@@ -0,0 +1,187 @@
+// Package netconfig applies the proxy and TLS settings of the config to HTTP
+// clients and to the go commands gogitup runs.
+package netconfig
+
+import (
+	"bytes"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+
+	"github.com/UnitVectorY-Labs/gogitup/internal/fileutil"
+)
+
+// Options describe how to reach HTTPS servers from a corporate network.
+type Options struct {
+	// Proxy is the URL of the proxy used for every request. When empty, the
+	// HTTPS_PROXY, HTTP_PROXY, and NO_PROXY environment variables apply.
+	Proxy string
+	// CABundle is a PEM file of certificate authorities trusted in addition
+	// to the system roots.
+	CABundle string
+	// ClientCert and ClientKey are PEM files of a certificate presented to
+	// servers that require mutual TLS. ClientKey defaults to ClientCert for
+	// files holding both.
+	ClientCert string
+	ClientKey  string
+}
+
+// IsZero reports whether no options are set.
+func (o Options) IsZero() bool {
+	return o == Options{}
+}
+
+// ProxyURL parses the configured proxy, returning nil when none is set.
+func (o Options) ProxyURL() (*url.URL, error) {
+	if o.Proxy == "" {
+		return nil, nil
+	}
+	u, err := url.Parse(o.Proxy)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https" && u.Scheme != "socks5") || u.Host == "" {
+		return nil, fmt.Errorf("invalid proxy URL %q", o.Proxy)
+	}
+	return u, nil
+}
+
+// Transport returns an HTTP transport that uses the proxy and TLS options.
+func (o Options) Transport() (*http.Transport, error) {
+	t := http.DefaultTransport.(*http.Transport).Clone()
+	proxy, err := o.ProxyURL()
+	if err != nil {
+		return nil, err
+	}
+	if proxy != nil {
+		t.Proxy = http.ProxyURL(proxy)
+	}
+	if t.TLSClientConfig, err = o.TLSConfig(); err != nil {
+		return nil, err
+	}
+	return t, nil
+}
+
+// TLSConfig returns the TLS configuration for the CA bundle and client
+// certificate options.
+func (o Options) TLSConfig() (*tls.Config, error) {
+	cfg := &tls.Config{}
+	if o.CABundle != "" {
+		pool, err := x509.SystemCertPool()
+		if err != nil {
+			pool = x509.NewCertPool()
+		}
+		data, err := readBundle(o.CABundle)
+		if err != nil {
+			return nil, err
+		}
+		pool.AppendCertsFromPEM(data)
+		cfg.RootCAs = pool
+	}
+	if o.ClientCert != "" {
+		cert, err := tls.LoadX509KeyPair(o.ClientCert, o.clientKey())
+		if err != nil {
+			return nil, fmt.Errorf("failed to load client certificate: %w", err)
+		}
+		cfg.Certificates = []tls.Certificate{cert}
+	}
+	return cfg, nil
+}
+
+// ClientCertificate parses the leaf of the configured client certificate, or
+// returns nil when none is set.
+func (o Options) ClientCertificate() (*x509.Certificate, error) {
+	if o.ClientCert == "" {
+		return nil, nil
+	}
+	cert, err := tls.LoadX509KeyPair(o.ClientCert, o.clientKey())
+	if err != nil {
+		return nil, fmt.Errorf("failed to load client certificate: %w", err)
+	}
+	return x509.ParseCertificate(cert.Certificate[0])
+}
+
+func (o Options) clientKey() string {
+	if o.ClientKey != "" {
+		return o.ClientKey
+	}
+	return o.ClientCert
+}
+
+// CountCertificates returns the number of certificates in the CA bundle.
+func (o Options) CountCertificates() (int, error) {
+	data, err := readBundle(o.CABundle)
+	if err != nil {
+		return 0, err
+	}
+	return bytes.Count(data, []byte("-----BEGIN CERTIFICATE-----")), nil
+}
+
+func readBundle(path string) ([]byte, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read CA bundle: %w", err)
+	}
+	if !x509.NewCertPool().AppendCertsFromPEM(data) {
+		return nil, fmt.Errorf("no certificates found in CA bundle %s", path)
+	}
+	return data, nil
+}
+
+// systemBundles are where Linux and BSD distributions keep the system roots,
+// in the order crypto/x509 looks for them.
+var systemBundles = []string{
+	"/etc/ssl/certs/ca-certificates.crt",
+	"/etc/pki/tls/certs/ca-bundle.crt",
+	"/etc/ssl/ca-bundle.pem",
+	"/etc/pki/tls/cacert.pem",
+	"/etc/pki/ca-trust/extracted/pem/tls-ca-bundle.pem",
+	"/etc/ssl/cert.pem",
+	"/usr/local/etc/ssl/cert.pem",
+}
+
+// Env returns the environment entries that make the go command, and the git
+// commands it runs to fetch modules directly, use the options. SSL_CERT_FILE
+// and GIT_SSL_CAINFO replace the system roots rather than adding to them, so
+// the CA bundle is combined with the system roots into bundlePath first. The
+// go command cannot present client certificates; git can.
+func (o Options) Env(bundlePath string) ([]string, error) {
+	var env []string
+	if o.Proxy != "" {
+		env = append(env, "HTTPS_PROXY="+o.Proxy, "HTTP_PROXY="+o.Proxy)
+	}
+	if o.CABundle != "" {
+		if err := writeCombinedBundle(bundlePath, o.CABundle); err != nil {
+			return nil, err
+		}
+		env = append(env, "SSL_CERT_FILE="+bundlePath, "GIT_SSL_CAINFO="+bundlePath)
+	}
+	if o.ClientCert != "" {
+		env = append(env, "GIT_SSL_CERT="+o.ClientCert, "GIT_SSL_KEY="+o.clientKey())
+	}
+	return env, nil
+}
+
+func writeCombinedBundle(path, extra string) error {
+	data, err := readBundle(extra)
+	if err != nil {
+		return err
+	}
+	var combined bytes.Buffer
+	system := systemBundles
+	if env := os.Getenv("SSL_CERT_FILE"); env != "" {
+		system = []string{env}
+	}
+	for _, p := range system {
+		if roots, err := os.ReadFile(p); err == nil {
+			combined.Write(roots)
+			combined.WriteString("\n")
+			break
+		} else if !errors.Is(err, os.ErrNotExist) {
+			return fmt.Errorf("failed to read system certificates: %w", err)
+		}
+	}
+	combined.Write(data)
+	return fileutil.WriteAtomic(path, combined.Bytes(), 0644)
+}
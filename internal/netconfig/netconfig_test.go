@@ -0,0 +1,137 @@
+package netconfig
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+)
+
+// writeClientCert writes a self-signed client certificate and its key to dir.
+func writeClientCert(t *testing.T, dir string) (string, string) {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("failed to generate key: %v", err)
+	}
+	tmpl := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "gogitup-test"},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, &key.PublicKey, key)
+	if err != nil {
+		t.Fatalf("failed to create certificate: %v", err)
+	}
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		t.Fatalf("failed to marshal key: %v", err)
+	}
+	certPath := filepath.Join(dir, "client.pem")
+	keyPath := filepath.Join(dir, "client.key")
+	os.WriteFile(certPath, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0600)
+	os.WriteFile(keyPath, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER}), 0600)
+	return certPath, keyPath
+}
+
+func writeServerCA(t *testing.T, server *httptest.Server) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "ca.pem")
+	data := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: server.Certificate().Raw})
+	if err := os.WriteFile(path, data, 0600); err != nil {
+		t.Fatalf("failed to write CA bundle: %v", err)
+	}
+	return path
+}
+
+func TestTransportPresentsClientCertificate(t *testing.T) {
+	server := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if len(r.TLS.PeerCertificates) == 0 || r.TLS.PeerCertificates[0].Subject.CommonName != "gogitup-test" {
+			w.WriteHeader(http.StatusForbidden)
+		}
+	}))
+	server.TLS = &tls.Config{ClientAuth: tls.RequireAnyClientCert}
+	server.StartTLS()
+	defer server.Close()
+
+	certPath, keyPath := writeClientCert(t, t.TempDir())
+	opts := Options{CABundle: writeServerCA(t, server), ClientCert: certPath, ClientKey: keyPath}
+	transport, err := opts.Transport()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	resp, err := (&http.Client{Transport: transport}).Get(server.URL)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("expected the client certificate to be accepted, got %d", resp.StatusCode)
+	}
+
+	cert, err := opts.ClientCertificate()
+	if err != nil || cert.Subject.CommonName != "gogitup-test" {
+		t.Fatalf("unexpected client certificate: %v, %v", cert, err)
+	}
+}
+
+func TestTransportRejectsInvalidSettings(t *testing.T) {
+	if _, err := (Options{Proxy: "proxy.example.com:3128"}).Transport(); err == nil {
+		t.Fatal("expected error for a proxy without a scheme")
+	}
+	empty := filepath.Join(t.TempDir(), "empty.pem")
+	os.WriteFile(empty, []byte("not a certificate"), 0600)
+	if _, err := (Options{CABundle: empty}).Transport(); err == nil || !strings.Contains(err.Error(), "no certificates") {
+		t.Fatalf("expected error for a bundle without certificates, got %v", err)
+	}
+}
+
+func TestEnvCombinesCABundleWithSystemRoots(t *testing.T) {
+	server := httptest.NewTLSServer(nil)
+	defer server.Close()
+
+	dir := t.TempDir()
+	system := filepath.Join(dir, "system.pem")
+	os.WriteFile(system, []byte("# system roots\n"), 0600)
+	t.Setenv("SSL_CERT_FILE", system)
+
+	certPath, keyPath := writeClientCert(t, dir)
+	bundlePath := filepath.Join(dir, "combined", "ca-bundle.pem")
+	opts := Options{Proxy: "http://proxy.example.com:3128", CABundle: writeServerCA(t, server), ClientCert: certPath, ClientKey: keyPath}
+	env, err := opts.Env(bundlePath)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	for _, want := range []string{
+		"HTTPS_PROXY=http://proxy.example.com:3128",
+		"SSL_CERT_FILE=" + bundlePath,
+		"GIT_SSL_CAINFO=" + bundlePath,
+		"GIT_SSL_KEY=" + keyPath,
+	} {
+		if !slices.Contains(env, want) {
+			t.Errorf("expected %q in %v", want, env)
+		}
+	}
+
+	data, err := os.ReadFile(bundlePath)
+	if err != nil {
+		t.Fatalf("failed to read combined bundle: %v", err)
+	}
+	if !strings.HasPrefix(string(data), "# system roots") || !strings.Contains(string(data), "BEGIN CERTIFICATE") {
+		t.Fatalf("expected system roots followed by the CA bundle, got:\n%s", data)
+	}
+}
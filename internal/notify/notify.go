@@ -0,0 +1,226 @@
+// Package notify sends notifications about available updates to the
+// desktop and to webhooks.
+package notify
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"os/exec"
+	"strings"
+	"text/template"
+)
+
+// Kinds of notifiers.
+const (
+	KindDesktop = "desktop"
+	KindWebhook = "webhook"
+	KindSlack   = "slack"
+	KindTeams   = "teams"
+)
+
+// Kinds lists the supported notifier kinds.
+var Kinds = []string{KindDesktop, KindWebhook, KindSlack, KindTeams}
+
+// Update is an available update reported in a notification.
+type Update struct {
+	Name             string `json:"name"`
+	InstalledVersion string `json:"installed_version"`
+	LatestVersion    string `json:"latest_version"`
+}
+
+// Notifier delivers a notification about available updates.
+type Notifier interface {
+	Notify(updates []Update) error
+}
+
+// Options configure a notifier.
+type Options struct {
+	// Kind is one of Kinds.
+	Kind string
+	// URL is the webhook URL for every kind but desktop.
+	URL string
+	// Body is a text/template for the JSON body of a generic webhook. When
+	// empty, DefaultBody is used.
+	Body string
+	// Headers are added to webhook requests.
+	Headers map[string]string
+}
+
+// DefaultBody is the JSON body a generic webhook sends unless configured
+// otherwise.
+const DefaultBody = `{"title": {{json .Title}}, "text": {{json .Text}}, "host": {{json .Host}}, "updates": {{json .Updates}}}`
+
+// Bodies of the chat webhooks. Teams cards need blank lines to break lines.
+const (
+	slackBody = `{"text": {{json (printf "*%s*\n%s" .Title .Text)}}}`
+	teamsBody = `{"@type": "MessageCard", "@context": "https://schema.org/extensions", "summary": {{json .Title}}, "title": {{json .Title}}, "text": {{json (join .Lines "\n\n")}}}`
+)
+
+// New creates the notifier described by opts. Webhooks are sent with client.
+func New(opts Options, client *http.Client) (Notifier, error) {
+	body := opts.Body
+	switch opts.Kind {
+	case KindDesktop:
+		return &Desktop{run: runCommand, lookPath: exec.LookPath}, nil
+	case KindWebhook:
+		if body == "" {
+			body = DefaultBody
+		}
+	case KindSlack:
+		body = slackBody
+	case KindTeams:
+		body = teamsBody
+	default:
+		return nil, fmt.Errorf("unknown notifier %q", opts.Kind)
+	}
+	if opts.URL == "" {
+		return nil, fmt.Errorf("%s notifier has no URL", opts.Kind)
+	}
+	tmpl, err := ParseBody(body)
+	if err != nil {
+		return nil, err
+	}
+	return &Webhook{url: opts.URL, body: tmpl, headers: opts.Headers, client: client}, nil
+}
+
+// Title summarizes updates in one line.
+func Title(updates []Update) string {
+	if len(updates) == 1 {
+		return "gogitup: 1 update available"
+	}
+	return fmt.Sprintf("gogitup: %d updates available", len(updates))
+}
+
+// lines describes each update on its own line.
+func lines(updates []Update) []string {
+	out := make([]string, len(updates))
+	for i, u := range updates {
+		out[i] = fmt.Sprintf("%s %s → %s", u.Name, u.InstalledVersion, u.LatestVersion)
+	}
+	return out
+}
+
+// templateData is what webhook body templates are executed with.
+type templateData struct {
+	Title   string
+	Text    string
+	Lines   []string
+	Host    string
+	Updates []Update
+}
+
+var templateFuncs = template.FuncMap{
+	"json": func(v any) (string, error) {
+		data, err := json.Marshal(v)
+		return string(data), err
+	},
+	"join": strings.Join,
+}
+
+// ParseBody parses a webhook body template. Templates are executed with
+// .Title, .Text (one update per line), .Lines, .Host, and .Updates, whose
+// elements have .Name, .InstalledVersion, and .LatestVersion, and can quote
+// values as JSON with the json function.
+func ParseBody(body string) (*template.Template, error) {
+	tmpl, err := template.New("body").Funcs(templateFuncs).Option("missingkey=error").Parse(body)
+	if err != nil {
+		return nil, fmt.Errorf("invalid body template: %w", err)
+	}
+	return tmpl, nil
+}
+
+// Webhook posts a JSON body rendered from a template.
+type Webhook struct {
+	url     string
+	body    *template.Template
+	headers map[string]string
+	client  *http.Client
+}
+
+// Notify implements Notifier.
+func (w *Webhook) Notify(updates []Update) error {
+	host, _ := os.Hostname()
+	l := lines(updates)
+	var buf bytes.Buffer
+	err := w.body.Execute(&buf, templateData{
+		Title:   Title(updates),
+		Text:    strings.Join(l, "\n"),
+		Lines:   l,
+		Host:    host,
+		Updates: updates,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to render webhook body: %w", err)
+	}
+	if !json.Valid(buf.Bytes()) {
+		return errors.New("webhook body template did not produce valid JSON")
+	}
+
+	req, err := http.NewRequest(http.MethodPost, w.url, &buf)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	for k, v := range w.headers {
+		req.Header.Set(k, v)
+	}
+	resp, err := w.client.Do(req)
+	if err != nil {
+		// The URL often embeds a secret, so it is not repeated.
+		var urlErr *url.Error
+		if errors.As(err, &urlErr) {
+			err = urlErr.Err
+		}
+		return fmt.Errorf("webhook request failed: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return fmt.Errorf("webhook returned %s: %s", resp.Status, strings.TrimSpace(string(msg)))
+	}
+	return nil
+}
+
+// Desktop shows a desktop notification with notify-send, or through the
+// freedesktop notification service on D-Bus when notify-send is not
+// installed.
+type Desktop struct {
+	run      func(name string, args ...string) error
+	lookPath func(file string) (string, error)
+}
+
+// Notify implements Notifier.
+func (d *Desktop) Notify(updates []Update) error {
+	title, text := Title(updates), strings.Join(lines(updates), "\n")
+	if _, err := d.lookPath("notify-send"); err == nil {
+		return d.run("notify-send", "--app-name=gogitup", title, text)
+	}
+	if _, err := d.lookPath("gdbus"); err == nil {
+		return d.run("gdbus", "call", "--session",
+			"--dest", "org.freedesktop.Notifications",
+			"--object-path", "/org/freedesktop/Notifications",
+			"--method", "org.freedesktop.Notifications.Notify",
+			gvariantString("gogitup"), "0", gvariantString(""), gvariantString(title), gvariantString(text),
+			"@as []", "@a{sv} {}", "-1")
+	}
+	return errors.New("desktop notifications need notify-send or gdbus")
+}
+
+// gvariantString quotes s as a GVariant text-format string for gdbus.
+func gvariantString(s string) string {
+	return "'" + strings.NewReplacer(`\`, `\\`, `'`, `\'`).Replace(s) + "'"
+}
+
+func runCommand(name string, args ...string) error {
+	out, err := exec.Command(name, args...).CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("%s failed: %w: %s", name, err, strings.TrimSpace(string(out)))
+	}
+	return nil
+}
@@ -0,0 +1,175 @@
+package notify
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+var testUpdates = []Update{
+	{Name: "gopls", InstalledVersion: "v0.16.1", LatestVersion: "v0.16.2"},
+	{Name: "staticcheck", InstalledVersion: "v0.5.0", LatestVersion: "v0.5.1"},
+}
+
+// webhookServer records the JSON bodies posted to it.
+func webhookServer(t *testing.T, status int) (*httptest.Server, *[]map[string]any) {
+	t.Helper()
+	var bodies []map[string]any
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Content-Type") != "application/json" {
+			t.Errorf("Content-Type = %q", r.Header.Get("Content-Type"))
+		}
+		data, _ := io.ReadAll(r.Body)
+		var body map[string]any
+		if err := json.Unmarshal(data, &body); err != nil {
+			t.Errorf("invalid JSON body %q: %v", data, err)
+		}
+		if token := r.Header.Get("X-Token"); token != "" {
+			body["token"] = token
+		}
+		bodies = append(bodies, body)
+		w.WriteHeader(status)
+		w.Write([]byte("rejected"))
+	}))
+	t.Cleanup(server.Close)
+	return server, &bodies
+}
+
+func TestWebhookDefaultBody(t *testing.T) {
+	server, bodies := webhookServer(t, http.StatusOK)
+	n, err := New(Options{Kind: KindWebhook, URL: server.URL, Headers: map[string]string{"X-Token": "secret"}}, http.DefaultClient)
+	if err != nil {
+		t.Fatalf("New() error: %v", err)
+	}
+	if err := n.Notify(testUpdates); err != nil {
+		t.Fatalf("Notify() error: %v", err)
+	}
+
+	body := (*bodies)[0]
+	if body["title"] != "gogitup: 2 updates available" || body["text"] != "gopls v0.16.1 → v0.16.2\nstaticcheck v0.5.0 → v0.5.1" {
+		t.Errorf("unexpected body %v", body)
+	}
+	updates, _ := body["updates"].([]any)
+	if len(updates) != 2 || updates[0].(map[string]any)["latest_version"] != "v0.16.2" {
+		t.Errorf("unexpected updates %v", body["updates"])
+	}
+	if body["token"] != "secret" {
+		t.Errorf("expected configured header, got %v", body)
+	}
+}
+
+func TestWebhookTemplatedBody(t *testing.T) {
+	server, bodies := webhookServer(t, http.StatusOK)
+	tmpl := `{"summary": {{json .Title}}, "apps": [{{range $i, $u := .Updates}}{{if $i}}, {{end}}{{json $u.Name}}{{end}}]}`
+	n, err := New(Options{Kind: KindWebhook, URL: server.URL, Body: tmpl}, http.DefaultClient)
+	if err != nil {
+		t.Fatalf("New() error: %v", err)
+	}
+	if err := n.Notify(testUpdates); err != nil {
+		t.Fatalf("Notify() error: %v", err)
+	}
+	apps, _ := (*bodies)[0]["apps"].([]any)
+	if (*bodies)[0]["summary"] != "gogitup: 2 updates available" || len(apps) != 2 || apps[1] != "staticcheck" {
+		t.Errorf("unexpected body %v", (*bodies)[0])
+	}
+}
+
+func TestWebhookRejectsInvalidJSON(t *testing.T) {
+	server, bodies := webhookServer(t, http.StatusOK)
+	n, err := New(Options{Kind: KindWebhook, URL: server.URL, Body: `{"text": {{.Text}}}`}, http.DefaultClient)
+	if err != nil {
+		t.Fatalf("New() error: %v", err)
+	}
+	if err := n.Notify(testUpdates); err == nil || !strings.Contains(err.Error(), "valid JSON") {
+		t.Fatalf("Notify() error = %v, want invalid JSON", err)
+	}
+	if len(*bodies) != 0 {
+		t.Fatalf("expected nothing to be sent, got %v", *bodies)
+	}
+}
+
+func TestWebhookReportsStatus(t *testing.T) {
+	server, _ := webhookServer(t, http.StatusForbidden)
+	n, _ := New(Options{Kind: KindSlack, URL: server.URL}, http.DefaultClient)
+	err := n.Notify(testUpdates)
+	if err == nil || !strings.Contains(err.Error(), "403 Forbidden: rejected") {
+		t.Fatalf("Notify() error = %v, want status", err)
+	}
+}
+
+func TestChatWebhookBodies(t *testing.T) {
+	server, bodies := webhookServer(t, http.StatusOK)
+	for _, kind := range []string{KindSlack, KindTeams} {
+		n, err := New(Options{Kind: kind, URL: server.URL}, http.DefaultClient)
+		if err != nil {
+			t.Fatalf("New(%s) error: %v", kind, err)
+		}
+		if err := n.Notify(testUpdates[:1]); err != nil {
+			t.Fatalf("Notify(%s) error: %v", kind, err)
+		}
+	}
+
+	if got := (*bodies)[0]["text"]; got != "*gogitup: 1 update available*\ngopls v0.16.1 → v0.16.2" {
+		t.Errorf("Slack text = %q", got)
+	}
+	teams := (*bodies)[1]
+	if teams["@type"] != "MessageCard" || teams["title"] != "gogitup: 1 update available" || teams["text"] != "gopls v0.16.1 → v0.16.2" {
+		t.Errorf("unexpected Teams card %v", teams)
+	}
+}
+
+func TestNewRejectsInvalidOptions(t *testing.T) {
+	for _, opts := range []Options{
+		{Kind: "email"},
+		{Kind: KindSlack},
+		{Kind: KindWebhook, URL: "https://example.com", Body: "{{.Title"},
+	} {
+		if _, err := New(opts, http.DefaultClient); err == nil {
+			t.Errorf("New(%+v) expected error", opts)
+		}
+	}
+}
+
+func TestDesktopNotify(t *testing.T) {
+	var calls [][]string
+	run := func(name string, args ...string) error {
+		calls = append(calls, append([]string{name}, args...))
+		return nil
+	}
+	installed := func(names ...string) func(string) (string, error) {
+		return func(file string) (string, error) {
+			for _, n := range names {
+				if n == file {
+					return "/usr/bin/" + file, nil
+				}
+			}
+			return "", errors.New("not found")
+		}
+	}
+
+	d := &Desktop{run: run, lookPath: installed("notify-send", "gdbus")}
+	if err := d.Notify(testUpdates[:1]); err != nil {
+		t.Fatalf("Notify() error: %v", err)
+	}
+	want := []string{"notify-send", "--app-name=gogitup", "gogitup: 1 update available", "gopls v0.16.1 → v0.16.2"}
+	if strings.Join(calls[0], "|") != strings.Join(want, "|") {
+		t.Fatalf("calls = %q, want %q", calls[0], want)
+	}
+
+	d = &Desktop{run: run, lookPath: installed("gdbus")}
+	if err := d.Notify([]Update{{Name: "it's", InstalledVersion: "v1", LatestVersion: "v2"}}); err != nil {
+		t.Fatalf("Notify() error: %v", err)
+	}
+	if calls[1][0] != "gdbus" || !strings.Contains(strings.Join(calls[1], " "), `'it\'s v1 → v2'`) {
+		t.Fatalf("unexpected gdbus call %q", calls[1])
+	}
+
+	d = &Desktop{run: run, lookPath: installed()}
+	if err := d.Notify(testUpdates); err == nil {
+		t.Fatal("expected error without notify-send or gdbus")
+	}
+}
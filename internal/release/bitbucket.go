@@ -0,0 +1,72 @@
+package release
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"golang.org/x/mod/semver"
+)
+
+// BitbucketClient looks up versions through the Bitbucket Cloud API. Bitbucket
+// has no releases, so the highest semantic version tag stands in for one.
+type BitbucketClient struct {
+	token      string
+	baseURL    string
+	httpClient *http.Client
+}
+
+type bitbucketTags struct {
+	Values []struct {
+		Name string `json:"name"`
+	} `json:"values"`
+	Next string `json:"next"`
+}
+
+// bitbucketMaxPages bounds how many pages of tags are read for one repository.
+const bitbucketMaxPages = 5
+
+// NewBitbucketClient creates a client for the Bitbucket API at baseURL, such
+// as https://api.bitbucket.org, with an optional access token.
+func NewBitbucketClient(baseURL, token string) *BitbucketClient {
+	return &BitbucketClient{token: token, baseURL: strings.TrimSuffix(baseURL, "/"), httpClient: newHTTPClient()}
+}
+
+// GetLatestRelease returns the highest vMAJOR.MINOR.PATCH tag of
+// workspace/repo, ignoring prerelease tags.
+func (c *BitbucketClient) GetLatestRelease(workspace, repo string) (string, error) {
+	next := fmt.Sprintf("%s/2.0/repositories/%s/%s/refs/tags?pagelen=100&sort=-target.date", c.baseURL, url.PathEscape(workspace), url.PathEscape(repo))
+	latest := ""
+	for page := 0; next != "" && page < bitbucketMaxPages; page++ {
+		req, err := newRequest(next)
+		if err != nil {
+			return "", err
+		}
+		if c.token != "" {
+			req.Header.Set("Authorization", "Bearer "+c.token)
+		}
+
+		var tags bitbucketTags
+		if err := getJSON(c.httpClient, req, "tags of "+workspace+"/"+repo, &tags); err != nil {
+			return "", err
+		}
+		for _, tag := range tags.Values {
+			if isReleaseVersion(tag.Name) && (latest == "" || semver.Compare(tag.Name, latest) > 0) {
+				latest = tag.Name
+			}
+		}
+		next = tags.Next
+	}
+	if latest == "" {
+		return "", errors.New("no version tag found for " + workspace + "/" + repo)
+	}
+	return latest, nil
+}
+
+// isReleaseVersion reports whether v is a vMAJOR.MINOR.PATCH tag without
+// prerelease or build suffixes.
+func isReleaseVersion(v string) bool {
+	return semver.IsValid(v) && semver.Canonical(v) == v && semver.Prerelease(v) == ""
+}
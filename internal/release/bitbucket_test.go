@@ -0,0 +1,44 @@
+package release
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestBitbucketGetLatestReleaseFollowsPages(t *testing.T) {
+	var server *httptest.Server
+	server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/2.0/repositories/workspace/tool/refs/tags" {
+			t.Errorf("unexpected path: %s", r.URL.Path)
+		}
+		if r.Header.Get("Authorization") != "Bearer bb-token" {
+			t.Errorf("unexpected Authorization header: %s", r.Header.Get("Authorization"))
+		}
+		if r.URL.Query().Get("page") == "2" {
+			w.Write([]byte(`{"values":[{"name":"v1.10.0"},{"name":"v1.9.3"}]}`))
+			return
+		}
+		w.Write([]byte(`{"values":[{"name":"v2.0.0-rc.1"},{"name":"v1.2.0"},{"name":"nightly"}],"next":"` + server.URL + `/2.0/repositories/workspace/tool/refs/tags?page=2"}`))
+	}))
+	defer server.Close()
+
+	tag, err := NewBitbucketClient(server.URL, "bb-token").GetLatestRelease("workspace", "tool")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if tag != "v1.10.0" {
+		t.Fatalf("expected v1.10.0, got %s", tag)
+	}
+}
+
+func TestBitbucketGetLatestReleaseNoVersionTags(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"values":[{"name":"latest"}]}`))
+	}))
+	defer server.Close()
+
+	if _, err := NewBitbucketClient(server.URL, "").GetLatestRelease("workspace", "tool"); err == nil {
+		t.Fatal("expected error when no tag is a version")
+	}
+}
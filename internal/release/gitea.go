@@ -0,0 +1,48 @@
+package release
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// GiteaClient looks up releases through the Gitea API, which Forgejo servers
+// such as Codeberg also provide.
+type GiteaClient struct {
+	token      string
+	baseURL    string
+	httpClient *http.Client
+}
+
+type giteaRelease struct {
+	TagName string `json:"tag_name"`
+}
+
+// NewGiteaClient creates a client for the Gitea or Forgejo server at baseURL
+// with an optional access token.
+func NewGiteaClient(baseURL, token string) *GiteaClient {
+	return &GiteaClient{token: token, baseURL: strings.TrimSuffix(baseURL, "/"), httpClient: newHTTPClient()}
+}
+
+// GetLatestRelease returns the tag of the latest release of owner/repo that is
+// neither a draft nor a prerelease.
+func (c *GiteaClient) GetLatestRelease(owner, repo string) (string, error) {
+	req, err := newRequest(fmt.Sprintf("%s/api/v1/repos/%s/%s/releases/latest", c.baseURL, url.PathEscape(owner), url.PathEscape(repo)))
+	if err != nil {
+		return "", err
+	}
+	if c.token != "" {
+		req.Header.Set("Authorization", "token "+c.token)
+	}
+
+	var release giteaRelease
+	if err := getJSON(c.httpClient, req, "latest release of "+owner+"/"+repo, &release); err != nil {
+		return "", err
+	}
+	if release.TagName == "" {
+		return "", errors.New("no release tag found for " + owner + "/" + repo)
+	}
+	return release.TagName, nil
+}
@@ -0,0 +1,39 @@
+package release
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestGiteaGetLatestRelease(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/v1/repos/owner/tool/releases/latest" {
+			t.Errorf("unexpected path: %s", r.URL.Path)
+		}
+		if r.Header.Get("Authorization") != "token gitea-token" {
+			t.Errorf("unexpected Authorization header: %s", r.Header.Get("Authorization"))
+		}
+		w.Write([]byte(`{"tag_name":"v1.4.0"}`))
+	}))
+	defer server.Close()
+
+	tag, err := NewGiteaClient(server.URL+"/", "gitea-token").GetLatestRelease("owner", "tool")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if tag != "v1.4.0" {
+		t.Fatalf("expected v1.4.0, got %s", tag)
+	}
+}
+
+func TestGiteaGetLatestReleaseNotFound(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+	}))
+	defer server.Close()
+
+	if _, err := NewGiteaClient(server.URL, "").GetLatestRelease("owner", "tool"); err == nil {
+		t.Fatal("expected error for status 404")
+	}
+}
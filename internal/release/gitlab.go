@@ -0,0 +1,51 @@
+package release
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// GitLabClient looks up releases through the GitLab REST API (v4).
+type GitLabClient struct {
+	token      string
+	baseURL    string
+	httpClient *http.Client
+}
+
+type gitLabRelease struct {
+	TagName         string `json:"tag_name"`
+	UpcomingRelease bool   `json:"upcoming_release"`
+}
+
+// NewGitLabClient creates a client for the GitLab instance at baseURL, such as
+// https://gitlab.com, with an optional personal access token.
+func NewGitLabClient(baseURL, token string) *GitLabClient {
+	return &GitLabClient{token: token, baseURL: strings.TrimSuffix(baseURL, "/"), httpClient: newHTTPClient()}
+}
+
+// GetLatestRelease returns the tag of the most recent release of the project
+// owner/repo, where owner may include subgroups. Upcoming releases are skipped.
+func (c *GitLabClient) GetLatestRelease(owner, repo string) (string, error) {
+	project := owner + "/" + repo
+	req, err := newRequest(fmt.Sprintf("%s/api/v4/projects/%s/releases?order_by=released_at&sort=desc&per_page=20", c.baseURL, url.PathEscape(project)))
+	if err != nil {
+		return "", err
+	}
+	if c.token != "" {
+		req.Header.Set("PRIVATE-TOKEN", c.token)
+	}
+
+	var releases []gitLabRelease
+	if err := getJSON(c.httpClient, req, "releases of "+project, &releases); err != nil {
+		return "", err
+	}
+	for _, r := range releases {
+		if !r.UpcomingRelease && r.TagName != "" {
+			return r.TagName, nil
+		}
+	}
+	return "", errors.New("no release found for " + project)
+}
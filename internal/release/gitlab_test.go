@@ -0,0 +1,39 @@
+package release
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestGitLabGetLatestRelease(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.EscapedPath() != "/api/v4/projects/group%2Fsub%2Fproject/releases" {
+			t.Errorf("unexpected path: %s", r.URL.EscapedPath())
+		}
+		if r.Header.Get("PRIVATE-TOKEN") != "gl-token" {
+			t.Errorf("unexpected PRIVATE-TOKEN header: %s", r.Header.Get("PRIVATE-TOKEN"))
+		}
+		w.Write([]byte(`[{"tag_name":"v3.0.0","upcoming_release":true},{"tag_name":"v2.1.0"},{"tag_name":"v2.0.0"}]`))
+	}))
+	defer server.Close()
+
+	tag, err := NewGitLabClient(server.URL, "gl-token").GetLatestRelease("group/sub", "project")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if tag != "v2.1.0" {
+		t.Fatalf("expected v2.1.0, got %s", tag)
+	}
+}
+
+func TestGitLabGetLatestReleaseNone(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`[]`))
+	}))
+	defer server.Close()
+
+	if _, err := NewGitLabClient(server.URL, "").GetLatestRelease("group", "project"); err == nil {
+		t.Fatal("expected error when the project has no releases")
+	}
+}
@@ -0,0 +1,163 @@
+// Package release looks up the latest release of a module's repository on the
+// code hosting service that serves it.
+package release
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"regexp"
+	"strings"
+
+	"github.com/UnitVectorY-Labs/gogitup/internal/goversion"
+	"github.com/UnitVectorY-Labs/gogitup/internal/retry"
+)
+
+// Provider looks up the latest release tag of a repository. It has the same
+// method set as github.Client, so GitHub clients are providers as well.
+type Provider interface {
+	GetLatestRelease(owner, repo string) (string, error)
+}
+
+// Provider kinds, as used in the release_hosts config.
+const (
+	KindGitHub    = "github"
+	KindGitLab    = "gitlab"
+	KindGitea     = "gitea"
+	KindForgejo   = "forgejo"
+	KindBitbucket = "bitbucket"
+)
+
+// Kinds lists the provider kinds that can be configured for a host.
+var Kinds = []string{KindGitLab, KindGitea, KindForgejo, KindBitbucket}
+
+// Host is the release provider serving modules under a module path host.
+type Host struct {
+	Kind     string
+	Provider Provider
+}
+
+// Hosts maps module path hosts, such as "gitlab.com", to their providers.
+type Hosts map[string]Host
+
+// Default describes a well-known public host and its API.
+type Default struct {
+	Host    string
+	Kind    string
+	BaseURL string
+}
+
+// Defaults are the public hosts that have a release provider without any
+// configuration.
+var Defaults = []Default{
+	{Host: "gitlab.com", Kind: KindGitLab, BaseURL: "https://gitlab.com"},
+	{Host: "codeberg.org", Kind: KindForgejo, BaseURL: "https://codeberg.org"},
+	{Host: "gitea.com", Kind: KindGitea, BaseURL: "https://gitea.com"},
+	{Host: "bitbucket.org", Kind: KindBitbucket, BaseURL: "https://api.bitbucket.org"},
+}
+
+// DefaultBaseURL returns the API base URL for a self-hosted server at host.
+func DefaultBaseURL(kind, host string) string {
+	if kind == KindBitbucket && host == "bitbucket.org" {
+		return "https://api.bitbucket.org"
+	}
+	return "https://" + host
+}
+
+// TokenEnv returns the environment variable that holds the token for a
+// provider kind when the config does not name one.
+func TokenEnv(kind string) string {
+	switch kind {
+	case KindGitLab:
+		return "GITLAB_TOKEN"
+	case KindGitea, KindForgejo:
+		return "GITEA_TOKEN"
+	case KindBitbucket:
+		return "BITBUCKET_TOKEN"
+	}
+	return ""
+}
+
+// NewProvider creates the provider of the given kind for the API at baseURL.
+// A nil httpClient uses a client with the default timeout and retry policy.
+func NewProvider(kind, baseURL, token string, httpClient *http.Client) (Provider, error) {
+	if httpClient == nil {
+		httpClient = newHTTPClient()
+	}
+	switch kind {
+	case KindGitLab:
+		c := NewGitLabClient(baseURL, token)
+		c.httpClient = httpClient
+		return c, nil
+	case KindGitea, KindForgejo:
+		c := NewGiteaClient(baseURL, token)
+		c.httpClient = httpClient
+		return c, nil
+	case KindBitbucket:
+		c := NewBitbucketClient(baseURL, token)
+		c.httpClient = httpClient
+		return c, nil
+	}
+	return nil, fmt.Errorf("unknown release provider %q", kind)
+}
+
+var majorSuffixRe = regexp.MustCompile(`^v[0-9]+$`)
+
+// ParseRepoPath splits a module path served by host into the owner and repo
+// that identify its repository for a provider kind. GitLab projects may be
+// nested in subgroups, so the whole module path (without a /vN major version
+// suffix) names the project; other providers use the first two elements.
+func ParseRepoPath(kind, modulePath, host string) (owner string, repo string, err error) {
+	if kind != KindGitLab {
+		return goversion.ParseRepoPath(modulePath, host)
+	}
+
+	rest, ok := strings.CutPrefix(modulePath, host+"/")
+	if !ok {
+		return "", "", fmt.Errorf("not a %s repository path: %s", host, modulePath)
+	}
+	parts := strings.Split(rest, "/")
+	if n := len(parts); n > 2 && majorSuffixRe.MatchString(parts[n-1]) {
+		parts = parts[:n-1]
+	}
+	for _, p := range parts {
+		if p == "" {
+			return "", "", errors.New("invalid repository path: " + modulePath)
+		}
+	}
+	if len(parts) < 2 {
+		return "", "", errors.New("invalid repository path: " + modulePath)
+	}
+	return strings.Join(parts[:len(parts)-1], "/"), parts[len(parts)-1], nil
+}
+
+func newHTTPClient() *http.Client {
+	return retry.NewHTTPClient(retry.DefaultTimeout, retry.DefaultPolicy, nil)
+}
+
+// getJSON sends req and decodes a successful JSON response into v.
+func getJSON(c *http.Client, req *http.Request, what string, v any) error {
+	resp, err := c.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to fetch %s: %w", what, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("API returned status %d for %s", resp.StatusCode, what)
+	}
+	if err := json.NewDecoder(resp.Body).Decode(v); err != nil {
+		return fmt.Errorf("failed to parse %s response: %w", what, err)
+	}
+	return nil
+}
+
+func newRequest(url string) (*http.Request, error) {
+	req, err := http.NewRequest("GET", url, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Accept", "application/json")
+	return req, nil
+}
@@ -0,0 +1,55 @@
+package release
+
+import "testing"
+
+func TestParseRepoPath(t *testing.T) {
+	tests := []struct {
+		kind, modulePath, host string
+		wantOwner, wantRepo    string
+		wantErr                bool
+	}{
+		{kind: KindGitLab, modulePath: "gitlab.com/group/sub/project", host: "gitlab.com", wantOwner: "group/sub", wantRepo: "project"},
+		{kind: KindGitLab, modulePath: "gitlab.com/group/project/v2", host: "gitlab.com", wantOwner: "group", wantRepo: "project"},
+		{kind: KindGitLab, modulePath: "gitlab.com/group", host: "gitlab.com", wantErr: true},
+		{kind: KindForgejo, modulePath: "codeberg.org/owner/tool/cmd/tool", host: "codeberg.org", wantOwner: "owner", wantRepo: "tool"},
+		{kind: KindBitbucket, modulePath: "bitbucket.org/workspace/tool", host: "bitbucket.org", wantOwner: "workspace", wantRepo: "tool"},
+		{kind: KindGitea, modulePath: "gitlab.com/owner/tool", host: "gitea.com", wantErr: true},
+	}
+
+	for _, tc := range tests {
+		owner, repo, err := ParseRepoPath(tc.kind, tc.modulePath, tc.host)
+		if tc.wantErr {
+			if err == nil {
+				t.Errorf("%s: expected an error", tc.modulePath)
+			}
+			continue
+		}
+		if err != nil || owner != tc.wantOwner || repo != tc.wantRepo {
+			t.Errorf("%s: got %q, %q, %v; want %q, %q", tc.modulePath, owner, repo, err, tc.wantOwner, tc.wantRepo)
+		}
+	}
+}
+
+func TestNewProvider(t *testing.T) {
+	for _, kind := range Kinds {
+		if _, err := NewProvider(kind, "https://example.com", "", nil); err != nil {
+			t.Fatalf("unexpected error for %s: %v", kind, err)
+		}
+	}
+	if _, err := NewProvider("svn", "https://example.com", "", nil); err == nil {
+		t.Fatal("expected error for unknown provider")
+	}
+}
+
+func TestIsReleaseVersion(t *testing.T) {
+	for _, v := range []string{"v1.0.0", "v1.10.0", "v0.9.9"} {
+		if !isReleaseVersion(v) {
+			t.Errorf("expected %q to be a release version", v)
+		}
+	}
+	for _, v := range []string{"1.0.0", "v1.0", "v1.0.0-rc.1", "v01.0.0", "v1.0.0+build"} {
+		if isReleaseVersion(v) {
+			t.Errorf("expected %q not to be a release version", v)
+		}
+	}
+}
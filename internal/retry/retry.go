@@ -0,0 +1,239 @@
+// Package retry retries network operations that fail transiently, backing off
+// exponentially with jitter between attempts.
+package retry
+
+import (
+	"context"
+	"errors"
+	"io"
+	"math/rand/v2"
+	"net"
+	"net/http"
+	"strconv"
+	"syscall"
+	"time"
+)
+
+// Defaults used when the config does not set http_timeout or retries.
+const (
+	DefaultTimeout = 30 * time.Second
+	DefaultRetries = 2
+)
+
+// Policy describes how often and how patiently failed operations are retried.
+type Policy struct {
+	// Retries is the number of attempts after the first; 0 disables retries.
+	Retries int
+	// BaseDelay is the longest delay before the first retry. It doubles for
+	// each later retry.
+	BaseDelay time.Duration
+	// MaxDelay caps the delay between attempts, including delays requested by
+	// a Retry-After header.
+	MaxDelay time.Duration
+}
+
+// DefaultPolicy retries twice, waiting up to half a second and then up to a
+// second.
+var DefaultPolicy = NewPolicy(DefaultRetries)
+
+// NewPolicy returns the default policy with the given number of retries.
+func NewPolicy(retries int) Policy {
+	return Policy{Retries: retries, BaseDelay: 500 * time.Millisecond, MaxDelay: 10 * time.Second}
+}
+
+// Backoff returns the delay before retry n, counting from 1: a random duration
+// between zero and BaseDelay*2^(n-1), capped at MaxDelay.
+func (p Policy) Backoff(n int) time.Duration {
+	limit := p.BaseDelay << (n - 1)
+	if limit <= 0 || limit > p.MaxDelay {
+		limit = p.MaxDelay
+	}
+	if limit <= 0 {
+		return 0
+	}
+	return rand.N(limit + 1)
+}
+
+// transientError marks an error as worth retrying.
+type transientError struct {
+	err error
+}
+
+func (e *transientError) Error() string { return e.err.Error() }
+func (e *transientError) Unwrap() error { return e.err }
+
+// Transient marks err as a temporary failure that Do retries.
+func Transient(err error) error {
+	if err == nil {
+		return nil
+	}
+	return &transientError{err: err}
+}
+
+// IsTransient reports whether err was marked with Transient.
+func IsTransient(err error) bool {
+	var t *transientError
+	return errors.As(err, &t)
+}
+
+// Do calls fn until it succeeds, fails with an error not marked Transient, or
+// the policy's retries are used up. It returns the last error.
+func Do(p Policy, fn func() error) error {
+	for attempt := 1; ; attempt++ {
+		err := fn()
+		if err == nil || attempt > p.Retries || !IsTransient(err) {
+			return err
+		}
+		time.Sleep(p.Backoff(attempt))
+	}
+}
+
+// NewHTTPClient returns an HTTP client that gives each attempt of a request
+// timeout to complete and retries transient failures according to p. A nil
+// base uses http.DefaultTransport.
+func NewHTTPClient(timeout time.Duration, p Policy, base http.RoundTripper) *http.Client {
+	return &http.Client{Transport: &Transport{Base: base, Policy: p, Timeout: timeout}}
+}
+
+// Transport is an http.RoundTripper that retries requests failing with a
+// transient network error, 429 Too Many Requests, or a 5xx status. A Retry-After header
+// is honored when it asks to wait no longer than the policy's MaxDelay;
+// otherwise the response is returned to the caller as is. Requests that are
+// not idempotent, such as a webhook POST, are only retried when the
+// connection was refused, since the server never saw them.
+type Transport struct {
+	// Base performs each attempt; nil uses http.DefaultTransport.
+	Base   http.RoundTripper
+	Policy Policy
+	// Timeout bounds each attempt, including reading the response body. Zero
+	// means no timeout.
+	Timeout time.Duration
+}
+
+func (t *Transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	base := t.Base
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	for attempt := 1; ; attempt++ {
+		resp, err := t.attempt(base, req)
+		if attempt > t.Policy.Retries || req.Context().Err() != nil {
+			return resp, err
+		}
+		delay, ok := t.retryDelay(req, resp, err, attempt)
+		if !ok || (req.Body != nil && req.GetBody == nil) {
+			return resp, err
+		}
+		if resp != nil {
+			io.Copy(io.Discard, resp.Body)
+			resp.Body.Close()
+		}
+
+		timer := time.NewTimer(delay)
+		select {
+		case <-timer.C:
+		case <-req.Context().Done():
+			timer.Stop()
+			return nil, req.Context().Err()
+		}
+		if req.GetBody != nil {
+			body, err := req.GetBody()
+			if err != nil {
+				return nil, err
+			}
+			req = req.Clone(req.Context())
+			req.Body = body
+		}
+	}
+}
+
+// attempt sends req once, bounded by t.Timeout. The timeout keeps running
+// while the response body is read and is released when the body is closed.
+func (t *Transport) attempt(base http.RoundTripper, req *http.Request) (*http.Response, error) {
+	if t.Timeout <= 0 {
+		return base.RoundTrip(req)
+	}
+	ctx, cancel := context.WithTimeout(req.Context(), t.Timeout)
+	resp, err := base.RoundTrip(req.WithContext(ctx))
+	if err != nil {
+		cancel()
+		return nil, err
+	}
+	resp.Body = &cancelBody{ReadCloser: resp.Body, cancel: cancel}
+	return resp, nil
+}
+
+// retryDelay decides whether an attempt is retried and how long to wait first.
+func (t *Transport) retryDelay(req *http.Request, resp *http.Response, err error, attempt int) (time.Duration, bool) {
+	if !idempotent(req) {
+		return t.Policy.Backoff(attempt), err != nil && errors.Is(err, syscall.ECONNREFUSED)
+	}
+	if err != nil {
+		return t.Policy.Backoff(attempt), transientNetError(err)
+	}
+	if resp.StatusCode != http.StatusTooManyRequests && resp.StatusCode < 500 {
+		return 0, false
+	}
+	if resp.StatusCode == http.StatusNotImplemented || resp.Header.Get("X-RateLimit-Remaining") == "0" {
+		// Neither goes away by retrying within seconds.
+		return 0, false
+	}
+	if wait, ok := retryAfter(resp.Header.Get("Retry-After")); ok {
+		return wait, wait <= t.Policy.MaxDelay
+	}
+	return t.Policy.Backoff(attempt), true
+}
+
+// idempotent reports whether sending req twice has the same effect as
+// sending it once, so that a failure after the server may have received it
+// can be retried.
+func idempotent(req *http.Request) bool {
+	switch req.Method {
+	case "", http.MethodGet, http.MethodHead, http.MethodOptions, http.MethodTrace, http.MethodPut, http.MethodDelete:
+		return true
+	}
+	return false
+}
+
+// transientNetError reports whether a failed attempt may succeed when
+// repeated: timeouts, reset or refused connections, and connections closed
+// mid-response. Certificate errors, unknown hosts, and proxy configuration
+// errors fail the same way every time, so they are returned at once.
+func transientNetError(err error) bool {
+	var dnsErr *net.DNSError
+	if errors.As(err, &dnsErr) {
+		return !dnsErr.IsNotFound
+	}
+	if errors.Is(err, context.DeadlineExceeded) || errors.Is(err, syscall.ECONNRESET) ||
+		errors.Is(err, syscall.ECONNREFUSED) || errors.Is(err, io.ErrUnexpectedEOF) {
+		return true
+	}
+	var netErr net.Error
+	return errors.As(err, &netErr) && netErr.Timeout()
+}
+
+// retryAfter parses a Retry-After header given in seconds or as an HTTP date.
+func retryAfter(value string) (time.Duration, bool) {
+	if value == "" {
+		return 0, false
+	}
+	if secs, err := strconv.Atoi(value); err == nil && secs >= 0 {
+		return time.Duration(secs) * time.Second, true
+	}
+	if at, err := http.ParseTime(value); err == nil {
+		return max(time.Until(at), 0), true
+	}
+	return 0, false
+}
+
+// cancelBody releases an attempt's timeout once its body is closed.
+type cancelBody struct {
+	io.ReadCloser
+	cancel context.CancelFunc
+}
+
+func (b *cancelBody) Close() error {
+	err := b.ReadCloser.Close()
+	b.cancel()
+	return err
+}
@@ -0,0 +1,222 @@
+package retry
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"syscall"
+	"testing"
+	"time"
+)
+
+func testPolicy(retries int) Policy {
+	return Policy{Retries: retries, BaseDelay: time.Millisecond, MaxDelay: 50 * time.Millisecond}
+}
+
+func TestBackoffIsCapped(t *testing.T) {
+	p := Policy{BaseDelay: time.Second, MaxDelay: 3 * time.Second}
+	for n := 1; n <= 40; n++ {
+		if d := p.Backoff(n); d < 0 || d > 3*time.Second {
+			t.Fatalf("backoff %d out of range: %s", n, d)
+		}
+	}
+	if d := p.Backoff(1); d > time.Second {
+		t.Fatalf("first backoff exceeds base delay: %s", d)
+	}
+}
+
+func TestDoRetriesOnlyTransientErrors(t *testing.T) {
+	calls := 0
+	err := Do(testPolicy(2), func() error {
+		calls++
+		return Transient(errors.New("502"))
+	})
+	if err == nil || calls != 3 {
+		t.Fatalf("expected 3 calls and an error, got %d, %v", calls, err)
+	}
+
+	calls = 0
+	err = Do(testPolicy(2), func() error {
+		calls++
+		return errors.New("not found")
+	})
+	if err == nil || calls != 1 {
+		t.Fatalf("expected 1 call, got %d, %v", calls, err)
+	}
+}
+
+func TestTransportRetriesServerErrors(t *testing.T) {
+	calls := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		body, _ := io.ReadAll(r.Body)
+		if string(body) != "payload" {
+			t.Errorf("expected body to be replayed, got %q", body)
+		}
+		if calls < 3 {
+			w.WriteHeader(http.StatusBadGateway)
+			return
+		}
+		w.Write([]byte("ok"))
+	}))
+	defer server.Close()
+
+	req, err := http.NewRequest(http.MethodPut, server.URL, strings.NewReader("payload"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp, err := NewHTTPClient(time.Second, testPolicy(2), nil).Do(req)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK || calls != 3 {
+		t.Fatalf("expected success after 3 calls, got %d after %d", resp.StatusCode, calls)
+	}
+}
+
+func TestTransportHonorsRetryAfter(t *testing.T) {
+	calls := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		w.Header().Set("Retry-After", "3600")
+		w.WriteHeader(http.StatusTooManyRequests)
+	}))
+	defer server.Close()
+
+	resp, err := NewHTTPClient(time.Second, testPolicy(2), nil).Get(server.URL)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusTooManyRequests || calls != 1 {
+		t.Fatalf("expected a long Retry-After to be returned without retrying, got %d after %d calls", resp.StatusCode, calls)
+	}
+}
+
+func TestTransportDoesNotRetryClientErrors(t *testing.T) {
+	calls := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		w.WriteHeader(http.StatusNotFound)
+	}))
+	defer server.Close()
+
+	resp, err := NewHTTPClient(time.Second, testPolicy(2), nil).Get(server.URL)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	resp.Body.Close()
+	if calls != 1 {
+		t.Fatalf("expected 1 call, got %d", calls)
+	}
+}
+
+func TestTransportTimesOutEachAttempt(t *testing.T) {
+	calls := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		if calls == 1 {
+			time.Sleep(200 * time.Millisecond)
+		}
+		w.Write([]byte("ok"))
+	}))
+	defer server.Close()
+
+	resp, err := NewHTTPClient(50*time.Millisecond, testPolicy(1), nil).Get(server.URL)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	body, _ := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if string(body) != "ok" || calls != 2 {
+		t.Fatalf("expected the slow attempt to be retried, got %q after %d calls", body, calls)
+	}
+}
+
+type roundTripFunc func(*http.Request) (*http.Response, error)
+
+func (f roundTripFunc) RoundTrip(req *http.Request) (*http.Response, error) { return f(req) }
+
+func TestTransportRetriesOnlyTransientNetworkErrors(t *testing.T) {
+	tests := []struct {
+		name      string
+		err       error
+		wantCalls int
+	}{
+		{"connection refused", &net.OpError{Op: "dial", Err: os.NewSyscallError("connect", syscall.ECONNREFUSED)}, 3},
+		{"connection reset", &net.OpError{Op: "read", Err: os.NewSyscallError("read", syscall.ECONNRESET)}, 3},
+		{"unexpected EOF", io.ErrUnexpectedEOF, 3},
+		{"DNS timeout", &net.DNSError{Err: "timeout", Name: "example.com", IsTimeout: true}, 3},
+		{"unknown host", &net.DNSError{Err: "no such host", Name: "example.invalid", IsNotFound: true}, 1},
+		{"certificate", &tls.CertificateVerificationError{Err: x509.UnknownAuthorityError{}}, 1},
+		{"bad proxy", errors.New(`proxyconnect tcp: unsupported proxy scheme "socks6"`), 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			calls := 0
+			base := roundTripFunc(func(*http.Request) (*http.Response, error) {
+				calls++
+				return nil, tt.err
+			})
+			_, err := NewHTTPClient(time.Second, testPolicy(2), base).Get("https://example.com")
+			if err == nil {
+				t.Fatal("expected an error")
+			}
+			if calls != tt.wantCalls {
+				t.Fatalf("expected %d calls, got %d", tt.wantCalls, calls)
+			}
+		})
+	}
+}
+
+func TestTransportRetriesPostOnlyWhenRefused(t *testing.T) {
+	calls := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		w.WriteHeader(http.StatusBadGateway)
+	}))
+	defer server.Close()
+
+	resp, err := NewHTTPClient(time.Second, testPolicy(2), nil).Post(server.URL, "text/plain", strings.NewReader("payload"))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	resp.Body.Close()
+	if calls != 1 {
+		t.Fatalf("expected a POST answered with 502 to be sent once, got %d calls", calls)
+	}
+
+	tests := []struct {
+		name      string
+		err       error
+		wantCalls int
+	}{
+		{"connection refused", &net.OpError{Op: "dial", Err: os.NewSyscallError("connect", syscall.ECONNREFUSED)}, 3},
+		{"connection reset", &net.OpError{Op: "read", Err: os.NewSyscallError("read", syscall.ECONNRESET)}, 1},
+		{"timeout", context.DeadlineExceeded, 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			calls := 0
+			base := roundTripFunc(func(*http.Request) (*http.Response, error) {
+				calls++
+				return nil, tt.err
+			})
+			_, err := NewHTTPClient(time.Second, testPolicy(2), base).Post("https://example.com", "text/plain", strings.NewReader("payload"))
+			if err == nil {
+				t.Fatal("expected an error")
+			}
+			if calls != tt.wantCalls {
+				t.Fatalf("expected %d calls, got %d", tt.wantCalls, calls)
+			}
+		})
+	}
+}
@@ -0,0 +1,182 @@
+package schedule
+
+import (
+	"fmt"
+	"os/exec"
+	"strings"
+)
+
+// Lines that enclose the gogitup entry in the crontab.
+const (
+	cronBegin = "# BEGIN gogitup schedule"
+	cronEnd   = "# END gogitup schedule"
+)
+
+// cronSchedules are the crontab times of each frequency: noon, when a laptop
+// is more likely to be on than at midnight.
+var cronSchedules = map[Frequency]string{
+	Daily:  "0 12 * * *",
+	Weekly: "0 12 * * 1",
+}
+
+// Cron schedules the job with an entry in the user's crontab. Other entries
+// are left as they are.
+type Cron struct {
+	// crontab runs crontab with args, passing stdin to it.
+	crontab func(stdin string, args ...string) (string, error)
+}
+
+// NewCron creates a Cron that edits the user's crontab.
+func NewCron() *Cron {
+	return &Cron{crontab: func(stdin string, args ...string) (string, error) {
+		return run(stdin, "crontab", args...)
+	}}
+}
+
+// Name implements Scheduler.
+func (c *Cron) Name() string {
+	return "crontab"
+}
+
+// Available implements Scheduler.
+func (c *Cron) Available() bool {
+	_, err := exec.LookPath("crontab")
+	return err == nil
+}
+
+// Enable implements Scheduler.
+func (c *Cron) Enable(job Job) error {
+	current, err := c.read()
+	if err != nil {
+		return err
+	}
+	rest, _ := removeCronBlock(current)
+	return c.write(rest + cronBlock(job))
+}
+
+// Disable implements Scheduler.
+func (c *Cron) Disable() (bool, error) {
+	current, err := c.read()
+	if err != nil {
+		return false, err
+	}
+	rest, found := removeCronBlock(current)
+	if !found {
+		return false, nil
+	}
+	return true, c.write(rest)
+}
+
+// Status implements Scheduler.
+func (c *Cron) Status() (Status, error) {
+	current, err := c.read()
+	if err != nil {
+		return Status{}, err
+	}
+	line, found := cronEntry(current)
+	if !found {
+		return Status{}, nil
+	}
+	fields := strings.Fields(line)
+	if len(fields) < 6 {
+		return Status{Enabled: true, Command: line}, nil
+	}
+	st := Status{Enabled: true, Schedule: strings.Join(fields[:5], " ")}
+	for freq, spec := range cronSchedules {
+		if spec == st.Schedule {
+			st.Schedule = string(freq)
+		}
+	}
+	// The command is what follows the five time fields.
+	rest := line
+	for range 5 {
+		rest = strings.TrimLeft(rest, " \t")
+		rest = rest[strings.IndexAny(rest, " \t"):]
+	}
+	st.Command = strings.TrimSpace(rest)
+	return st, nil
+}
+
+// read returns the user's crontab, which is empty when there is none.
+func (c *Cron) read() (string, error) {
+	out, err := c.crontab("", "-l")
+	if err != nil {
+		if strings.Contains(out, "no crontab for") {
+			return "", nil
+		}
+		return "", err
+	}
+	return out, nil
+}
+
+func (c *Cron) write(crontab string) error {
+	_, err := c.crontab(crontab, "-")
+	return err
+}
+
+// cronBlock renders the crontab lines of job.
+func cronBlock(job Job) string {
+	var words []string
+	if len(job.Env) > 0 {
+		words = append(words, "env")
+		for _, env := range job.Env {
+			words = append(words, shellQuote(env))
+		}
+	}
+	for _, arg := range job.Command {
+		words = append(words, shellQuote(arg))
+	}
+	command := fmt.Sprintf("%s >> %s 2>&1", strings.Join(words, " "), shellQuote(job.LogFile))
+	// cron turns unescaped percent signs into newlines.
+	command = strings.ReplaceAll(command, "%", `\%`)
+	return fmt.Sprintf("%s\n%s %s\n%s\n", cronBegin, cronSchedules[job.Frequency], command, cronEnd)
+}
+
+// removeCronBlock removes the gogitup lines from a crontab and reports
+// whether there were any.
+func removeCronBlock(crontab string) (string, bool) {
+	var kept []string
+	found, inBlock := false, false
+	for _, line := range strings.SplitAfter(crontab, "\n") {
+		switch strings.TrimSpace(line) {
+		case cronBegin:
+			found, inBlock = true, true
+			continue
+		case cronEnd:
+			inBlock = false
+			continue
+		}
+		if !inBlock && line != "" {
+			kept = append(kept, line)
+		}
+	}
+	rest := strings.Join(kept, "")
+	if rest != "" && !strings.HasSuffix(rest, "\n") {
+		rest += "\n"
+	}
+	return rest, found
+}
+
+// cronEntry returns the schedule line of the gogitup block.
+func cronEntry(crontab string) (string, bool) {
+	inBlock := false
+	for _, line := range strings.Split(crontab, "\n") {
+		switch trimmed := strings.TrimSpace(line); {
+		case trimmed == cronBegin:
+			inBlock = true
+		case trimmed == cronEnd:
+			inBlock = false
+		case inBlock && trimmed != "" && !strings.HasPrefix(trimmed, "#"):
+			return trimmed, true
+		}
+	}
+	return "", false
+}
+
+// shellQuote quotes s for sh when it contains anything but plain characters.
+func shellQuote(s string) string {
+	if s != "" && strings.Trim(s, "abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ0123456789_-./:=@+,") == "" {
+		return s
+	}
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
@@ -0,0 +1,96 @@
+// Package schedule runs gogitup periodically in the background, with a
+// systemd user timer or, where systemd is not available, a crontab entry.
+package schedule
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"github.com/UnitVectorY-Labs/gogitup/internal/xdg"
+)
+
+// Frequency is how often a scheduled job runs.
+type Frequency string
+
+// Supported frequencies.
+const (
+	Daily  Frequency = "daily"
+	Weekly Frequency = "weekly"
+)
+
+// Job is a command run on a schedule.
+type Job struct {
+	Frequency Frequency
+	// Command is the executable followed by its arguments.
+	Command []string
+	// Env holds KEY=VALUE pairs set for the command, since scheduled jobs do
+	// not run in the user's login shell.
+	Env []string
+	// LogFile is appended the output of every run.
+	LogFile string
+}
+
+// Status describes the scheduled job, if any.
+type Status struct {
+	Enabled bool
+	// Schedule is the frequency, or the backend's own schedule when it was
+	// changed by hand.
+	Schedule string
+	// Command is the command line as the backend runs it.
+	Command string
+	// NextRun and LastRun are reported by systemd only.
+	NextRun string
+	LastRun string
+}
+
+// Scheduler installs and removes the scheduled job in one backend.
+type Scheduler interface {
+	// Name describes the backend, such as "systemd user timer".
+	Name() string
+	// Available reports whether the backend can be used on this machine.
+	Available() bool
+	// Enable installs job, replacing any job installed before.
+	Enable(job Job) error
+	// Disable removes the job and reports whether there was one.
+	Disable() (bool, error)
+	Status() (Status, error)
+}
+
+// DefaultLogPath is the log file scheduled jobs write to.
+func DefaultLogPath() string {
+	return filepath.Join(xdg.StateHome(), "gogitup", "schedule.log")
+}
+
+// Schedulers returns the supported backends in order of preference.
+func Schedulers() []Scheduler {
+	return []Scheduler{NewSystemd(), NewCron()}
+}
+
+// run runs a command, returning its output and, on failure, an error that
+// includes what the command printed.
+func run(stdin string, name string, args ...string) (string, error) {
+	cmd := exec.Command(name, args...)
+	if stdin != "" {
+		cmd.Stdin = strings.NewReader(stdin)
+	}
+	out, err := cmd.CombinedOutput()
+	if err != nil {
+		msg := strings.TrimSpace(string(out))
+		if msg == "" {
+			return string(out), fmt.Errorf("%s %s failed: %w", name, strings.Join(args, " "), err)
+		}
+		return string(out), fmt.Errorf("%s %s failed: %w: %s", name, strings.Join(args, " "), err, msg)
+	}
+	return string(out), nil
+}
+
+// writeFile writes data to path, creating its directory.
+func writeFile(path, data string) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	return os.WriteFile(path, []byte(data), 0644)
+}
@@ -0,0 +1,145 @@
+package schedule
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+var testJob = Job{
+	Frequency: Weekly,
+	Command:   []string{"/home/me/go/bin/gogitup", "--config", "/home/me/my 100%.yaml", "upgrade"},
+	Env:       []string{"PATH=/usr/local/go/bin:/usr/bin", "GOPROXY=https://proxy.example.com"},
+	LogFile:   "/home/me/.local/state/gogitup/schedule.log",
+}
+
+func TestSystemdEnableDisable(t *testing.T) {
+	var calls []string
+	s := &Systemd{Dir: t.TempDir(), systemctl: func(args ...string) (string, error) {
+		calls = append(calls, strings.Join(args, " "))
+		if args[0] == "show" {
+			return "UnitFileState=enabled\nNextElapseUSecRealtime=Mon 2026-10-26 00:23:10 UTC\nLastTriggerUSec=n/a\n", nil
+		}
+		return "", nil
+	}}
+
+	if err := s.Enable(testJob); err != nil {
+		t.Fatalf("Enable() error: %v", err)
+	}
+	service, _ := os.ReadFile(filepath.Join(s.Dir, ServiceUnit))
+	for _, want := range []string{
+		`Environment="PATH=/usr/local/go/bin:/usr/bin"`,
+		`ExecStart="/home/me/go/bin/gogitup" "--config" "/home/me/my 100%%.yaml" "upgrade"`,
+		"StandardOutput=append:/home/me/.local/state/gogitup/schedule.log",
+	} {
+		if !strings.Contains(string(service), want) {
+			t.Errorf("service unit lacks %q:\n%s", want, service)
+		}
+	}
+	timer, _ := os.ReadFile(filepath.Join(s.Dir, TimerUnit))
+	if !strings.Contains(string(timer), "OnCalendar=weekly\nPersistent=true") {
+		t.Errorf("unexpected timer unit:\n%s", timer)
+	}
+	if strings.Join(calls, "|") != "daemon-reload|enable --now gogitup.timer" {
+		t.Errorf("systemctl calls = %q", calls)
+	}
+
+	st, err := s.Status()
+	if err != nil {
+		t.Fatalf("Status() error: %v", err)
+	}
+	if !st.Enabled || st.Schedule != "weekly" || !strings.HasPrefix(st.Command, `"/home/me/go/bin/gogitup"`) || st.NextRun != "Mon 2026-10-26 00:23:10 UTC" {
+		t.Errorf("unexpected status %+v", st)
+	}
+
+	calls = nil
+	if found, err := s.Disable(); !found || err != nil {
+		t.Fatalf("Disable() = %v, %v", found, err)
+	}
+	if _, err := os.Stat(filepath.Join(s.Dir, TimerUnit)); !errors.Is(err, os.ErrNotExist) {
+		t.Error("expected the timer unit to be removed")
+	}
+	if strings.Join(calls, "|") != "disable --now gogitup.timer|daemon-reload" {
+		t.Errorf("systemctl calls = %q", calls)
+	}
+	if found, err := s.Disable(); found || err != nil {
+		t.Fatalf("second Disable() = %v, %v", found, err)
+	}
+}
+
+// fakeCrontab keeps a crontab in memory.
+type fakeCrontab struct {
+	content string
+	exists  bool
+}
+
+func (f *fakeCrontab) run(stdin string, args ...string) (string, error) {
+	if args[0] == "-l" {
+		if !f.exists {
+			return "no crontab for me\n", errors.New("exit status 1")
+		}
+		return f.content, nil
+	}
+	f.content, f.exists = stdin, true
+	return "", nil
+}
+
+func TestCronEnableKeepsOtherEntries(t *testing.T) {
+	f := &fakeCrontab{content: "MAILTO=me\n30 2 * * * backup\n", exists: true}
+	c := &Cron{crontab: f.run}
+
+	job := testJob
+	job.Frequency = Daily
+	if err := c.Enable(job); err != nil {
+		t.Fatalf("Enable() error: %v", err)
+	}
+	want := "MAILTO=me\n30 2 * * * backup\n" + cronBegin + "\n" +
+		`0 12 * * * env PATH=/usr/local/go/bin:/usr/bin GOPROXY=https://proxy.example.com /home/me/go/bin/gogitup --config '/home/me/my 100\%.yaml' upgrade >> /home/me/.local/state/gogitup/schedule.log 2>&1` +
+		"\n" + cronEnd + "\n"
+	if f.content != want {
+		t.Fatalf("crontab =\n%s\nwant\n%s", f.content, want)
+	}
+
+	// Enabling again replaces the entry.
+	if err := c.Enable(testJob); err != nil {
+		t.Fatalf("Enable() error: %v", err)
+	}
+	if strings.Count(f.content, cronBegin) != 1 || !strings.Contains(f.content, "0 12 * * 1 env") {
+		t.Fatalf("expected one weekly entry, got\n%s", f.content)
+	}
+
+	st, err := c.Status()
+	if err != nil {
+		t.Fatalf("Status() error: %v", err)
+	}
+	if !st.Enabled || st.Schedule != "weekly" || !strings.HasPrefix(st.Command, "env PATH=") {
+		t.Errorf("unexpected status %+v", st)
+	}
+
+	if found, err := c.Disable(); !found || err != nil {
+		t.Fatalf("Disable() = %v, %v", found, err)
+	}
+	if f.content != "MAILTO=me\n30 2 * * * backup\n" {
+		t.Fatalf("crontab after disable =\n%s", f.content)
+	}
+}
+
+func TestCronWithoutCrontab(t *testing.T) {
+	f := &fakeCrontab{}
+	c := &Cron{crontab: f.run}
+
+	if st, err := c.Status(); err != nil || st.Enabled {
+		t.Fatalf("Status() = %+v, %v", st, err)
+	}
+	if found, err := c.Disable(); found || err != nil {
+		t.Fatalf("Disable() = %v, %v", found, err)
+	}
+	if err := c.Enable(testJob); err != nil {
+		t.Fatalf("Enable() error: %v", err)
+	}
+	if !strings.HasPrefix(f.content, cronBegin+"\n0 12 * * 1 ") {
+		t.Fatalf("unexpected crontab\n%s", f.content)
+	}
+}
@@ -0,0 +1,189 @@
+package schedule
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"github.com/UnitVectorY-Labs/gogitup/internal/xdg"
+)
+
+// Names of the systemd units.
+const (
+	ServiceUnit = "gogitup.service"
+	TimerUnit   = "gogitup.timer"
+)
+
+// Systemd schedules the job with a systemd user service and timer. The timer
+// is persistent, so a run missed while the machine was off happens at the
+// next boot.
+type Systemd struct {
+	// Dir is the directory of the user's unit files.
+	Dir string
+	// systemctl runs systemctl --user with args.
+	systemctl func(args ...string) (string, error)
+}
+
+// NewSystemd creates a Systemd that writes units to the user unit directory
+// in $XDG_CONFIG_HOME.
+func NewSystemd() *Systemd {
+	return &Systemd{
+		Dir: filepath.Join(xdg.ConfigHome(), "systemd", "user"),
+		systemctl: func(args ...string) (string, error) {
+			return run("", "systemctl", append([]string{"--user"}, args...)...)
+		},
+	}
+}
+
+// Name implements Scheduler.
+func (s *Systemd) Name() string {
+	return "systemd user timer"
+}
+
+// Available implements Scheduler. It requires a running systemd user
+// instance, which containers and macOS lack.
+func (s *Systemd) Available() bool {
+	if _, err := exec.LookPath("systemctl"); err != nil {
+		return false
+	}
+	_, err := s.systemctl("show-environment")
+	return err == nil
+}
+
+// Enable implements Scheduler.
+func (s *Systemd) Enable(job Job) error {
+	if err := writeFile(filepath.Join(s.Dir, ServiceUnit), serviceUnit(job)); err != nil {
+		return err
+	}
+	if err := writeFile(filepath.Join(s.Dir, TimerUnit), timerUnit(job)); err != nil {
+		return err
+	}
+	if _, err := s.systemctl("daemon-reload"); err != nil {
+		return err
+	}
+	_, err := s.systemctl("enable", "--now", TimerUnit)
+	return err
+}
+
+// Disable implements Scheduler.
+func (s *Systemd) Disable() (bool, error) {
+	timer := filepath.Join(s.Dir, TimerUnit)
+	if _, err := os.Stat(timer); errors.Is(err, os.ErrNotExist) {
+		return false, nil
+	}
+	if _, err := s.systemctl("disable", "--now", TimerUnit); err != nil {
+		return true, err
+	}
+	for _, unit := range []string{TimerUnit, ServiceUnit} {
+		if err := os.Remove(filepath.Join(s.Dir, unit)); err != nil && !errors.Is(err, os.ErrNotExist) {
+			return true, err
+		}
+	}
+	_, err := s.systemctl("daemon-reload")
+	return true, err
+}
+
+// Status implements Scheduler.
+func (s *Systemd) Status() (Status, error) {
+	timer, err := os.ReadFile(filepath.Join(s.Dir, TimerUnit))
+	if errors.Is(err, os.ErrNotExist) {
+		return Status{}, nil
+	}
+	if err != nil {
+		return Status{}, err
+	}
+	service, err := os.ReadFile(filepath.Join(s.Dir, ServiceUnit))
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return Status{}, err
+	}
+
+	st := Status{
+		Enabled:  true,
+		Schedule: unitValue(string(timer), "OnCalendar"),
+		Command:  unitValue(string(service), "ExecStart"),
+	}
+	out, err := s.systemctl("show", TimerUnit, "--property=UnitFileState,NextElapseUSecRealtime,LastTriggerUSec")
+	if err != nil {
+		return st, err
+	}
+	props := unitProperties(out)
+	st.Enabled = props["UnitFileState"] == "enabled"
+	st.NextRun = props["NextElapseUSecRealtime"]
+	st.LastRun = props["LastTriggerUSec"]
+	return st, nil
+}
+
+// serviceUnit renders the service that runs the job once.
+func serviceUnit(job Job) string {
+	var b strings.Builder
+	b.WriteString("# Written by gogitup schedule enable.\n")
+	b.WriteString("[Unit]\n")
+	b.WriteString("Description=Keep Go-installed binaries up to date with gogitup\n")
+	b.WriteString("Wants=network-online.target\n")
+	b.WriteString("After=network-online.target\n\n")
+	b.WriteString("[Service]\n")
+	b.WriteString("Type=oneshot\n")
+	for _, env := range job.Env {
+		fmt.Fprintf(&b, "Environment=%s\n", systemdQuote(env, false))
+	}
+	args := make([]string, len(job.Command))
+	for i, arg := range job.Command {
+		args[i] = systemdQuote(arg, true)
+	}
+	fmt.Fprintf(&b, "ExecStart=%s\n", strings.Join(args, " "))
+	fmt.Fprintf(&b, "StandardOutput=append:%s\n", strings.ReplaceAll(job.LogFile, "%", "%%"))
+	b.WriteString("StandardError=inherit\n")
+	return b.String()
+}
+
+// timerUnit renders the timer that starts the service. The randomized delay
+// keeps many machines from hitting GitHub and the module proxy at once.
+func timerUnit(job Job) string {
+	return fmt.Sprintf(`# Written by gogitup schedule enable.
+[Unit]
+Description=Run gogitup %s
+
+[Timer]
+OnCalendar=%s
+Persistent=true
+RandomizedDelaySec=1h
+
+[Install]
+WantedBy=timers.target
+`, job.Frequency, job.Frequency)
+}
+
+// systemdQuote quotes s as a word of a unit file setting. Specifiers are
+// escaped, and so are environment variable references when exec is set,
+// since systemd expands them in ExecStart.
+func systemdQuote(s string, exec bool) string {
+	replacements := []string{`\`, `\\`, `"`, `\"`, "%", "%%", "\n", `\n`}
+	if exec {
+		replacements = append(replacements, "$", "$$")
+	}
+	return `"` + strings.NewReplacer(replacements...).Replace(s) + `"`
+}
+
+// unitValue returns the value of the first key= line in a unit file.
+func unitValue(unit, key string) string {
+	for _, line := range strings.Split(unit, "\n") {
+		if value, ok := strings.CutPrefix(line, key+"="); ok {
+			return value
+		}
+	}
+	return ""
+}
+
+// unitProperties parses the KEY=VALUE lines of systemctl show.
+func unitProperties(out string) map[string]string {
+	props := make(map[string]string)
+	for _, line := range strings.Split(out, "\n") {
+		if key, value, ok := strings.Cut(strings.TrimSpace(line), "="); ok {
+			props[key] = value
+		}
+	}
+	return props
+}
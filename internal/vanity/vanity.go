@@ -0,0 +1,203 @@
+// Package vanity resolves vanity import paths, such as honnef.co/go/tools, to
+// the repositories that serve them using the go-import and go-source meta tags
+// returned for ?go-get=1 requests.
+package vanity
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/UnitVectorY-Labs/gogitup/internal/cache"
+)
+
+// Repo describes the repository serving an import path.
+type Repo struct {
+	// Prefix is the import path of the repository root.
+	Prefix string
+	// RepoPath is the repository location from the go-import tag, such as
+	// "github.com/dominikh/go-tools", and SourcePath the source home from the
+	// go-source tag. Either may be empty.
+	RepoPath   string
+	SourcePath string
+}
+
+// Resolver resolves an import path to the repository serving it.
+type Resolver interface {
+	Resolve(importPath string) (Repo, error)
+}
+
+// ErrNoMeta is returned when a server does not describe an import path.
+var ErrNoMeta = errors.New("no go-import meta tag found")
+
+// maxBodySize bounds how much of a go-get page is read; the meta tags are in
+// the head.
+const maxBodySize = 1 << 20
+
+// DefaultResolver fetches https://<import path>?go-get=1.
+type DefaultResolver struct {
+	httpClient *http.Client
+}
+
+// NewDefaultResolver creates a DefaultResolver.
+func NewDefaultResolver() *DefaultResolver {
+	return &DefaultResolver{httpClient: &http.Client{Timeout: 10 * time.Second}}
+}
+
+// NewDefaultResolverWithClient creates a DefaultResolver that fetches go-get
+// pages with httpClient, such as one using a proxy.
+func NewDefaultResolverWithClient(httpClient *http.Client) *DefaultResolver {
+	return &DefaultResolver{httpClient: httpClient}
+}
+
+// Resolve fetches the go-get page for importPath and parses its meta tags.
+func (r *DefaultResolver) Resolve(importPath string) (Repo, error) {
+	resp, err := r.httpClient.Get("https://" + importPath + "?go-get=1")
+	if err != nil {
+		return Repo{}, fmt.Errorf("failed to resolve %s: %w", importPath, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return Repo{}, fmt.Errorf("resolving %s returned status %d", importPath, resp.StatusCode)
+	}
+	body, err := io.ReadAll(io.LimitReader(resp.Body, maxBodySize))
+	if err != nil {
+		return Repo{}, fmt.Errorf("failed to read go-get page for %s: %w", importPath, err)
+	}
+	return ParseMeta(body, importPath)
+}
+
+var (
+	metaTagRe = regexp.MustCompile(`(?is)<meta\s[^>]*>`)
+	attrRe    = regexp.MustCompile(`(?is)(name|content)\s*=\s*("[^"]*"|'[^']*')`)
+)
+
+// ParseMeta extracts the repository serving importPath from the go-import and
+// go-source meta tags of a go-get page.
+func ParseMeta(page []byte, importPath string) (Repo, error) {
+	var repo Repo
+	for _, tag := range metaTagRe.FindAll(page, -1) {
+		var name, content string
+		for _, m := range attrRe.FindAllSubmatch(tag, -1) {
+			value := strings.Trim(string(m[2]), `"'`)
+			if strings.EqualFold(string(m[1]), "name") {
+				name = value
+			} else {
+				content = value
+			}
+		}
+
+		fields := strings.Fields(content)
+		switch {
+		case name == "go-import" && len(fields) == 3 && fields[1] != "mod" && matchesPrefix(importPath, fields[0]):
+			repo.Prefix = fields[0]
+			repo.RepoPath = repoPath(fields[2])
+		case name == "go-source" && len(fields) >= 2 && matchesPrefix(importPath, fields[0]):
+			repo.SourcePath = repoPath(fields[1])
+		}
+	}
+	if repo.Prefix == "" {
+		return Repo{}, ErrNoMeta
+	}
+	return repo, nil
+}
+
+func matchesPrefix(importPath, prefix string) bool {
+	return importPath == prefix || strings.HasPrefix(importPath, prefix+"/")
+}
+
+// repoPath converts a repository URL such as https://github.com/owner/repo.git
+// to "github.com/owner/repo", returning "" for URLs it cannot use.
+func repoPath(rawURL string) string {
+	u, err := url.Parse(rawURL)
+	if err != nil || u.Host == "" || (u.Scheme != "https" && u.Scheme != "http") {
+		return ""
+	}
+	path := strings.TrimSuffix(strings.Trim(u.Path, "/"), ".git")
+	if path == "" {
+		return ""
+	}
+	return u.Host + "/" + path
+}
+
+// CachedResolver remembers resolutions in the gogitup cache, including import
+// paths that have no meta tags, so each path is fetched at most once per
+// cache.ResolutionTTL.
+type CachedResolver struct {
+	resolver Resolver
+	cache    *cache.Cache
+}
+
+// NewCachedResolver wraps resolver with the cache c.
+func NewCachedResolver(resolver Resolver, c *cache.Cache) *CachedResolver {
+	return &CachedResolver{resolver: resolver, cache: c}
+}
+
+// Resolve returns the cached resolution of importPath, resolving it when it is
+// missing or expired. Network failures are not cached.
+func (r *CachedResolver) Resolve(importPath string) (Repo, error) {
+	if res, ok := cache.GetResolution(r.cache, importPath); ok {
+		if res.Prefix == "" {
+			return Repo{}, ErrNoMeta
+		}
+		return Repo{Prefix: res.Prefix, RepoPath: res.RepoPath, SourcePath: res.SourcePath}, nil
+	}
+
+	repo, err := r.resolver.Resolve(importPath)
+	switch {
+	case errors.Is(err, ErrNoMeta):
+		cache.SetResolution(r.cache, importPath, cache.Resolution{})
+		return Repo{}, err
+	case err != nil:
+		return Repo{}, err
+	}
+	cache.SetResolution(r.cache, importPath, cache.Resolution{Prefix: repo.Prefix, RepoPath: repo.RepoPath, SourcePath: repo.SourcePath})
+	return repo, nil
+}
+
+var (
+	moduleVersionRe = regexp.MustCompile(`^v([0-9]+)\.[0-9]+\.[0-9]+(-[0-9A-Za-z.-]+)?(\+[0-9A-Za-z.-]+)?$`)
+	majorSuffixRe   = regexp.MustCompile(`(^|/)v([0-9]+)$`)
+)
+
+// ModuleVersion converts a release tag of the repository rooted at prefix to
+// a version of the module at modulePath. Modules in a subdirectory of the
+// repository use tags prefixed with that directory, such as "gopls/v0.16.0".
+// It reports false when the tag is not a version of the module, for example
+// because it belongs to another module in the repository, is not a semantic
+// version, or has a different major version.
+func ModuleVersion(modulePath, prefix, tag string) (string, bool) {
+	if !matchesPrefix(modulePath, prefix) {
+		return "", false
+	}
+	dir := strings.TrimPrefix(strings.TrimPrefix(modulePath, prefix), "/")
+
+	major := "1"
+	if m := majorSuffixRe.FindStringSubmatch(dir); m != nil {
+		major = m[2]
+		dir = strings.TrimSuffix(strings.TrimSuffix(dir, m[0]), "/")
+	}
+
+	version := tag
+	if dir != "" {
+		var ok bool
+		if version, ok = strings.CutPrefix(tag, dir+"/"); !ok {
+			return "", false
+		}
+	}
+
+	m := moduleVersionRe.FindStringSubmatch(version)
+	if m == nil {
+		return "", false
+	}
+	if m[1] != major && !(major == "1" && m[1] == "0") {
+		return "", false
+	}
+	return version, true
+}
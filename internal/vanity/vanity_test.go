@@ -0,0 +1,134 @@
+package vanity
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/UnitVectorY-Labs/gogitup/internal/cache"
+)
+
+const goGetPage = `<!DOCTYPE html>
+<html>
+<head>
+<meta name="go-import" content="golang.org/x/tools mod https://proxy.golang.org">
+<meta name="go-import" content="golang.org/x/tools git https://go.googlesource.com/tools">
+<meta name='go-source' content='golang.org/x/tools https://github.com/golang/tools/ https://github.com/golang/tools/tree/master{/dir} https://github.com/golang/tools/blob/master{/dir}/{file}#L{line}'>
+</head>
+</html>`
+
+func TestParseMeta(t *testing.T) {
+	repo, err := ParseMeta([]byte(goGetPage), "golang.org/x/tools/gopls")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if repo.Prefix != "golang.org/x/tools" {
+		t.Fatalf("expected prefix golang.org/x/tools, got %q", repo.Prefix)
+	}
+	if repo.RepoPath != "go.googlesource.com/tools" {
+		t.Fatalf("expected repo path go.googlesource.com/tools, got %q", repo.RepoPath)
+	}
+	if repo.SourcePath != "github.com/golang/tools" {
+		t.Fatalf("expected source path github.com/golang/tools, got %q", repo.SourcePath)
+	}
+}
+
+func TestParseMetaNoMatchingPrefix(t *testing.T) {
+	if _, err := ParseMeta([]byte(goGetPage), "golang.org/x/toolsmith"); !errors.Is(err, ErrNoMeta) {
+		t.Fatalf("expected ErrNoMeta, got %v", err)
+	}
+	if _, err := ParseMeta([]byte("<html></html>"), "example.com/tool"); !errors.Is(err, ErrNoMeta) {
+		t.Fatalf("expected ErrNoMeta, got %v", err)
+	}
+}
+
+func TestDefaultResolverFetchesGoGetPage(t *testing.T) {
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("go-get") != "1" {
+			t.Errorf("expected go-get=1 query, got %q", r.URL.RawQuery)
+		}
+		host := r.Host
+		w.Write([]byte(`<meta name="go-import" content="` + host + `/tool git https://github.com/owner/tool.git">`))
+	}))
+	defer server.Close()
+
+	host := strings.TrimPrefix(server.URL, "https://")
+	r := &DefaultResolver{httpClient: server.Client()}
+	repo, err := r.Resolve(host + "/tool/cmd/tool")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if repo.Prefix != host+"/tool" || repo.RepoPath != "github.com/owner/tool" {
+		t.Fatalf("unexpected repo: %+v", repo)
+	}
+}
+
+type stubResolver struct {
+	repo  Repo
+	err   error
+	calls int
+}
+
+func (s *stubResolver) Resolve(importPath string) (Repo, error) {
+	s.calls++
+	return s.repo, s.err
+}
+
+func TestCachedResolver(t *testing.T) {
+	c := &cache.Cache{Entries: make(map[string]cache.Entry)}
+	stub := &stubResolver{repo: Repo{Prefix: "honnef.co/go/tools", RepoPath: "github.com/dominikh/go-tools"}}
+	r := NewCachedResolver(stub, c)
+
+	for range 2 {
+		repo, err := r.Resolve("honnef.co/go/tools")
+		if err != nil || repo.RepoPath != "github.com/dominikh/go-tools" {
+			t.Fatalf("unexpected result: %+v (err=%v)", repo, err)
+		}
+	}
+	if stub.calls != 1 {
+		t.Fatalf("expected one lookup, got %d", stub.calls)
+	}
+
+	stub.err = ErrNoMeta
+	for range 2 {
+		if _, err := r.Resolve("example.com/plain"); !errors.Is(err, ErrNoMeta) {
+			t.Fatalf("expected ErrNoMeta, got %v", err)
+		}
+	}
+	if stub.calls != 2 {
+		t.Fatalf("expected paths without meta tags to be cached, got %d lookups", stub.calls)
+	}
+
+	stub.err = errors.New("network down")
+	r.Resolve("example.com/offline")
+	r.Resolve("example.com/offline")
+	if stub.calls != 4 {
+		t.Fatalf("expected network failures not to be cached, got %d lookups", stub.calls)
+	}
+}
+
+func TestModuleVersion(t *testing.T) {
+	tests := []struct {
+		modulePath, prefix, tag string
+		want                    string
+		wantOK                  bool
+	}{
+		{modulePath: "honnef.co/go/tools", prefix: "honnef.co/go/tools", tag: "v0.5.1", want: "v0.5.1", wantOK: true},
+		{modulePath: "honnef.co/go/tools", prefix: "honnef.co/go/tools", tag: "2024.1.1"},
+		{modulePath: "golang.org/x/tools/gopls", prefix: "golang.org/x/tools", tag: "gopls/v0.16.2", want: "v0.16.2", wantOK: true},
+		{modulePath: "golang.org/x/tools/gopls", prefix: "golang.org/x/tools", tag: "v0.24.0"},
+		{modulePath: "example.com/mod/v2", prefix: "example.com/mod", tag: "v2.3.0", want: "v2.3.0", wantOK: true},
+		{modulePath: "example.com/mod/v2", prefix: "example.com/mod", tag: "v1.9.0"},
+		{modulePath: "example.com/mod", prefix: "example.com/mod", tag: "v3.0.0"},
+		{modulePath: "example.com/other", prefix: "example.com/mod", tag: "v1.0.0"},
+	}
+
+	for _, tc := range tests {
+		got, ok := ModuleVersion(tc.modulePath, tc.prefix, tc.tag)
+		if got != tc.want || ok != tc.wantOK {
+			t.Errorf("ModuleVersion(%q, %q, %q) = %q, %v; want %q, %v", tc.modulePath, tc.prefix, tc.tag, got, ok, tc.want, tc.wantOK)
+		}
+	}
+}
@@ -0,0 +1,103 @@
+package xdg
+
+import (
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+)
+
+// ConfigHome returns $XDG_CONFIG_HOME, defaulting to ~/.config.
+func ConfigHome() string {
+	return baseDir("XDG_CONFIG_HOME", ".config")
+}
+
+// CacheHome returns $XDG_CACHE_HOME, defaulting to ~/.cache.
+func CacheHome() string {
+	return baseDir("XDG_CACHE_HOME", ".cache")
+}
+
+// StateHome returns $XDG_STATE_HOME, defaulting to ~/.local/state.
+func StateHome() string {
+	return baseDir("XDG_STATE_HOME", filepath.Join(".local", "state"))
+}
+
+func baseDir(env, fallback string) string {
+	// The XDG specification requires relative paths to be ignored.
+	if dir := os.Getenv(env); filepath.IsAbs(dir) {
+		return dir
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return filepath.Join(".", fallback)
+	}
+	return filepath.Join(home, fallback)
+}
+
+// HomePath returns name joined to the user's home directory, or to the
+// current directory when the home directory cannot be determined.
+func HomePath(name string) string {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return filepath.Join(".", name)
+	}
+	return filepath.Join(home, name)
+}
+
+// Resolve returns the path of a gogitup file: the value of the override
+// environment variable when set, otherwise the XDG path. The legacy path is
+// returned instead while it exists and the XDG path does not, so a file that
+// has not been migrated yet is still found.
+func Resolve(overrideEnv, xdgPath, legacyPath string) string {
+	if path := os.Getenv(overrideEnv); path != "" {
+		return path
+	}
+	if exists(legacyPath) && !exists(xdgPath) {
+		return legacyPath
+	}
+	return xdgPath
+}
+
+// Migrate moves legacyPath to xdgPath when the legacy file exists and the XDG
+// file does not. It reports whether a file was moved.
+func Migrate(xdgPath, legacyPath string) (bool, error) {
+	if !exists(legacyPath) || exists(xdgPath) {
+		return false, nil
+	}
+	if err := os.MkdirAll(filepath.Dir(xdgPath), 0700); err != nil {
+		return false, err
+	}
+	if err := os.Rename(legacyPath, xdgPath); err == nil {
+		return true, nil
+	}
+
+	// Fall back to copying when the paths are on different filesystems.
+	if err := copyFile(legacyPath, xdgPath); err != nil {
+		return false, err
+	}
+	return true, os.Remove(legacyPath)
+}
+
+func copyFile(src, dst string) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+
+	out, err := os.OpenFile(dst, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0600)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(out, in); err != nil {
+		out.Close()
+		os.Remove(dst)
+		return err
+	}
+	return out.Close()
+}
+
+func exists(path string) bool {
+	_, err := os.Stat(path)
+	return err == nil || !errors.Is(err, os.ErrNotExist)
+}
@@ -0,0 +1,84 @@
+package xdg
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestConfigHome(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+
+	t.Setenv("XDG_CONFIG_HOME", "")
+	if got := ConfigHome(); got != filepath.Join(home, ".config") {
+		t.Fatalf("expected default config home, got %s", got)
+	}
+
+	t.Setenv("XDG_CONFIG_HOME", "relative/dir")
+	if got := ConfigHome(); got != filepath.Join(home, ".config") {
+		t.Fatalf("expected relative XDG_CONFIG_HOME to be ignored, got %s", got)
+	}
+
+	t.Setenv("XDG_CONFIG_HOME", "/xdg/config")
+	if got := ConfigHome(); got != "/xdg/config" {
+		t.Fatalf("expected /xdg/config, got %s", got)
+	}
+}
+
+func TestResolve(t *testing.T) {
+	dir := t.TempDir()
+	xdgPath := filepath.Join(dir, "xdg", "config.yaml")
+	legacyPath := filepath.Join(dir, ".legacy")
+	t.Setenv("GOGITUP_TEST_PATH", "")
+
+	if got := Resolve("GOGITUP_TEST_PATH", xdgPath, legacyPath); got != xdgPath {
+		t.Fatalf("expected XDG path without legacy file, got %s", got)
+	}
+
+	if err := os.WriteFile(legacyPath, []byte("apps: []\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if got := Resolve("GOGITUP_TEST_PATH", xdgPath, legacyPath); got != legacyPath {
+		t.Fatalf("expected legacy path before migration, got %s", got)
+	}
+
+	t.Setenv("GOGITUP_TEST_PATH", "/override")
+	if got := Resolve("GOGITUP_TEST_PATH", xdgPath, legacyPath); got != "/override" {
+		t.Fatalf("expected override path, got %s", got)
+	}
+}
+
+func TestMigrate(t *testing.T) {
+	dir := t.TempDir()
+	xdgPath := filepath.Join(dir, "xdg", "gogitup", "config.yaml")
+	legacyPath := filepath.Join(dir, ".gogitup")
+
+	moved, err := Migrate(xdgPath, legacyPath)
+	if err != nil || moved {
+		t.Fatalf("expected no migration without legacy file, got moved=%t err=%v", moved, err)
+	}
+
+	if err := os.WriteFile(legacyPath, []byte("apps: []\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	moved, err = Migrate(xdgPath, legacyPath)
+	if err != nil || !moved {
+		t.Fatalf("expected migration, got moved=%t err=%v", moved, err)
+	}
+	if _, err := os.Stat(legacyPath); !os.IsNotExist(err) {
+		t.Fatalf("expected legacy file to be removed, got %v", err)
+	}
+	data, err := os.ReadFile(xdgPath)
+	if err != nil || string(data) != "apps: []\n" {
+		t.Fatalf("unexpected migrated content %q (err=%v)", data, err)
+	}
+
+	if err := os.WriteFile(legacyPath, []byte("other"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	moved, err = Migrate(xdgPath, legacyPath)
+	if err != nil || moved {
+		t.Fatalf("expected existing XDG file to be kept, got moved=%t err=%v", moved, err)
+	}
+}